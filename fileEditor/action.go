@@ -1,6 +1,8 @@
 package fileeditor
 
 import (
+	"strings"
+
 	"github.com/Asiandayboy/CLITextEditor/util/ansi"
 	"github.com/Asiandayboy/CLITextEditor/util/math"
 )
@@ -84,8 +86,8 @@ func (f *FileEditor) SnapACXToTabBoundary(currBufferLine int, currACX int, direc
 		ret = start + EditorLeftMargin - f.ViewportOffsetX
 	}
 
-	if f.SoftWrap && ret > (f.TermWidth) {
-		return ret % (f.TermWidth - EditorLeftMargin), tabInfo
+	if f.SoftWrap && ret > (f.EditorWidth) {
+		return ret % (f.EditorWidth - EditorLeftMargin), tabInfo
 	}
 
 	return ret, tabInfo
@@ -117,12 +119,12 @@ func constrainCursorX(f *FileEditor, direction uint8) {
 			if direction == upDirection {
 				prevLine := f.VisualBuffer[visualLineIdx+1]
 				if len(currLine) > len(prevLine) {
-					f.ViewportOffsetX = lineLength - f.TermWidth
+					f.ViewportOffsetX = lineLength - f.EditorWidth
 				}
 			} else if direction == downDirection && visualLineIdx > 0 {
 				prevLine := f.VisualBuffer[visualLineIdx-1]
 				if len(currLine) > len(prevLine) {
-					f.ViewportOffsetX = lineLength - f.TermWidth
+					f.ViewportOffsetX = lineLength - f.EditorWidth
 				}
 			}
 		}
@@ -137,7 +139,7 @@ func constrainCursorX(f *FileEditor, direction uint8) {
 	}
 
 	if !f.SoftWrap {
-		f.apparentCursorX, _ = f.SnapACXToTabBoundary(visualLineIdx, f.apparentCursorX, direction)
+		f.apparentCursorX, _ = f.SnapACXToTabBoundary(f.lineAtRow(visualLineIdx), f.apparentCursorX, direction)
 	} else {
 		bufferLine := CalcBufferLineFromACY(f.apparentCursorY, f.VisualBufferMapped, f.ViewportOffsetY)
 
@@ -155,7 +157,7 @@ func constrainCursorX(f *FileEditor, direction uint8) {
 				f.bufferLine, f.VisualBuffer, f.VisualBufferMapped, f.ViewportOffsetY,
 			)
 
-			if bufIdx <= f.TermWidth && tabInfo != empty {
+			if bufIdx <= f.EditorWidth && tabInfo != empty {
 				if f.apparentCursorX-EditorLeftMargin > 1 {
 					if direction == downDirection {
 						f.DecrementCursorY()
@@ -186,19 +188,19 @@ func (f *FileEditor) MoveToTabBoundary(tabInfoArr []TabInfo, currACX int, cursor
 	if cursorDirection == "left" {
 		tabInfo, err := GetTabInfoByIndex(tabInfoArr, bufferIndex, false)
 		if err != nil {
-			return math.Clamp(currACX, EditorLeftMargin, f.TermWidth)
+			return math.Clamp(currACX, EditorLeftMargin, f.EditorWidth)
 		}
 
 		tabWidth := tabInfo.TabWidth()
-		return math.Clamp(currACX-tabWidth+1, EditorLeftMargin, f.TermWidth)
+		return math.Clamp(currACX-tabWidth+1, EditorLeftMargin, f.EditorWidth)
 	} else {
 		tabInfo, err := GetTabInfoByIndex(tabInfoArr, bufferIndex, false)
 		if err != nil {
-			return math.Clamp(currACX+1, EditorLeftMargin, f.TermWidth)
+			return math.Clamp(currACX+1, EditorLeftMargin, f.EditorWidth)
 		}
 
 		tabWidth := tabInfo.TabWidth()
-		return math.Clamp(currACX+tabWidth, EditorLeftMargin, f.TermWidth)
+		return math.Clamp(currACX+tabWidth, EditorLeftMargin, f.EditorWidth)
 	}
 }
 
@@ -242,7 +244,7 @@ func (f *FileEditor) SetCursorPositionOnClick(m MouseInput) byte {
 	x := math.Clamp(m.X, EditorLeftMargin, currLineLen+EditorLeftMargin-f.ViewportOffsetX)
 
 	if !f.SoftWrap {
-		x, _ = f.SnapACXToTabBoundary(currBufferLine, x, noDirection)
+		x, _ = f.SnapACXToTabBoundary(f.lineAtRow(currBufferLine), x, noDirection)
 	} else {
 		x, _ = f.SnapACXToTabBoundary(CalcBufferLineFromACY(m.Y, f.VisualBufferMapped, f.ViewportOffsetY), x, noDirection)
 	}
@@ -279,7 +281,7 @@ func (f *FileEditor) actionCursorLeft() {
 					if f.apparentCursorY == 1 && f.ViewportOffsetY > 0 {
 						f.actionScrollUp()
 					}
-					f.apparentCursorX = f.TermWidth - (EditorLeftMargin - softWrapTabDif)
+					f.apparentCursorX = f.EditorWidth - (EditorLeftMargin - softWrapTabDif)
 				} else {
 					f.apparentCursorX = f.MoveToTabBoundary(tabInfoArr, f.apparentCursorX-1, "left")
 				}
@@ -292,7 +294,7 @@ func (f *FileEditor) actionCursorLeft() {
 			f.apparentCursorX = len(line) + EditorLeftMargin
 			if !f.SoftWrap && len(line) >= f.GetViewportWidth() { // scroll screen to end of line if line past screen
 				f.ViewportOffsetX = len(line) - f.GetViewportWidth() + 1
-				f.apparentCursorX = f.TermWidth
+				f.apparentCursorX = f.EditorWidth
 			}
 		} else if f.apparentCursorY == 1 && f.ViewportOffsetY > 0 { // begin scrolling up and moving to end of line
 			f.actionScrollUp()
@@ -300,7 +302,7 @@ func (f *FileEditor) actionCursorLeft() {
 			f.apparentCursorX = len(line) + EditorLeftMargin
 			if len(line) > f.GetViewportWidth() {
 				f.ViewportOffsetX = len(line) - f.GetViewportWidth() + 1
-				f.apparentCursorX = f.TermWidth
+				f.apparentCursorX = f.EditorWidth
 			}
 		}
 	}
@@ -321,7 +323,7 @@ func (f *FileEditor) actionCursorRight() {
 
 		tabInfo, err := GetTabInfoByIndex(tabInfoArr, f.bufferIndex, false)
 		if err != nil {
-			if f.apparentCursorX == f.TermWidth {
+			if f.apparentCursorX == f.EditorWidth {
 				f.ViewportOffsetX++
 			} else {
 				f.apparentCursorX = f.MoveToTabBoundary(tabInfoArr, f.apparentCursorX, "right")
@@ -333,18 +335,18 @@ func (f *FileEditor) actionCursorRight() {
 		tabWidth := tabInfo.TabWidth()
 		softWrapTabDif := f.apparentCursorX + tabWidth
 
-		if f.apparentCursorX == f.TermWidth {
+		if f.apparentCursorX == f.EditorWidth {
 			f.ViewportOffsetX += tabWidth
 		} else {
-			if f.SoftWrap && softWrapTabDif > f.TermWidth { // adding a tab that continues to the next line
+			if f.SoftWrap && softWrapTabDif > f.EditorWidth { // adding a tab that continues to the next line
 				f.IncrementCursorY()
 				if f.apparentCursorY == f.GetViewportHeight() && f.apparentCursorY+f.ViewportOffsetY <= len(f.VisualBuffer) {
 					f.actionScrollDown()
 				}
-				f.apparentCursorX = EditorLeftMargin + (softWrapTabDif - f.TermWidth)
+				f.apparentCursorX = EditorLeftMargin + (softWrapTabDif - f.EditorWidth)
 			} else {
-				if f.apparentCursorX+tabWidth > f.TermWidth {
-					f.ViewportOffsetX += (f.apparentCursorX + tabWidth - f.TermWidth)
+				if f.apparentCursorX+tabWidth > f.EditorWidth {
+					f.ViewportOffsetX += (f.apparentCursorX + tabWidth - f.EditorWidth)
 				}
 				f.apparentCursorX = f.MoveToTabBoundary(tabInfoArr, f.apparentCursorX, "right")
 			}
@@ -401,46 +403,39 @@ func (f *FileEditor) actionScrollUp() {
 /*
 Adds a new line by mutating the FileBuffer
 */
+/*
+Splits the line at the cursor. The new line is indented as NewLineIndent
+decides, and a closer right after the cursor goes on a line of its own
+*/
 func (f *FileEditor) actionNewLine() byte {
-	line := f.FileBuffer[f.bufferLine]
-	n := len(f.FileBuffer)
-
-	actualBufferIndex := AlignBufferIndex(f.bufferIndex, f.bufferLine, f.TabMap)
-
-	// split the current line
-	beforeSplit := line[:actualBufferIndex]
-	afterSplit := line[actualBufferIndex:]
-
-	// insert the new line (afterSplit) in the middle of buffer array
-	result := make([]string, n+1)
-
-	copy(result, f.FileBuffer[:f.bufferLine])
-
-	result[f.bufferLine] = beforeSplit
-	result[f.bufferLine+1] = afterSplit
+	pos := f.cursorPos()
+	line := f.FileBuffer[pos.Line]
+
+	// marks on the line move down with its text when the whole line is moved down
+	if pos.Index == 0 {
+		f.ReplaceLines(pos.Line, 0, []string{""})
+		f.MoveCursorToBufferPos(pos.Line+1, 0)
+		return EnumNewLineInserted
+	}
 
-	copy(result[f.bufferLine+2:], f.FileBuffer[f.bufferLine+1:])
+	before, after := line[:pos.Index], line[pos.Index:]
+	indent, closed := NewLineIndent(before, after, FiletypeFor(f.Filename), f.indentUnit())
 
-	f.FileBuffer = result
+	// a line left with only its indentation is cleared
+	if isBlankLine(before) {
+		before = ""
+	}
+	after = strings.TrimLeft(after, " \t")
 
-	// update cursor position
-	if actualBufferIndex == len(line) { // inserting new line at the end of a line
-		if f.SoftWrap {
-			f.RefreshSoftWrapVisualBuffers()
-		} else {
-			f.RefreshNoWrapVisualBuffers()
-		}
-		f.actionCursorDown()
-		setSavedCursorX(f.apparentCursorX, f.ViewportOffsetX, false)
-		return NewLineInsertedAtLineEnd
+	lines := []string{before, indent + after}
+	if closed {
+		lines = []string{before, indent, before[:FirstNonBlank(before)] + after}
 	}
 
-	f.IncrementCursorY()
-	f.ViewportOffsetX = 0
-	f.apparentCursorX = EditorLeftMargin
-	setSavedCursorX(f.apparentCursorX, f.ViewportOffsetX, false)
+	f.ReplaceLines(pos.Line, 1, lines)
+	f.MoveCursorToBufferPos(pos.Line+1, len(indent))
 
-	return NewLineInserted
+	return EnumNewLineInserted
 }
 
 func (f *FileEditor) actionTyping(key byte) {
@@ -454,7 +449,7 @@ func (f *FileEditor) actionTyping(key byte) {
 	f.FileBuffer[f.bufferLine] = before + string(key) + after
 	f.apparentCursorX++
 
-	if f.apparentCursorX > f.TermWidth {
+	if f.apparentCursorX > f.EditorWidth {
 		if f.SoftWrap {
 			f.apparentCursorX = EditorLeftMargin + 1
 			f.IncrementCursorY()
@@ -463,7 +458,7 @@ func (f *FileEditor) actionTyping(key byte) {
 				f.ViewportOffsetY++ // changing it directly bc the condition in actionScrollDown doesn't apply here, ig
 			}
 		} else {
-			f.apparentCursorX = f.TermWidth
+			f.apparentCursorX = f.EditorWidth
 			f.ViewportOffsetX++
 		}
 	}
@@ -504,12 +499,12 @@ func (f *FileEditor) actionInsertTab() {
 
 		f.apparentCursorX += tabWidth
 
-		if f.apparentCursorX > f.TermWidth {
+		if f.apparentCursorX > f.EditorWidth {
 			/*
 				We need to know how much the tab exceeded the terminal width; we'll take that
 				value and add it to the cursor or viewport offset, depending if soft wrap is on or not
 			*/
-			tabDif := f.apparentCursorX - f.TermWidth
+			tabDif := f.apparentCursorX - f.EditorWidth
 
 			if f.SoftWrap {
 				// BUG WHEN INSERTING TAB WITH SOFT WRAP ON
@@ -525,7 +520,7 @@ func (f *FileEditor) actionInsertTab() {
 					f.ViewportOffsetY++ // changing it directly bc the condition in actionScrollDown doesn't apply here, ig
 				}
 			} else {
-				f.apparentCursorX = f.TermWidth
+				f.apparentCursorX = f.EditorWidth
 				f.ViewportOffsetX += tabDif
 			}
 		}
@@ -553,14 +548,15 @@ func (f *FileEditor) actionDeleteText() {
 			f.apparentCursorX = math.Clamp(
 				len(prevLine)+EditorLeftMargin,
 				EditorLeftMargin,
-				f.TermWidth,
+				f.EditorWidth,
 			)
 			f.ViewportOffsetX = math.Clamp(
-				len(prevLine)+EditorLeftMargin-f.TermWidth,
+				len(prevLine)+EditorLeftMargin-f.EditorWidth,
 				0,
-				len(prevLine)+EditorLeftMargin-f.TermWidth,
+				len(prevLine)+EditorLeftMargin-f.EditorWidth,
 			)
 		}
+		f.linesReplaced(f.bufferLine-1, 2, 1)
 		f.FileBuffer = append(f.FileBuffer[:f.bufferLine], f.FileBuffer[f.bufferLine+1:]...)
 		return
 	}
@@ -573,6 +569,7 @@ func (f *FileEditor) actionDeleteText() {
 		*/
 		f.FileBuffer[f.bufferLine-1] += currLine
 		f.FileBuffer = append(f.FileBuffer[:f.bufferLine], f.FileBuffer[f.bufferLine+1:]...)
+		f.linesReplaced(f.bufferLine-1, 2, 1)
 		if f.apparentCursorY == 1 && f.ViewportOffsetY > 0 {
 			f.actionScrollUp()
 		}
@@ -590,23 +587,23 @@ func (f *FileEditor) actionDeleteText() {
 				f.apparentCursorX = math.Clamp(
 					len(prevLine)+EditorLeftMargin-extraSpace,
 					EditorLeftMargin,
-					f.TermWidth-extraSpace,
+					f.EditorWidth-extraSpace,
 				)
 				f.ViewportOffsetX = math.Clamp(
-					len(prevLine)+EditorLeftMargin-f.TermWidth+extraSpace,
+					len(prevLine)+EditorLeftMargin-f.EditorWidth+extraSpace,
 					0,
-					len(prevLine)+EditorLeftMargin-f.TermWidth+extraSpace,
+					len(prevLine)+EditorLeftMargin-f.EditorWidth+extraSpace,
 				)
 			} else {
 				f.apparentCursorX = math.Clamp(
 					len(prevLine)+EditorLeftMargin,
 					EditorLeftMargin,
-					f.TermWidth,
+					f.EditorWidth,
 				)
 				f.ViewportOffsetX = math.Clamp(
-					len(prevLine)+EditorLeftMargin-f.TermWidth,
+					len(prevLine)+EditorLeftMargin-f.EditorWidth,
 					0,
-					len(prevLine)+EditorLeftMargin-f.TermWidth,
+					len(prevLine)+EditorLeftMargin-f.EditorWidth,
 				)
 			}
 		}
@@ -669,7 +666,7 @@ func (f *FileEditor) actionDeleteText() {
 				f.actionScrollUp()
 			}
 			if isDeletingTabKey {
-				f.apparentCursorX = f.TermWidth - (EditorLeftMargin - softWrapTabDif)
+				f.apparentCursorX = f.EditorWidth - (EditorLeftMargin - softWrapTabDif)
 			} else {
 				f.apparentCursorX = len(f.VisualBuffer[f.apparentCursorY-1+f.ViewportOffsetY]) + EditorLeftMargin
 			}
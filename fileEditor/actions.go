@@ -0,0 +1,293 @@
+package fileeditor
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+This file is responsible for the action registry, which lists everything the
+editor can do that isn't typing. Keybindings, commands typed in the command
+bar and the command palette all run actions through the registry, so a new
+action only has to be registered once to be reachable from all three.
+
+Commands that take an argument, like open or grep, are parsed by the command
+bar itself, since an action doesn't take arguments
+*/
+
+type Action struct {
+	Name        string // used to rebind the action
+	Description string
+	Command     string                   // runs the action from the command bar; empty if it has no command
+	DefaultKey  byte                     // 0 if the action isn't bound to a key by default
+	Handler     func(f *FileEditor) byte // returns the flag to send to the render loop
+}
+
+// every registered action, in the order they are listed in the command palette
+var actionRegistry []*Action
+
+/*
+Adds the action to the registry. Registering an action with
+the name of an existing one replaces the existing one
+*/
+func RegisterAction(a *Action) {
+	for i, existing := range actionRegistry {
+		if existing.Name == a.Name {
+			actionRegistry[i] = a
+			return
+		}
+	}
+
+	actionRegistry = append(actionRegistry, a)
+}
+
+/*
+Returns the action with the name, or nil
+*/
+func LookupAction(name string) *Action {
+	for _, a := range actionRegistry {
+		if a.Name == name {
+			return a
+		}
+	}
+
+	return nil
+}
+
+/*
+Returns the action run by the command, or nil
+*/
+func lookupCommand(command string) *Action {
+	if command == "" {
+		return nil
+	}
+
+	for _, a := range actionRegistry {
+		if a.Command == command {
+			return a
+		}
+	}
+
+	return nil
+}
+
+/*
+Runs the action with the name. Returns the flag that should
+be sent to the render loop, or 0 if there is no such action
+*/
+func (f *FileEditor) RunAction(name string) byte {
+	a := LookupAction(name)
+	if a == nil {
+		return 0
+	}
+
+	return a.Handler(f)
+}
+
+/*
+Returns a readable name for the key, like "Ctrl+T"
+*/
+func keyName(key byte) string {
+	switch {
+	case key == Tab:
+		return "Tab"
+	case key == NewLine:
+		return "Enter"
+	case key == Escape:
+		return "Esc"
+	case key == CtrlRBracket:
+		return "Ctrl+]"
+	case key == CtrlSlash:
+		return "Ctrl+/"
+	case key == Backspace:
+		return "Backspace"
+	case key == Space:
+		return "Space"
+	case key > 0 && key <= 26:
+		return "Ctrl+" + string('A'+key-1)
+	default:
+		return string(key)
+	}
+}
+
+/*
+Opens a picker that lists every action with its keybinding and its
+command, and runs the chosen action
+*/
+func (f *FileEditor) OpenCommandPalette() {
+	items := make([]PickerItem, len(actionRegistry))
+	for i, a := range actionRegistry {
+		details := make([]string, 0, 3)
+		if keys := f.Keybindings.KeysForAction(f.EditorMode, a.Name); keys != nil {
+			details = append(details, "["+keySequenceName(keys)+"]")
+		}
+		if a.Command != "" {
+			details = append(details, fmt.Sprintf("%q", a.Command))
+		}
+		details = append(details, a.Description)
+
+		items[i] = PickerItem{
+			Label:  a.Name,
+			Detail: strings.Join(details, " "),
+			Value:  a.Name,
+		}
+	}
+
+	f.OpenPicker(NewPicker("Commands", items, func(f *FileEditor, item PickerItem) byte {
+		return f.RunAction(item.Value.(string))
+	}))
+}
+
+func init() {
+	// actions that change what is shown, without editing the buffer
+	redraw := func(fn func(f *FileEditor)) func(f *FileEditor) byte {
+		return func(f *FileEditor) byte {
+			fn(f)
+			return EnumCursorPositionChange
+		}
+	}
+
+	actions := []*Action{
+		{ActionCommandPalette, "List every action and run one", CMDBAR_PALETTE, CtrlO,
+			redraw((*FileEditor).OpenCommandPalette)},
+		{ActionSave, "Write the buffer to its file", CMDBAR_SAVE, CtrlS,
+			redraw((*FileEditor).SaveFile)},
+		{ActionQuit, "Quit the editor, unless a buffer has unsaved changes", CMDBAR_QUIT, 0,
+			func(f *FileEditor) byte { return f.Quit(false) }},
+		{ActionForceQuit, "Quit the editor, discarding unsaved changes", CMDBAR_FORCE_QUIT, 0,
+			func(f *FileEditor) byte { return f.Quit(true) }},
+		{ActionUndo, "Undo the last change", CMDBAR_UNDO, 0,
+			redraw((*FileEditor).Undo)},
+		{ActionRedo, "Redo the last undone change", CMDBAR_REDO, CtrlR,
+			redraw((*FileEditor).Redo)},
+		{ActionToggleTextWrap, "Toggle soft wrapping of long lines", CMDBAR_TOGGLE_SOFTWRAP, 0,
+			func(f *FileEditor) byte { return f.ToggleSoftWrap(!f.SoftWrapEnabled) }},
+
+		{ActionFindFile, "Fuzzy find a file of the project and open it", CMDBAR_FIND_FILE, CtrlP,
+			redraw((*FileEditor).OpenFileFinder)},
+		{ActionToggleFileTree, "Show or hide the file tree", CMDBAR_FILE_TREE, CtrlT,
+			redraw((*FileEditor).ToggleFileTree)},
+		{ActionFocusSearchResults, "Focus the results of the last project search", "", 0,
+			redraw((*FileEditor).FocusGrepPanel)},
+
+		{ActionNextBuffer, "Switch to the next buffer", CMDBAR_BUFFER_NEXT, 0,
+			redraw((*FileEditor).NextBuffer)},
+		{ActionPrevBuffer, "Switch to the previous buffer", CMDBAR_BUFFER_PREV, 0,
+			redraw((*FileEditor).PrevBuffer)},
+		{ActionCloseBuffer, "Close the buffer, unless it has unsaved changes", CMDBAR_BUFFER_CLOSE, 0,
+			redraw(func(f *FileEditor) { f.CloseBuffer(false) })},
+		{ActionDiscardBuffer, "Close the buffer, discarding its unsaved changes", CMDBAR_BUFFER_DISCARD, 0,
+			redraw(func(f *FileEditor) { f.CloseBuffer(true) })},
+		{ActionListBuffers, "List the open buffers and switch to one", CMDBAR_BUFFER_LIST, 0,
+			redraw((*FileEditor).OpenBufferPicker)},
+
+		{ActionSplitWindow, "Split the window in two, one on top of the other", CMDBAR_SPLIT, 0,
+			redraw(func(f *FileEditor) { f.SplitWindow(SplitHorizontal) })},
+		{ActionVSplitWindow, "Split the window in two, side by side", CMDBAR_VSPLIT, 0,
+			redraw(func(f *FileEditor) { f.SplitWindow(SplitVertical) })},
+		{ActionCloseWindow, "Close the window", CMDBAR_CLOSE_WINDOW, 0,
+			redraw((*FileEditor).CloseWindow)},
+		{ActionNextWindow, "Focus the next window, or the windows if a panel is focused", CMDBAR_NEXT_WINDOW, CtrlW,
+			redraw(func(f *FileEditor) {
+				if f.fileTree != nil && f.fileTree.Focused {
+					f.unfocusFileTree()
+				} else if f.grepPanel != nil && f.grepPanel.Focused {
+					f.grepPanel.Focused = false
+				} else {
+					f.FocusNextWindow()
+				}
+			})},
+
+		{ActionNextHunk, "Move to the next changed hunk", CMDBAR_NEXT_HUNK, 0,
+			redraw((*FileEditor).actionNextHunk)},
+		{ActionPrevHunk, "Move to the previous changed hunk", CMDBAR_PREV_HUNK, 0,
+			redraw((*FileEditor).actionPrevHunk)},
+		{ActionRevertHunk, "Revert the changed hunk under the cursor", CMDBAR_REVERT_HUNK, 0,
+			redraw((*FileEditor).actionRevertHunk)},
+		{ActionToggleBlame, "Show or hide who last changed the line", CMDBAR_TOGGLE_BLAME, 0,
+			redraw((*FileEditor).ToggleBlame)},
+		{ActionDiffSaved, "Show the changes since the file was saved", "", 0,
+			redraw(func(f *FileEditor) {
+				if err := f.OpenDiffView(""); err != nil {
+					f.statusMessage = err.Error()
+				}
+			})},
+
+		{ActionWordLeft, "Move to the start of the previous word", "", 0,
+			redraw((*FileEditor).MoveWordLeft)},
+		{ActionWordRight, "Move to the start of the next word", "", 0,
+			redraw((*FileEditor).MoveWordRight)},
+		{ActionLineStart, "Move to the first non-blank character, or the start of the line", "", 0,
+			redraw((*FileEditor).MoveLineStart)},
+		{ActionLineEnd, "Move to the end of the line", "", 0,
+			redraw((*FileEditor).MoveLineEnd)},
+		{ActionPageUp, "Move up by the height of the window", "", 0,
+			redraw(func(f *FileEditor) { f.MovePage(false) })},
+		{ActionPageDown, "Move down by the height of the window", "", 0,
+			redraw(func(f *FileEditor) { f.MovePage(true) })},
+		{ActionBufferStart, "Move to the start of the buffer", "", 0,
+			redraw((*FileEditor).MoveBufferStart)},
+		{ActionBufferEnd, "Move to the end of the buffer", "", 0,
+			redraw((*FileEditor).MoveBufferEnd)},
+		{ActionParagraphUp, "Move to the blank line before the paragraph", "", 0,
+			redraw(func(f *FileEditor) { f.MoveParagraph(false) })},
+		{ActionParagraphDown, "Move to the blank line after the paragraph", "", 0,
+			redraw(func(f *FileEditor) { f.MoveParagraph(true) })},
+		{ActionMatchBracket, "Move to the bracket that matches the one under the cursor", "", CtrlRBracket,
+			redraw((*FileEditor).MoveMatchingBracket)},
+		{ActionGotoLine, "Go to a line, or a line and column like 12:5", "", CtrlG,
+			redraw((*FileEditor).OpenGotoPrompt)},
+		{ActionJumpBack, "Go back to where the cursor was before the last jump", "", CtrlB,
+			redraw((*FileEditor).JumpBack)},
+		{ActionJumpForward, "Go forward to the jump that was gone back from", "", CtrlF,
+			redraw((*FileEditor).JumpForward)},
+		{ActionToggleBookmark, "Add or remove a bookmark on the line", CMDBAR_BOOKMARK, CtrlK,
+			redraw((*FileEditor).ToggleBookmark)},
+		{ActionListBookmarks, "List the bookmarks and marks and go to one", CMDBAR_BOOKMARKS, 0,
+			redraw((*FileEditor).OpenBookmarkList)},
+		{ActionAddCursorAbove, "Add a cursor on the line above the cursors", "", 0,
+			redraw(func(f *FileEditor) { f.AddCursorVertical(false) })},
+		{ActionAddCursorBelow, "Add a cursor on the line below the cursors", "", 0,
+			redraw(func(f *FileEditor) { f.AddCursorVertical(true) })},
+		{ActionAddCursorsAtWord, "Add a cursor at every occurrence of the word under the cursor", "", CtrlL,
+			redraw((*FileEditor).AddCursorsAtWord)},
+		{ActionClearCursors, "Remove the secondary cursors", "", 0,
+			redraw((*FileEditor).ClearCursors)},
+
+		{ActionDuplicateLines, "Copy the lines of the cursors below them", CMDBAR_DUPLICATE, CtrlD,
+			redraw((*FileEditor).DuplicateLines)},
+		{ActionMoveLinesUp, "Move the lines of the cursors up", "", 0,
+			redraw(func(f *FileEditor) { f.MoveLines(false) })},
+		{ActionMoveLinesDown, "Move the lines of the cursors down", "", 0,
+			redraw(func(f *FileEditor) { f.MoveLines(true) })},
+		{ActionJoinLines, "Join the line with the next one, or the lines of the cursors", CMDBAR_JOIN, 0,
+			redraw(func(f *FileEditor) { f.JoinLines(2) })},
+		{ActionDeleteLines, "Delete the lines of the cursors", CMDBAR_DELETE_LINES, 0,
+			redraw((*FileEditor).DeleteLines)},
+
+		{ActionToggleComment, "Comment or uncomment the lines of the cursors", CMDBAR_COMMENT, CtrlSlash,
+			redraw((*FileEditor).ToggleComment)},
+		{ActionToggleBlockComment, "Wrap the lines of the cursors in a block comment, or unwrap them", CMDBAR_BLOCK_COMMENT, 0,
+			redraw((*FileEditor).ToggleBlockComment)},
+
+		{ActionToggleFold, "Open the fold on the line, or fold the block around the cursor", CMDBAR_TOGGLE_FOLD, 0,
+			redraw((*FileEditor).ToggleFold)},
+		{ActionFold, "Fold the block around the cursor", CMDBAR_FOLD, 0,
+			redraw((*FileEditor).CloseFold)},
+		{ActionUnfold, "Open the fold on the line", CMDBAR_UNFOLD, 0,
+			redraw((*FileEditor).OpenFold)},
+		{ActionFoldAll, "Fold every block of the buffer", CMDBAR_FOLD_ALL, 0,
+			redraw((*FileEditor).CloseAllFolds)},
+		{ActionUnfoldAll, "Open every fold of the buffer", CMDBAR_UNFOLD_ALL, 0,
+			redraw((*FileEditor).OpenAllFolds)},
+
+		{ActionCompleteNext, "Complete the word before the cursor, or select the next completion", "", 0,
+			redraw(func(f *FileEditor) { f.Complete(false) })},
+		{ActionCompletePrev, "Complete the word before the cursor, or select the previous completion", "", 0,
+			redraw(func(f *FileEditor) { f.Complete(true) })},
+	}
+
+	for _, a := range actions {
+		RegisterAction(a)
+	}
+}
@@ -0,0 +1,214 @@
+package fileeditor
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/diff"
+)
+
+/*
+This file is responsible for the inline blame view, which shows who last
+touched the line the cursor is on as virtual text at the end of the line.
+
+The blame is read from `git blame --porcelain` when the blame command is
+run. Since the FileBuffer can be edited afterwards, each line of the FileBuffer
+is mapped back to its blamed line with a diff, and lines that don't map to a
+blamed line are shown as not committed yet
+*/
+
+const blameUncommittedHash string = "0000000"
+
+var blameColor string = ansi.NewRGBColor(100, 100, 100).ToFgColorANSI()
+
+type BlameCommit struct {
+	Hash   string
+	Author string
+	Date   time.Time
+}
+
+type BlameLine struct {
+	Commit  *BlameCommit
+	Content string
+}
+
+/*
+Returns the short hash, author and date of the commit as a single string
+*/
+func (c BlameCommit) String() string {
+	if strings.HasPrefix(c.Hash, blameUncommittedHash) {
+		return "Not committed yet"
+	}
+
+	return fmt.Sprintf("%s, %s (%.7s)", c.Author, c.Date.Format("2006-01-02"), c.Hash)
+}
+
+/*
+Parses the output of `git blame --porcelain` into a BlameLine for
+each line of the file, in order.
+
+Each line of the porcelain output starts with a header of the form
+"<hash> <original line> <final line> [<lines in group>]". The first time a
+commit appears, the header is followed by information about the commit, such
+as "author <name>" and "author-time <unix timestamp>". Finally, the content
+of the line follows, prefixed by a tab character
+*/
+func ParseBlamePorcelain(output string) ([]BlameLine, error) {
+	commits := make(map[string]*BlameCommit)
+	lines := make([]BlameLine, 0)
+
+	var current *BlameCommit
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		if strings.HasPrefix(text, "\t") {
+			if current == nil {
+				return nil, fmt.Errorf("blame: line content without a header")
+			}
+			lines = append(lines, BlameLine{Commit: current, Content: text[1:]})
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			fields := strings.Fields(text)
+			if len(fields) < 3 || len(fields[0]) < 40 {
+				return nil, fmt.Errorf("blame: invalid header %q", text)
+			}
+
+			commit, exists := commits[fields[0]]
+			if !exists {
+				commit = &BlameCommit{Hash: fields[0]}
+				commits[fields[0]] = commit
+			}
+			current = commit
+			continue
+		}
+
+		key, value, _ := strings.Cut(text, " ")
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-time":
+			timestamp, err := strconv.ParseInt(value, 10, 64)
+			if err == nil {
+				current.Date = time.Unix(timestamp, 0)
+			}
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+/*
+Runs git blame on the saved version of the file
+*/
+func GitBlame(filename string) ([]BlameLine, error) {
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("git", "blame", "--porcelain", "--", filepath.Base(absPath))
+	cmd.Dir = filepath.Dir(absPath)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseBlamePorcelain(string(out))
+}
+
+/*
+Toggles the inline blame view. The blame is read again every
+time the view is turned on
+*/
+func (f *FileEditor) ToggleBlame() {
+	if f.blameEnabled {
+		f.blameEnabled = false
+		f.blameLines = nil
+		f.blameMapped = nil
+		return
+	}
+
+	lines, err := GitBlame(f.Filename)
+	if err != nil {
+		return
+	}
+
+	f.blameEnabled = true
+	f.blameLines = lines
+	f.refreshBlameMapping()
+}
+
+/*
+Maps each line of the FileBuffer to the index of its blamed line, or -1 for
+lines that have been added or modified since the blame was read
+*/
+func (f *FileEditor) refreshBlameMapping() {
+	if !f.blameEnabled {
+		return
+	}
+
+	blamed := make([]string, len(f.blameLines))
+	for i, l := range f.blameLines {
+		blamed[i] = l.Content
+	}
+
+	f.blameMapped = make([]int, len(f.FileBuffer))
+
+	var oldIdx, newIdx int = 0, 0
+	for _, h := range diff.Lines(blamed, f.FileBuffer) {
+		for newIdx < h.NewStart {
+			f.blameMapped[newIdx] = oldIdx
+			oldIdx++
+			newIdx++
+		}
+		for range h.NewLines {
+			f.blameMapped[newIdx] = -1
+			newIdx++
+		}
+		oldIdx += h.OldLines
+	}
+
+	for newIdx < len(f.FileBuffer) {
+		f.blameMapped[newIdx] = oldIdx
+		oldIdx++
+		newIdx++
+	}
+}
+
+/*
+Returns the virtual text that is rendered at the end of the line (0-indexed),
+truncated so that it fits in the space left in the viewport
+*/
+func (f FileEditor) blameVirtualText(line int, visualLineLength int) string {
+	if !f.blameEnabled || line >= len(f.blameMapped) {
+		return ""
+	}
+
+	var text string
+	if idx := f.blameMapped[line]; idx < 0 {
+		text = "Not committed yet"
+	} else {
+		text = f.blameLines[idx].Commit.String()
+	}
+
+	const padding int = 4
+	space := f.GetViewportWidth() - visualLineLength - padding - 1
+	if space <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%*s%s%s%s", padding, "", blameColor+Italic, TruncateText(text, space), Reset)
+}
@@ -2,6 +2,7 @@ package fileeditor
 
 import (
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/Asiandayboy/CLITextEditor/util/ansi"
 	"github.com/Asiandayboy/CLITextEditor/util/math"
@@ -19,6 +20,26 @@ func (t TabInfo) TabWidth() int {
 
 const TabInfoErrMsg string = "A tab character does not exist at the visual index"
 
+/*
+Returns the text cut to at most width characters,
+without splitting a multi-byte character
+*/
+func TruncateText(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	count := 0
+	for i := range text {
+		if count == width {
+			return text[:i]
+		}
+		count++
+	}
+
+	return text
+}
+
 type TabInfoErr struct {
 	msg string
 }
@@ -168,9 +189,15 @@ func (f *FileEditor) RefreshSoftWrapVisualBuffers() {
 	var end int = 1
 
 	viewportWidth := f.GetViewportWidth()
+	hidden := f.hiddenLines()
 
 	for i, line := range f.FileBuffer {
 		line = f.RenderTabCharWithSpaces(line, i)
+		if hidden != nil && hidden[i] { // a folded line ends where the line before it does
+			f.VisualBufferMapped = append(f.VisualBufferMapped, end-1)
+			continue
+		}
+
 		if len(line) >= viewportWidth {
 			wordWrappedLines := f.GetWordWrappedLines(line, viewportWidth)
 
@@ -186,28 +213,87 @@ func (f *FileEditor) RefreshSoftWrapVisualBuffers() {
 	}
 }
 
+/*
+Refreshes the visual buffers without soft wrap, where each line is a row. The
+VisualBufferMapped is only used when lines are folded, and is nil otherwise
+*/
 func (f *FileEditor) RefreshNoWrapVisualBuffers() {
 	f.VisualBufferMapped = nil
-	f.VisualBuffer = make([]string, len(f.FileBuffer))
+	f.VisualBuffer = make([]string, 0, len(f.FileBuffer))
 	f.TabMap = make(TabMapType)
+	hidden := f.hiddenLines()
 
 	for i, line := range f.FileBuffer {
 		line = f.RenderTabCharWithSpaces(line, i)
-		f.VisualBuffer[i] = line
+		if hidden == nil || !hidden[i] {
+			f.VisualBuffer = append(f.VisualBuffer, line)
+		}
+		if hidden != nil {
+			f.VisualBufferMapped = append(f.VisualBufferMapped, len(f.VisualBuffer))
+		}
+	}
+}
+
+/*
+Refreshes the visual buffers according to whether soft wrap is enabled or not
+*/
+func (f *FileEditor) RefreshVisualBuffers() {
+	if f.SoftWrapEnabled {
+		f.RefreshSoftWrapVisualBuffers()
+	} else {
+		f.RefreshNoWrapVisualBuffers()
+	}
+}
+
+/*
+Returns the visual index of the given index in a line of the FileBuffer,
+which accounts for tab characters being rendered with spaces
+*/
+func (f FileEditor) GetVisualIndex(line string, index int) int {
+	var visualIndex int = 0
+	for i := 0; i < index && i < len(line); i++ {
+		if line[i] == Tab {
+			visualIndex += f.GetSpaceWidthOfTabChar(visualIndex)
+		} else {
+			visualIndex++
+		}
+	}
+
+	return visualIndex
+}
+
+/*
+Returns the actual index in a line of the FileBuffer from its visual index,
+which is the inverse of GetVisualIndex. A visual index inside of a tab
+character returns the index of the tab
+*/
+func (f FileEditor) GetActualIndex(line string, visualIndex int) int {
+	var v int = 0
+	for i := 0; i < len(line); i++ {
+		if v >= visualIndex {
+			return i
+		}
+
+		if line[i] == Tab {
+			v += f.GetSpaceWidthOfTabChar(v)
+		} else {
+			v++
+		}
 	}
+
+	return len(line)
 }
 
 func (f *FileEditor) PrintBuffer() {
 	currRowColor := modeColors[f.EditorMode].ToFgColorANSI()
+	f.foldRegions() // for the fold markers in the gutter
 
 	/*
-		The status bar height is subtracted from the terminal height to avoid the unnecessary scrolling,
+		The viewport height already excludes the status bar to avoid the unnecessary scrolling,
 		which would truncate the beginning of the buffer; The maxHeight value will be decreased for each
 		word-wrapped line there is.
 	*/
-	var maxHeight int = f.TermHeight - f.StatusBarHeight
-
-	ansi.MoveCursor(0, 0)
+	var maxHeight int = f.GetViewportHeight()
 
 	var lastIdx int = -1 // only used for soft-wrap
 	var linesPrinted = 0
@@ -228,10 +314,22 @@ func (f *FileEditor) PrintBuffer() {
 			line = f.VisualBuffer[i][math.Min(f.ViewportOffsetX, len(f.VisualBuffer[i])):math.Min(
 				f.ViewportOffsetX+f.GetViewportWidth()-1, len(f.VisualBuffer[i]))]
 		}
-		ansi.EraseEntireLine()
+		f.eraseEditorRow(linesPrinted + 1)
 
 		if f.SoftWrap {
 			currIdx := CalcBufferLineFromACY(i+1, f.VisualBufferMapped, 0)
+			nextIdx := CalcBufferLineFromACY(i+2, f.VisualBufferMapped, 0)
+			isLastRow := currIdx != nextIdx || i+1 == f.VisualBufferMapped[len(f.VisualBufferMapped)-1]
+
+			if isLastRow {
+				width := utf8.RuneCountInString(line)
+				fold, foldWidth := f.foldVirtualText(currIdx, width)
+				line += fold
+				if f.bufferLine == currIdx {
+					line += f.blameVirtualText(currIdx, width+foldWidth)
+				}
+			}
+
 			if lastIdx == currIdx {
 				if f.bufferLine == currIdx {
 					fmt.Printf("%s   %s", lineNumColor, currRowColor)
@@ -239,27 +337,31 @@ func (f *FileEditor) PrintBuffer() {
 					fmt.Printf("%s   %s", lineNumColor, wrappedColor)
 				}
 
-				nextIdx := CalcBufferLineFromACY(i+2, f.VisualBufferMapped, 0)
-
-				if currIdx != nextIdx || i+1 == f.VisualBufferMapped[len(f.VisualBufferMapped)-1] {
-					fmt.Printf("%s %s%s%s %s\n", BotLCorner, borderColor, Vertical, Reset, line)
+				if isLastRow {
+					fmt.Printf("%s%s%s%s%s %s", BotLCorner, f.gutterMarker(currIdx), borderColor, Vertical, Reset, line)
 				} else {
-					fmt.Printf("%s %s%s%s %s\n", Vertical, borderColor, Vertical, Reset, line)
+					fmt.Printf("%s%s%s%s%s %s", Vertical, f.gutterMarker(currIdx), borderColor, Vertical, Reset, line)
 				}
 
 			} else {
 				if f.bufferLine == currIdx {
-					fmt.Printf("%s%s%4d %s%s%s %s\n", lineNumColor, currRowColor, currIdx+1, borderColor, Vertical, Reset, line)
+					fmt.Printf("%s%s%4d%s%s%s%s %s", lineNumColor, currRowColor, currIdx+1, f.gutterMarker(currIdx), borderColor, Vertical, Reset, line)
 				} else {
-					fmt.Printf("%s%4d %s%s%s %s\n", lineNumColor, currIdx+1, borderColor, Vertical, Reset, line)
+					fmt.Printf("%s%4d%s%s%s%s %s", lineNumColor, currIdx+1, f.gutterMarker(currIdx), borderColor, Vertical, Reset, line)
 				}
 			}
 			lastIdx = currIdx
 		} else {
-			if f.bufferLine == i {
-				fmt.Printf("%s%s%4d %s%s%s %s\n", lineNumColor, currRowColor, i+1, borderColor, Vertical, Reset, line)
+			lineIdx := f.lineAtRow(i)
+			width := utf8.RuneCountInString(line)
+			fold, foldWidth := f.foldVirtualText(lineIdx, width)
+			line += fold
+
+			if f.bufferLine == lineIdx {
+				line += f.blameVirtualText(lineIdx, width+foldWidth)
+				fmt.Printf("%s%s%4d%s%s%s%s %s", lineNumColor, currRowColor, lineIdx+1, f.gutterMarker(lineIdx), borderColor, Vertical, Reset, line)
 			} else {
-				fmt.Printf("%s%4d %s%s%s %s\n", lineNumColor, i+1, borderColor, Vertical, Reset, line)
+				fmt.Printf("%s%4d%s%s%s%s %s", lineNumColor, lineIdx+1, f.gutterMarker(lineIdx), borderColor, Vertical, Reset, line)
 			}
 		}
 
@@ -268,12 +370,12 @@ func (f *FileEditor) PrintBuffer() {
 	}
 
 	// print the remaining empty spaces (if there is any in the viewport space avaiable)
-	for range maxHeight {
-		ansi.EraseEntireLine()
+	for row := linesPrinted + 1; row <= linesPrinted+maxHeight; row++ {
+		f.eraseEditorRow(row)
 		if f.PrintEmptyLines {
-			fmt.Printf("%s   ~ %s%s%s\n", lineNumColor, borderColor, Vertical, Reset)
-		} else {
-			ansi.MoveCursorDown(1)
+			fmt.Printf("%s   ~ %s%s%s", lineNumColor, borderColor, Vertical, Reset)
 		}
 	}
+
+	f.printMatchingBracket()
 }
@@ -0,0 +1,237 @@
+package fileeditor
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for managing the buffers that are open in the editor.
+
+Only the current buffer is stored in the FileEditor itself (through the embedded
+bufferState and viewState), so switching buffers stores the current state in
+its entry of the buffer list and loads the state of the other buffer
+*/
+
+type openBuffer struct {
+	bufferState
+	lastView viewState // where the cursor and viewport were when the buffer was switched away from
+}
+
+/*
+Stores the state of the current buffer in its entry of the buffer list
+*/
+func (f *FileEditor) storeCurrentBuffer() {
+	f.buffers[f.currentBuffer].bufferState = f.bufferState
+	f.buffers[f.currentBuffer].lastView = f.viewState
+}
+
+/*
+Loads the state of the buffer at the index as the current buffer
+*/
+func (f *FileEditor) loadBuffer(index int) {
+	f.currentBuffer = index
+	f.bufferState = f.buffers[index].bufferState
+	f.viewState = f.buffers[index].lastView
+
+	f.RefreshVisualBuffers()
+	setSavedCursorX(f.apparentCursorX, f.ViewportOffsetX, false)
+
+	ansi.ClearEntireScreen()
+	ansi.SetTerminalWindowTitle(f.Filename)
+}
+
+/*
+Returns the index of the buffer that has the file open, or -1
+*/
+func (f FileEditor) findBuffer(filename string) int {
+	target, err := filepath.Abs(filename)
+	if err != nil {
+		return -1
+	}
+
+	for i, b := range f.buffers {
+		name := b.Filename
+		if i == f.currentBuffer {
+			name = f.Filename
+		}
+
+		if path, err := filepath.Abs(name); err == nil && path == target {
+			return i
+		}
+	}
+
+	return -1
+}
+
+/*
+Switches to the buffer at the index
+*/
+func (f *FileEditor) SwitchBuffer(index int) {
+	if index < 0 || index >= len(f.buffers) || index == f.currentBuffer {
+		return
+	}
+
+	f.storeCurrentBuffer()
+	f.loadBuffer(index)
+}
+
+/*
+Opens the file in a new buffer and makes it the current buffer.
+If the file is already open, its buffer becomes the current one instead
+*/
+func (f *FileEditor) OpenBuffer(filename string) error {
+	if i := f.findBuffer(filename); i >= 0 {
+		f.SwitchBuffer(i)
+		return nil
+	}
+
+	f.storeCurrentBuffer()
+	previous := f.currentBuffer
+
+	f.bufferState = bufferState{
+		Filename:   filename,
+		FileBuffer: make([]string, 0),
+		Saved:      true,
+	}
+	f.viewState = viewState{}
+
+	f.OpenFile()
+	err := f.ReadFileToBuffer()
+	f.CloseFile()
+
+	if err != nil {
+		f.loadBuffer(previous)
+		return err
+	}
+
+	f.buffers = append(f.buffers, &openBuffer{})
+	f.currentBuffer = len(f.buffers) - 1
+
+	ansi.ClearEntireScreen()
+	ansi.SetTerminalWindowTitle(f.Filename)
+
+	return nil
+}
+
+func (f *FileEditor) NextBuffer() {
+	f.SwitchBuffer((f.currentBuffer + 1) % len(f.buffers))
+}
+
+func (f *FileEditor) PrevBuffer() {
+	f.SwitchBuffer((f.currentBuffer - 1 + len(f.buffers)) % len(f.buffers))
+}
+
+/*
+Closes the current buffer and switches to the one next to it. An unsaved
+buffer is only closed if force is true; otherwise a warning is shown
+*/
+func (f *FileEditor) CloseBuffer(force bool) {
+	if len(f.buffers) == 1 {
+		f.statusMessage = "Cannot close the last buffer"
+		return
+	}
+
+	if !f.Saved && !force {
+		f.statusMessage = fmt.Sprintf("%s has unsaved changes (%s! to discard them)", f.Filename, CMDBAR_BUFFER_CLOSE)
+		return
+	}
+
+	f.removeBuffer(f.currentBuffer)
+}
+
+/*
+Removes the buffer at the index from the buffer list. If it is the current
+buffer, the buffer next to it becomes the current one
+*/
+func (f *FileEditor) removeBuffer(index int) {
+	closingCurrent := index == f.currentBuffer
+	if !closingCurrent {
+		f.storeCurrentBuffer()
+	}
+
+	f.buffers = append(f.buffers[:index], f.buffers[index+1:]...)
+
+	next := math.Min(index, len(f.buffers)-1)
+
+	// windows showing the closed buffer show the next one instead
+	for _, w := range f.windows {
+		if w == f.focusedWindow {
+			continue
+		}
+		if w.buffer == index {
+			w.buffer = next
+			w.view = f.buffers[next].lastView
+		} else if w.buffer > index {
+			w.buffer--
+		}
+	}
+
+	if closingCurrent {
+		f.loadBuffer(next)
+	} else if f.currentBuffer > index {
+		f.currentBuffer--
+	}
+}
+
+/*
+Returns EnumQuit to quit the editor. Unless force is true, quitting is refused
+with a warning when a buffer has unsaved changes
+*/
+func (f *FileEditor) Quit(force bool) byte {
+	if force {
+		return EnumQuit
+	}
+
+	unsaved := make([]string, 0)
+	for i, b := range f.buffers {
+		if i == f.currentBuffer && !f.Saved {
+			unsaved = append(unsaved, f.Filename)
+		} else if i != f.currentBuffer && !b.Saved {
+			unsaved = append(unsaved, b.Filename)
+		}
+	}
+
+	switch len(unsaved) {
+	case 0:
+		return EnumQuit
+	case 1:
+		f.statusMessage = fmt.Sprintf("%s has unsaved changes (%s to discard them)", unsaved[0], CMDBAR_FORCE_QUIT)
+	default:
+		f.statusMessage = fmt.Sprintf("%d buffers have unsaved changes (%s to discard them)", len(unsaved), CMDBAR_FORCE_QUIT)
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Opens a picker that lists every open buffer and switches to the chosen one
+*/
+func (f *FileEditor) OpenBufferPicker() {
+	f.storeCurrentBuffer()
+
+	items := make([]PickerItem, len(f.buffers))
+	for i, b := range f.buffers {
+		detail := ""
+		if !b.Saved {
+			detail = "(Unsaved)"
+		}
+		if i == f.currentBuffer {
+			detail += " (current)"
+		}
+
+		items[i] = PickerItem{
+			Label:  fmt.Sprintf("%d: %s", i+1, b.Filename),
+			Detail: detail,
+			Value:  i,
+		}
+	}
+
+	f.OpenPicker(NewPicker("Buffers", items, func(f *FileEditor, item PickerItem) byte {
+		f.SwitchBuffer(item.Value.(int))
+		return 0
+	}))
+}
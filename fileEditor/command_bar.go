@@ -2,6 +2,8 @@ package fileeditor
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/Asiandayboy/CLITextEditor/render"
 	"github.com/Asiandayboy/CLITextEditor/util/ansi"
@@ -11,7 +13,48 @@ const (
 	CMDBAR_SAVE            string = "save"
 	CMDBAR_SAVE_AS         string = "save as"
 	CMDBAR_QUIT            string = "quit"
+	CMDBAR_FORCE_QUIT      string = "quit!"
 	CMDBAR_TOGGLE_SOFTWRAP string = "sw"
+	CMDBAR_NEXT_HUNK       string = "next hunk"
+	CMDBAR_PREV_HUNK       string = "prev hunk"
+	CMDBAR_REVERT_HUNK     string = "revert hunk"
+	CMDBAR_TOGGLE_BLAME    string = "blame"
+	CMDBAR_DIFF            string = "diff" // optionally followed by the path of the file to compare against
+	CMDBAR_OPEN            string = "open" // followed by the path of the file to open
+	CMDBAR_BUFFER_NEXT     string = "bnext"
+	CMDBAR_BUFFER_PREV     string = "bprev"
+	CMDBAR_BUFFER_CLOSE    string = "bclose"
+	CMDBAR_BUFFER_DISCARD  string = "bclose!"
+	CMDBAR_BUFFER_LIST     string = "buffers"
+	CMDBAR_UNDO            string = "undo"
+	CMDBAR_REDO            string = "redo"
+	CMDBAR_SPLIT           string = "split"
+	CMDBAR_VSPLIT          string = "vsplit"
+	CMDBAR_CLOSE_WINDOW    string = "close"
+	CMDBAR_NEXT_WINDOW     string = "wnext"
+	CMDBAR_RESIZE_WINDOW   string = "resize" // followed by the number of cells to grow the window by, or shrink it by if negative
+	CMDBAR_FILE_TREE       string = "tree"
+	CMDBAR_FIND_FILE       string = "files"
+	CMDBAR_GREP            string = "grep" // followed by the pattern to search the project for, prefixed with -F to match it literally
+	CMDBAR_PALETTE         string = "palette"
+	CMDBAR_BOOKMARK        string = "bookmark"
+	CMDBAR_BOOKMARKS       string = "bookmarks"
+	CMDBAR_WORD_CHARS      string = "wordchars" // followed by the characters other than letters and digits that words are made of
+	CMDBAR_GOTO            string = "goto"      // followed by the line to go to, and optionally the column, like 12:5
+	CMDBAR_RETAB           string = "retab"     // optionally followed by tabs or spaces, a size, and a range of lines like 10,20
+	CMDBAR_DUPLICATE       string = "duplicate"
+	CMDBAR_JOIN            string = "join"
+	CMDBAR_DELETE_LINES    string = "deletelines"
+	CMDBAR_SORT            string = "sort"    // optionally followed by the flags n, r and u, and a range of lines like 10,20
+	CMDBAR_REVERSE         string = "reverse" // optionally followed by a range of lines like 10,20
+	CMDBAR_COMMENT         string = "comment"
+	CMDBAR_BLOCK_COMMENT   string = "blockcomment"
+	CMDBAR_TOGGLE_FOLD     string = "togglefold"
+	CMDBAR_FOLD            string = "fold"
+	CMDBAR_UNFOLD          string = "unfold"
+	CMDBAR_FOLD_ALL        string = "foldall"
+	CMDBAR_UNFOLD_ALL      string = "unfoldall"
+	CMDBAR_FOLD_METHOD     string = "foldmethod" // optionally followed by indent or brackets
 )
 
 const cmdBarWidth int = 35
@@ -23,8 +66,8 @@ const cmdBarCursorXBoundary int = cmdBarWidth - 10
 const cmdBarPrefixLength int = 5
 
 func drawCommandBar(f FileEditor) {
-	xPos := f.GetViewportWidth()/2 - cmdBarWidth/2 + cmdBarPrefixLength
-	yPos := f.GetViewportHeight()/2 - cmdBarHeight/2
+	xPos := f.EditorX + f.GetViewportWidth()/2 - cmdBarWidth/2 + cmdBarPrefixLength
+	yPos := f.EditorY + f.GetViewportHeight()/2 - cmdBarHeight/2
 	blueRGB := modeColors[f.EditorMode]
 	blue := blueRGB.ToFgColorANSI()
 
@@ -45,7 +88,11 @@ func drawCommandBar(f FileEditor) {
 	}
 }
 
-func executeCommandBarStr(f *FileEditor, cmdString string) {
+/*
+Runs the command string. Returns the flag of the action run by the
+command, or 0 if the command doesn't run an action
+*/
+func executeCommandBarStr(f *FileEditor, cmdString string) byte {
 	/*
 		The only command that isn't handled here is the CMDBAR_QUIT
 		command. This is because the quit command needs to be handled
@@ -55,15 +102,100 @@ func executeCommandBarStr(f *FileEditor, cmdString string) {
 		because the quit signal won't be checked until the next loop cycle
 		due to how the command bar is implemented rn. So....yeah
 	*/
-	switch cmdString {
-	case CMDBAR_SAVE:
-		f.SaveFile()
-	case CMDBAR_SAVE_AS:
-		// not implemented yet
-	case CMDBAR_TOGGLE_SOFTWRAP:
-		f.inputChan <- f.ToggleSoftWrap(!f.SoftWrapEnabled)
-	default:
+	if arg, ok := parseCommandArg(cmdString, CMDBAR_DIFF); ok {
+		if err := f.OpenDiffView(arg); err != nil {
+			f.statusMessage = err.Error()
+		}
+		return EnumCursorPositionChange
+	}
+
+	if arg, ok := parseCommandArg(cmdString, CMDBAR_OPEN); ok && arg != "" {
+		f.pushJump()
+		if err := f.OpenBuffer(arg); err != nil {
+			f.statusMessage = err.Error()
+		}
+		return EnumCursorPositionChange
+	}
+
+	if arg, ok := parseCommandArg(cmdString, CMDBAR_GREP); ok {
+		if arg == "" {
+			f.FocusGrepPanel()
+		} else {
+			f.StartGrep(arg)
+		}
+		return EnumCursorPositionChange
+	}
+
+	if arg, ok := parseCommandArg(cmdString, CMDBAR_RESIZE_WINDOW); ok {
+		amount, err := strconv.Atoi(arg)
+		if err != nil {
+			f.statusMessage = "Usage: " + CMDBAR_RESIZE_WINDOW + " <+/-cells>"
+			return EnumCursorPositionChange
+		}
+		f.ResizeWindow(amount)
+		return EnumCursorPositionChange
+	}
+
+	if arg, ok := parseCommandArg(cmdString, CMDBAR_GOTO); ok {
+		line, col, err := ParseLineColumn(arg)
+		if err != nil {
+			f.statusMessage = "Usage: " + CMDBAR_GOTO + " <line>[:<column>]"
+			return EnumCursorPositionChange
+		}
+		f.GotoLine(line, col)
+		return EnumCursorPositionChange
+	}
+
+	if arg, ok := parseCommandArg(cmdString, CMDBAR_WORD_CHARS); ok {
+		if arg != "" {
+			f.WordChars = arg
+		}
+		f.showInfo("Word characters: " + f.WordChars)
+		return EnumCursorPositionChange
+	}
+
+	if arg, ok := parseCommandArg(cmdString, CMDBAR_RETAB); ok {
+		f.Retab(arg)
+		return EnumCursorPositionChange
 	}
+
+	if arg, ok := parseCommandArg(cmdString, CMDBAR_SORT); ok {
+		f.SortLines(arg)
+		return EnumCursorPositionChange
+	}
+
+	if arg, ok := parseCommandArg(cmdString, CMDBAR_REVERSE); ok {
+		f.ReverseLines(arg)
+		return EnumCursorPositionChange
+	}
+
+	if arg, ok := parseCommandArg(cmdString, CMDBAR_FOLD_METHOD); ok {
+		f.SetFoldMethod(arg)
+		return EnumCursorPositionChange
+	}
+
+	if a := lookupCommand(cmdString); a != nil {
+		return a.Handler(f)
+	}
+
+	f.statusMessage = "Unknown command: " + cmdString
+	return 0
+}
+
+/*
+Returns the argument that follows the command name in the command string.
+The ok flag is false if the command string is not the given command
+*/
+func parseCommandArg(cmdString string, name string) (arg string, ok bool) {
+	if cmdString == name {
+		return "", true
+	}
+
+	if strings.HasPrefix(cmdString, name+" ") {
+		return strings.TrimSpace(cmdString[len(name)+1:]), true
+	}
+
+	return "", false
 }
 
 func (f *FileEditor) UpdateCommandBarState() {
@@ -71,16 +203,26 @@ func (f *FileEditor) UpdateCommandBarState() {
 }
 
 func (f FileEditor) isCommandBarQuitStr() bool {
-	return f.CommandBarBuffer == CMDBAR_QUIT
+	return f.CommandBarBuffer == CMDBAR_QUIT || f.CommandBarBuffer == CMDBAR_FORCE_QUIT
 }
 
 func (f *FileEditor) ToggleCommandBar(toggled bool) {
 	f.CommandBarToggled = toggled
 
 	if toggled {
-		drawCommandBar(*f)
+		if f.macros.depth == 0 {
+			drawCommandBar(*f)
+		}
 	} else if len(f.CommandBarBuffer) > 0 {
-		executeCommandBarStr(f, f.CommandBarBuffer)
+		// commands run while rendering, so the flags that need a render of their own are sent for the next one
+		switch flag := executeCommandBarStr(f, f.CommandBarBuffer); flag {
+		case EnumSoftWrapEnabled, EnumSoftWrapDisabled:
+			if f.macros.depth > 0 { // a macro being played doesn't render
+				f.updateForFlag(flag)
+			} else {
+				f.inputChan <- flag
+			}
+		}
 	}
 
 	f.CommandBarBuffer = ""
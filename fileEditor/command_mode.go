@@ -0,0 +1,603 @@
+package fileeditor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for the commands typed in Command mode, which follow
+the grammar of vim:
+
+	[count] motion                       moves the cursor
+	[count] operator [count] motion      runs the operator on the text the motion moves over
+	[count] operator operator            runs the operator on count lines, like dd
+	[count] operator i|a object          runs the operator on a text object, like di(
+
+The operators are d (delete), c (change), y (yank), > (indent) and < (outdent),
+the motions are listed in motion.go, and the text objects in textobject.go.
+Deleted, changed and yanked text is stored in the register, which p and P
+paste after and before the cursor.
+
+The other commands are:
+  - i, a, I, A, o, O and E, which enter Edit mode
+  - v and V, which enter View mode
+  - x, X, D and C, which are short for dl, dh, d$ and c$
+  - m followed by a name, which sets the mark with the name (see marks.go)
+  - z followed by a, o, c, R or M, which open and close folds (see fold.go)
+  - q followed by a name, which records a macro until q is typed again, and
+    @ followed by a name, which plays it count times (see macro.go)
+  - tab and shift+tab, which indent and outdent lines (see indent.go)
+  - J, which joins count lines, at least two (see lines.go)
+  - u, which undoes the last change
+  - ., which repeats the last change. A count typed before it replaces the
+    count of the change, and a change made with c, or by entering Edit mode,
+    inserts the same text again
+
+Everything is done with the positions of the FileBuffer, and the cursor is
+placed afterwards with MoveCursorToBufferPos
+*/
+
+type modalCommand struct {
+	count       int    // count typed before the operator; 0 if none was typed
+	operator    byte   // 0 for commands without an operator
+	motionCount int    // count typed after the operator; 0 if none was typed
+	motion      string // the motion, the operator again for commands like dd, or the key of another command
+	char        byte   // the character typed after f, t, F and T
+	inserted    string // the text typed in Edit mode after a change, so that the change can be repeated
+}
+
+type register struct {
+	text     string
+	linewise bool // true if the text is whole lines, which are pasted as lines
+}
+
+type modalState struct {
+	cmd        modalCommand  // the command being typed
+	keys       []byte        // keys typed so far of the command, shown in the status bar
+	lastChange *modalCommand // nil until a change is made
+	recording  bool          // true while the text typed in Edit mode is added to the last change
+	register   register
+}
+
+// commands that are short for an operator and a motion
+var commandAliases = map[byte]modalCommand{
+	'x': {operator: 'd', motion: "l"},
+	'X': {operator: 'd', motion: "h"},
+	'D': {operator: 'd', motion: "$"},
+	'C': {operator: 'c', motion: "$"},
+}
+
+// limits counts so that a long run of digits can't overflow
+const maxCommandCount int = 99999
+
+func isOperator(key byte) bool {
+	return key == 'd' || key == 'c' || key == 'y' || key == '>' || key == '<'
+}
+
+/*
+Returns true while a command has been started but not finished
+*/
+func (m modalState) pending() bool {
+	return len(m.keys) > 0
+}
+
+/*
+Forgets the command being typed
+*/
+func (m *modalState) resetCommand() {
+	m.cmd = modalCommand{}
+	m.keys = nil
+}
+
+/*
+Handles a key typed in Command mode, which either continues the command being
+typed or runs it. Returns the flag that should be sent to the render loop
+*/
+func (f *FileEditor) handleCommandModeKey(key byte) byte {
+	m := &f.modal
+	cmd := &m.cmd
+	m.keys = append(m.keys, key)
+
+	switch {
+	case cmd.motion == "g":
+		if key != 'g' {
+			m.resetCommand()
+			return EnumCursorPositionChange
+		}
+		cmd.motion = "gg"
+		return f.runCommand()
+
+	case cmd.motion != "": // the character of f, t, F and T, the object after i and a, or the name after m, z, q and @
+		cmd.char = key
+		return f.runCommand()
+
+	case key >= '0' && key <= '9' && (key != '0' || f.typingCount() > 0):
+		count := &cmd.count
+		if cmd.operator != 0 {
+			count = &cmd.motionCount
+		}
+		*count = math.Min(*count*10+int(key-'0'), maxCommandCount)
+		return EnumCursorPositionChange
+
+	case isOperator(key):
+		if cmd.operator == 0 {
+			cmd.operator = key
+			return EnumCursorPositionChange
+		}
+		if cmd.operator != key {
+			m.resetCommand()
+			return EnumCursorPositionChange
+		}
+		cmd.motion = string(key)
+		return f.runCommand()
+
+	case key == 'g':
+		cmd.motion = "g"
+		return EnumCursorPositionChange
+
+	case key == 'q' && cmd.operator == 0 && f.macros.recording != 0:
+		cmd.motion = "q"
+		return f.runCommand()
+
+	case (key == 'm' || key == 'z' || key == 'q' || key == '@') && cmd.operator == 0:
+		cmd.motion = string(key)
+		return EnumCursorPositionChange
+
+	case cmd.operator != 0 && (key == 'i' || key == 'a'):
+		cmd.motion = string(key)
+		return EnumCursorPositionChange
+	}
+
+	if mo, ok := motions[string(key)]; ok {
+		cmd.motion = string(key)
+		if mo.needsChar {
+			return EnumCursorPositionChange
+		}
+		return f.runCommand()
+	}
+
+	// the other commands can't follow an operator
+	if cmd.operator != 0 {
+		m.resetCommand()
+		return EnumCursorPositionChange
+	}
+
+	if alias, ok := commandAliases[key]; ok {
+		cmd.operator = alias.operator
+		cmd.motion = alias.motion
+		return f.runCommand()
+	}
+
+	cmd.motion = string(key)
+	return f.runCommand()
+}
+
+/*
+Returns the count being typed, which is the count after the operator once
+an operator has been typed. A 0 that doesn't continue a count is a motion
+*/
+func (f FileEditor) typingCount() int {
+	if f.modal.cmd.operator != 0 {
+		return f.modal.cmd.motionCount
+	}
+
+	return f.modal.cmd.count
+}
+
+/*
+Runs the command that has been typed
+*/
+func (f *FileEditor) runCommand() byte {
+	cmd := f.modal.cmd
+	f.modal.resetCommand()
+
+	return f.executeCommand(cmd, false)
+}
+
+/*
+Runs the command. When repeat is true, the command is the last change being
+repeated with ., so a change made with c inserts the text that was typed
+after it instead of entering Edit mode
+*/
+func (f *FileEditor) executeCommand(cmd modalCommand, repeat bool) byte {
+	count := math.Max(cmd.count, 1) * math.Max(cmd.motionCount, 1)
+	hasCount := cmd.count > 0 || cmd.motionCount > 0
+
+	line, index := f.CursorBufferPos()
+	pos := BufferPos{Line: line, Index: index}
+
+	// without an operator, a global mark can be gone to in another file
+	if cmd.operator == 0 && (cmd.motion == "'" || cmd.motion == "`") {
+		f.GoToMark(cmd.char, cmd.motion == "'")
+		return EnumCursorPositionChange
+	}
+
+	if cmd.operator != 0 {
+		return f.runOperator(cmd, pos, count, hasCount, repeat)
+	}
+
+	if mo, ok := motions[cmd.motion]; ok {
+		target, ok := mo.move(f, pos, count, hasCount, cmd.char)
+		if !ok {
+			f.motionFailed(cmd)
+			return EnumCursorPositionChange
+		}
+
+		if jumpMotions[cmd.motion] {
+			f.pushJump()
+		}
+		f.MoveCursorToBufferPos(target.Line, target.Index)
+		return EnumCursorPositionChange
+	}
+
+	switch cmd.motion {
+	case "i", "a", "I", "A", "o", "O", "E":
+		return f.enterEditMode(cmd, pos, repeat)
+	case "v", "V":
+		f.EditorMode = EditorViewMode
+		return EnumEditorModeChange
+	case "m":
+		f.SetMark(cmd.char)
+	case "z":
+		f.foldCommand(cmd.char)
+	case "q":
+		if f.macros.recording != 0 {
+			f.StopMacro()
+		} else {
+			f.StartMacro(cmd.char)
+		}
+	case "@":
+		return f.PlayMacro(cmd.char, count)
+	case "u":
+		for range count {
+			f.Undo()
+		}
+	case "p", "P":
+		f.paste(pos, count, cmd.motion == "P")
+		f.modal.lastChange = &cmd
+	case "J":
+		f.JoinLines(count)
+		f.modal.lastChange = &cmd
+	case string(Tab), shiftTabSequence:
+		f.ShiftSelection(count, cmd.motion == shiftTabSequence)
+		f.modal.lastChange = &cmd
+	case ".":
+		if f.modal.lastChange == nil {
+			break
+		}
+		change := *f.modal.lastChange
+		if cmd.count > 0 {
+			change.count = cmd.count
+			change.motionCount = 0
+		}
+		return f.executeCommand(change, true)
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Shows that the motion or text object of the command found nothing to move
+over, which also stops the macro being played
+*/
+func (f *FileEditor) motionFailed(cmd modalCommand) {
+	switch cmd.motion {
+	case "f", "t", "F", "T":
+		f.statusMessage = fmt.Sprintf("Character not found: %c", cmd.char)
+	case "'", "`":
+		f.statusMessage = fmt.Sprintf("Mark not set: %c", cmd.char)
+	case "%":
+		f.statusMessage = "No matching bracket"
+	case "i", "a":
+		f.statusMessage = fmt.Sprintf("No text object found: %s%c", cmd.motion, cmd.char)
+	default:
+		f.statusMessage = "Can't move with " + cmd.motion
+	}
+}
+
+/*
+Runs the operator of the command on the text that its motion moves over
+*/
+func (f *FileEditor) runOperator(cmd modalCommand, pos BufferPos, count int, hasCount bool, repeat bool) byte {
+	var start, end BufferPos
+	var kind byte
+
+	if cmd.motion == string(cmd.operator) { // dd, cc, yy, >> and <<
+		start = pos
+		end = BufferPos{Line: math.Min(pos.Line+count-1, len(f.FileBuffer)-1)}
+		kind = motionLinewise
+	} else if cmd.motion == "i" || cmd.motion == "a" { // text objects
+		r, ok := ResolveTextObject(f.FileBuffer, pos, cmd.char, cmd.motion == "a", count, f.WordChars)
+		if !ok {
+			f.motionFailed(cmd)
+			return EnumCursorPositionChange
+		}
+
+		start, end, kind = r.Start, r.End, motionExclusive
+		if r.Linewise {
+			kind = motionLinewise
+		}
+	} else {
+		mo, ok := motions[cmd.motion]
+		if !ok {
+			return EnumCursorPositionChange
+		}
+
+		// cw changes up to the end of the word, leaving the whitespace after it
+		if class := charClassAt(f.FileBuffer, pos, f.WordChars); cmd.operator == 'c' && cmd.motion == "w" &&
+			(class == charClassWord || class == charClassPunct) {
+			mo = motion{kind: motionInclusive, move: func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+				pos = wordEndAt(f.FileBuffer, pos, f.WordChars)
+				for range count - 1 {
+					pos = NextWordEnd(f.FileBuffer, pos, f.WordChars)
+				}
+				return pos, true
+			}}
+		}
+
+		target, ok := mo.move(f, pos, count, hasCount, cmd.char)
+		if !ok {
+			f.motionFailed(cmd)
+			return EnumCursorPositionChange
+		}
+
+		start, end, kind = pos, target, mo.kind
+		if end.Before(start) {
+			start, end = end, start
+		}
+
+		if kind == motionInclusive {
+			end.Index = math.Min(end.Index+1, len(f.FileBuffer[end.Line]))
+		} else if kind == motionExclusive && end.Line > start.Line && end.Index == 0 {
+			// stop at the end of the previous line, so that dw on the last word of a line doesn't join lines
+			end = BufferPos{Line: end.Line - 1, Index: len(f.FileBuffer[end.Line-1])}
+		}
+	}
+
+	linewise := kind == motionLinewise
+	if linewise {
+		start.Index = 0
+		end.Index = len(f.FileBuffer[end.Line])
+	}
+
+	if cmd.operator == 'd' && start == end {
+		return EnumCursorPositionChange
+	}
+
+	if cmd.operator != 'y' && !f.writable() {
+		return EnumCursorPositionChange
+	}
+
+	if cmd.operator != 'y' {
+		f.modal.lastChange = &cmd
+	}
+
+	switch cmd.operator {
+	case 'y':
+		f.modal.register = register{text: f.TextInRange(start, end), linewise: linewise}
+		if !linewise {
+			f.MoveCursorToBufferPos(start.Line, start.Index)
+		} else if start.Line != pos.Line {
+			f.MoveCursorToBufferPos(start.Line, FirstNonBlank(f.FileBuffer[start.Line]))
+		}
+		return EnumCursorPositionChange
+
+	case '>', '<':
+		f.pushUndo()
+		f.ShiftLines(start.Line, end.Line, cmd.operator == '<')
+		f.bufferModified()
+		f.MoveCursorToBufferPos(start.Line, FirstNonBlank(f.FileBuffer[start.Line]))
+		return EnumCursorPositionChange
+
+	case 'd':
+		f.modal.register = register{text: f.TextInRange(start, end), linewise: linewise}
+		f.pushUndo()
+		if linewise {
+			f.ReplaceLines(start.Line, end.Line-start.Line+1, nil)
+			line := math.Min(start.Line, len(f.FileBuffer)-1)
+			start = BufferPos{Line: line, Index: FirstNonBlank(f.FileBuffer[line])}
+		} else {
+			f.DeleteRange(start, end)
+		}
+		f.bufferModified()
+		f.MoveCursorToBufferPos(start.Line, start.Index)
+		return EnumCursorPositionChange
+
+	case 'c':
+		f.modal.register = register{text: f.TextInRange(start, end), linewise: linewise}
+		f.pushUndo()
+		if linewise {
+			// the line that replaces the changed lines keeps the indentation of the first one
+			first := f.FileBuffer[start.Line]
+			indent := first[:FirstNonBlank(first)]
+			f.ReplaceLines(start.Line, end.Line-start.Line+1, []string{indent})
+			start.Index = len(indent)
+		} else {
+			f.DeleteRange(start, end)
+		}
+
+		if repeat {
+			end := f.InsertText(start, cmd.inserted)
+			f.bufferModified()
+			f.MoveCursorToBufferPos(end.Line, math.Max(end.Index-1, 0))
+			return EnumCursorPositionChange
+		}
+
+		f.bufferModified()
+		f.MoveCursorToBufferPos(start.Line, start.Index)
+
+		// what is typed next belongs to the change, both for undo and for repeating it
+		f.typingUndoGroup = true
+		f.modal.recording = true
+		f.EditorMode = EditorEditMode
+		return EnumEditorModeChange
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Enters Edit mode with the cursor placed according to the key of the command.
+When repeat is true, the command is the last change being repeated with ., so
+the text that was typed after it is inserted instead of entering Edit mode
+*/
+func (f *FileEditor) enterEditMode(cmd modalCommand, pos BufferPos, repeat bool) byte {
+	if !f.writable() {
+		return EnumCursorPositionChange
+	}
+
+	key := cmd.motion[0]
+	line := f.FileBuffer[pos.Line]
+	target := pos
+
+	switch key {
+	case 'a':
+		target.Index = math.Min(pos.Index+1, len(line))
+	case 'A':
+		target.Index = len(line)
+	case 'I':
+		target.Index = FirstNonBlank(line)
+	case 'o', 'O':
+		// the new line keeps the indentation of the line the cursor is on
+		indent := line[:FirstNonBlank(line)]
+		at := pos.Line
+		if key == 'o' {
+			at++
+			indent, _ = NewLineIndent(line, "", FiletypeFor(f.Filename), f.indentUnit())
+		}
+
+		f.pushUndo()
+		f.ReplaceLines(at, 0, []string{indent})
+		f.bufferModified()
+		target = BufferPos{Line: at, Index: len(indent)}
+	}
+
+	if repeat {
+		if cmd.inserted == "" {
+			f.MoveCursorToBufferPos(target.Line, target.Index)
+			return EnumCursorPositionChange
+		}
+		if key != 'o' && key != 'O' {
+			f.pushUndo()
+		}
+
+		end := f.InsertText(target, cmd.inserted)
+		f.bufferModified()
+		f.MoveCursorToBufferPos(end.Line, math.Max(end.Index-1, 0))
+		return EnumCursorPositionChange
+	}
+
+	f.MoveCursorToBufferPos(target.Line, target.Index)
+
+	// what is typed next is undone along with the new line of o and O, and is repeated with .
+	f.typingUndoGroup = key == 'o' || key == 'O'
+	f.modal.lastChange = &cmd
+	f.modal.recording = true
+	f.EditorMode = EditorEditMode
+	return EnumEditorModeChange
+}
+
+/*
+Pastes the register count times, after the cursor or before it. Lines are
+pasted below or above the line of the cursor
+*/
+func (f *FileEditor) paste(pos BufferPos, count int, before bool) {
+	r := f.modal.register
+	if r.text == "" && !r.linewise {
+		f.statusMessage = "Nothing to paste"
+		return
+	}
+
+	if !f.writable() {
+		return
+	}
+
+	f.pushUndo()
+
+	// the text is pasted at every cursor, with pos standing in for the cursor
+	cursors := f.allCursors()
+	cursors[0] = pos
+
+	if r.linewise {
+		lines := strings.Split(r.text, "\n")
+		pasted := make([]string, 0, len(lines)*count)
+		for range count {
+			pasted = append(pasted, lines...)
+		}
+
+		// the lines are pasted once per line with a cursor, from the last line up
+		at := make([]int, len(cursors))
+		for i, c := range cursors {
+			at[i] = c.Line
+			if !before {
+				at[i]++
+			}
+		}
+		lineNums := slices.Clone(at)
+		slices.Sort(lineNums)
+		lineNums = slices.Compact(lineNums)
+		for i := len(lineNums) - 1; i >= 0; i-- {
+			f.ReplaceLines(lineNums[i], 0, pasted)
+		}
+		f.bufferModified()
+
+		positions := make([]BufferPos, len(cursors))
+		for i, line := range at {
+			line += slices.Index(lineNums, line) * len(pasted)
+			positions[i] = BufferPos{Line: line, Index: FirstNonBlank(pasted[0])}
+		}
+		f.setCursors(positions)
+		return
+	}
+
+	edits := make([]TextEdit, len(cursors))
+	for i, c := range cursors {
+		at := c
+		if !before {
+			at.Index = math.Min(c.Index+1, len(f.FileBuffer[c.Line]))
+		}
+		edits[i] = TextEdit{Start: at, End: at, Text: strings.Repeat(r.text, count)}
+	}
+	ends := f.ApplyEdits(edits)
+	f.bufferModified()
+
+	// the cursors end on the last pasted character
+	for i, end := range ends {
+		ends[i].Index = math.Max(end.Index-1, 0)
+	}
+	f.setCursors(ends)
+}
+
+/*
+Adds a key typed in Edit mode to the text of the change being recorded
+*/
+func (f *FileEditor) recordInsertedKey(key byte) {
+	m := &f.modal
+	if !m.recording || m.lastChange == nil {
+		return
+	}
+
+	switch {
+	case key == NewLine:
+		m.lastChange.inserted += "\n"
+	case key == Tab:
+		if f.TabIndentType == IndentWithSpace {
+			m.lastChange.inserted += strings.Repeat(" ", f.GetSpaceWidthOfTabChar(f.bufferIndex))
+		} else {
+			m.lastChange.inserted += string(Tab)
+		}
+	case key == Backspace:
+		if m.lastChange.inserted == "" {
+			// deleting text from before the change can't be repeated
+			m.recording = false
+			return
+		}
+		m.lastChange.inserted = m.lastChange.inserted[:len(m.lastChange.inserted)-1]
+	case key >= Space && key < Backspace:
+		m.lastChange.inserted += string(key)
+	}
+}
@@ -0,0 +1,184 @@
+package fileeditor
+
+import (
+	"strings"
+)
+
+/*
+This file is responsible for commenting and uncommenting lines, with the
+comment syntax of the filetype (see filetype.go).
+
+Toggling line comments works on the line of the cursor, or on the lines from
+the first cursor to the last one when there are secondary cursors. If every
+line that isn't blank is commented, the lines are uncommented. Otherwise,
+every line that isn't blank is commented, so a range of commented and
+uncommented lines is always commented as a whole first. The markers are put at
+the smallest indentation of the lines, so that they line up. Filetypes without
+line comments, like html, get each line wrapped in a block comment instead.
+
+Toggling a block comment wraps the lines in one block comment, or unwraps them
+if the first line already starts it and the last line already ends it
+*/
+
+/*
+Returns the lines commented with the line comments of the filetype,
+or uncommented if every line that isn't blank is already commented
+*/
+func ToggleLineComments(lines []string, ft Filetype) []string {
+	start, end := ft.LineComment, ""
+	if start == "" {
+		start, end = ft.BlockComment[0], ft.BlockComment[1]
+	}
+	if start == "" {
+		return lines
+	}
+
+	commented := func(line string) bool {
+		text := strings.TrimSpace(line)
+		return strings.HasPrefix(text, start) && strings.HasSuffix(text, end) && len(text) >= len(start)+len(end)
+	}
+
+	uncomment := true
+	indent := -1
+	for _, line := range lines {
+		if isBlankLine(line) {
+			continue
+		}
+		if !commented(line) {
+			uncomment = false
+		}
+		if n := FirstNonBlank(line); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent < 0 { // every line is blank
+		return lines
+	}
+
+	toggled := make([]string, len(lines))
+	for i, line := range lines {
+		switch {
+		case isBlankLine(line):
+			toggled[i] = line
+		case uncomment:
+			toggled[i] = uncommentLine(line, start, end)
+		case end != "":
+			toggled[i] = line[:indent] + start + " " + strings.TrimRight(line[indent:], " \t") + " " + end
+		default:
+			toggled[i] = line[:indent] + start + " " + line[indent:]
+		}
+	}
+
+	return toggled
+}
+
+/*
+Returns the line without the comment markers around its text,
+and without one space between each marker and the text
+*/
+func uncommentLine(line string, start string, end string) string {
+	i := FirstNonBlank(line)
+	text := strings.TrimRight(line[i+len(start):], " \t")
+	text = strings.TrimPrefix(text[:len(text)-len(end)], " ")
+	if end != "" {
+		text = strings.TrimSuffix(text, " ")
+	}
+
+	return line[:i] + text
+}
+
+/*
+Returns the lines wrapped in a block comment of the filetype, where the start
+marker goes before the text of the first line and the end marker after the text
+of the last one, or unwrapped if they already are. Returns false if the
+filetype has no block comments
+*/
+func ToggleBlockComment(lines []string, ft Filetype) ([]string, bool) {
+	start, end := ft.BlockComment[0], ft.BlockComment[1]
+	if start == "" {
+		return lines, false
+	}
+
+	// blank lines around the lines are left as they are
+	first, last := 0, len(lines)-1
+	for first < last && isBlankLine(lines[first]) {
+		first++
+	}
+	for last > first && isBlankLine(lines[last]) {
+		last--
+	}
+	if isBlankLine(lines[first]) {
+		return lines, true
+	}
+
+	toggled := make([]string, len(lines))
+	copy(toggled, lines)
+
+	firstLine := lines[first]
+	i := FirstNonBlank(firstLine)
+	lastLine := strings.TrimRight(lines[last], " \t")
+
+	wrapped := strings.HasPrefix(firstLine[i:], start) && strings.HasSuffix(lastLine, end) &&
+		(first != last || len(lastLine)-i >= len(start)+len(end))
+
+	if wrapped {
+		if first == last {
+			toggled[first] = uncommentLine(firstLine, start, end)
+			return toggled, true
+		}
+
+		toggled[first] = firstLine[:i] + strings.TrimPrefix(firstLine[i+len(start):], " ")
+		if isBlankLine(toggled[first]) {
+			toggled[first] = ""
+		}
+		toggled[last] = strings.TrimRight(strings.TrimSuffix(lastLine, end), " \t")
+		return toggled, true
+	}
+
+	toggled[first] = firstLine[:i] + start + " " + firstLine[i:]
+	toggled[last] = strings.TrimRight(toggled[last], " \t") + " " + end
+
+	return toggled, true
+}
+
+/*
+Toggles the line comments of the lines of the cursors
+*/
+func (f *FileEditor) ToggleComment() {
+	if !f.writable() {
+		return
+	}
+
+	ft := FiletypeFor(f.Filename)
+	if ft.LineComment == "" && ft.BlockComment[0] == "" {
+		f.statusMessage = "No comments in " + ft.Name + " files"
+		return
+	}
+
+	first, last := f.cursorLines()
+	f.pushUndo()
+	f.reindentLines(first, ToggleLineComments(f.FileBuffer[first:last+1], ft))
+	f.bufferModified()
+}
+
+/*
+Toggles a block comment around the lines of the cursors
+*/
+func (f *FileEditor) ToggleBlockComment() {
+	if !f.writable() {
+		return
+	}
+
+	ft := FiletypeFor(f.Filename)
+	first, last := f.cursorLines()
+
+	lines, ok := ToggleBlockComment(f.FileBuffer[first:last+1], ft)
+	if !ok {
+		f.statusMessage = "No block comments in " + ft.Name + " files"
+		return
+	}
+
+	f.pushUndo()
+	f.reindentLines(first, lines)
+	f.bufferModified()
+}
@@ -0,0 +1,376 @@
+package fileeditor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/render"
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for word completion.
+
+In Edit mode, Ctrl+N and Ctrl+P open a popup under the cursor that lists the
+words starting with the word before the cursor, found in every open buffer.
+The words closest to the cursor come first, then the words that occur the most,
+and the words that are only in other buffers come last.
+
+While the popup is open:
+  - Ctrl+N and the down arrow key select the next word, and Ctrl+P and the
+    up arrow key the previous one
+  - enter and tab insert the rest of the selected word at every cursor
+  - typing a word character or backspace keeps the popup open, listing the
+    words that start with the new word before the cursor
+  - escape, and every other key, close the popup
+
+The popup is drawn under the cursor, or above it when there is no room
+under it, and is moved left to stay inside of the window
+*/
+
+const (
+	completionMaxHeight int = 10 // the most words the popup shows at once
+	completionMaxWidth  int = 40
+)
+
+type Completion struct {
+	prefix   string    // the word before the cursor that the words start with
+	start    BufferPos // where the prefix starts
+	words    []string
+	selected int
+	scroll   int
+}
+
+/*
+Returns the words of the buffers that start with the prefix and are longer
+than it, ranked by how close their nearest occurrence in the first buffer is
+to the line of the position, and then by how often they occur in all of the
+buffers. The word at the position, which is in the first buffer, is skipped
+*/
+func CompletionWords(prefix string, buffers [][]string, pos BufferPos, wordChars string) []string {
+	type rank struct {
+		distance int // -1 if the word is only in other buffers
+		count    int
+	}
+
+	ranks := make(map[string]*rank)
+	for b, lines := range buffers {
+		for l, line := range lines {
+			for i := 0; i < len(line); {
+				if !isWordChar(line[i], wordChars) {
+					i++
+					continue
+				}
+
+				start := i
+				for i < len(line) && isWordChar(line[i], wordChars) {
+					i++
+				}
+
+				word := line[start:i]
+				if len(word) <= len(prefix) || !strings.HasPrefix(word, prefix) {
+					continue
+				}
+				if b == 0 && l == pos.Line && start <= pos.Index && pos.Index <= i {
+					continue
+				}
+
+				r, ok := ranks[word]
+				if !ok {
+					r = &rank{distance: -1}
+					ranks[word] = r
+				}
+				r.count++
+
+				if distance := math.Abs(l - pos.Line); b == 0 && (r.distance < 0 || distance < r.distance) {
+					r.distance = distance
+				}
+			}
+		}
+	}
+
+	words := make([]string, 0, len(ranks))
+	for word := range ranks {
+		words = append(words, word)
+	}
+
+	slices.SortFunc(words, func(a, b string) int {
+		ra, rb := ranks[a], ranks[b]
+		switch {
+		case ra.distance != rb.distance && (ra.distance < 0 || rb.distance < 0):
+			return rb.distance - ra.distance // the words of the first buffer go first
+		case ra.distance != rb.distance:
+			return ra.distance - rb.distance
+		case ra.count != rb.count:
+			return rb.count - ra.count
+		}
+		return strings.Compare(a, b)
+	})
+
+	return words
+}
+
+/*
+Returns the word characters right before the cursor, and where they start
+*/
+func (f *FileEditor) wordBeforeCursor() (string, BufferPos) {
+	pos := f.cursorPos()
+	line := f.FileBuffer[pos.Line]
+
+	start := pos.Index
+	for start > 0 && isWordChar(line[start-1], f.WordChars) {
+		start--
+	}
+
+	return line[start:pos.Index], BufferPos{Line: pos.Line, Index: start}
+}
+
+/*
+Returns the words that complete the word before the cursor, from the
+current buffer and then the other open buffers
+*/
+func (f *FileEditor) completionWords(prefix string) []string {
+	buffers := make([][]string, 0, len(f.buffers))
+	buffers = append(buffers, f.FileBuffer)
+	for i, b := range f.buffers {
+		if i != f.currentBuffer {
+			buffers = append(buffers, b.FileBuffer)
+		}
+	}
+
+	return CompletionWords(prefix, buffers, f.cursorPos(), f.WordChars)
+}
+
+/*
+Opens the popup with the words that complete the word before the cursor,
+selecting the first word, or the last one if last is true. Selects the
+next word instead if the popup is already open, or the previous one
+*/
+func (f *FileEditor) Complete(last bool) {
+	if f.completion != nil {
+		amount := 1
+		if last {
+			amount = -1
+		}
+		f.moveCompletionSelection(amount)
+		return
+	}
+
+	if f.EditorMode != EditorEditMode {
+		f.statusMessage = "Words are only completed in Edit mode"
+		return
+	}
+
+	prefix, start := f.wordBeforeCursor()
+	if prefix == "" {
+		f.statusMessage = "No word before the cursor"
+		return
+	}
+
+	words := f.completionWords(prefix)
+	if len(words) == 0 {
+		f.statusMessage = "No completions for " + prefix
+		return
+	}
+
+	f.completion = &Completion{prefix: prefix, start: start, words: words}
+	if last {
+		f.moveCompletionSelection(-1)
+	}
+}
+
+func (f *FileEditor) CloseCompletion() {
+	f.completion = nil
+}
+
+/*
+Moves the selection of the popup by the amount, wrapping around its ends
+*/
+func (f *FileEditor) moveCompletionSelection(amount int) {
+	c := f.completion
+	n := len(c.words)
+	c.selected = ((c.selected+amount)%n + n) % n
+
+	_, height, _, _ := f.completionBox()
+	rows := math.Max(height-2, 1)
+	if c.selected < c.scroll {
+		c.scroll = c.selected
+	} else if c.selected >= c.scroll+rows {
+		c.scroll = c.selected - rows + 1
+	}
+}
+
+/*
+Lists the words again after the word before the cursor has changed, keeping
+the selected word selected. Closes the popup when the cursor has left the
+word, or when no word completes it anymore
+*/
+func (f *FileEditor) refreshCompletion() {
+	c := f.completion
+
+	prefix, start := f.wordBeforeCursor()
+	if f.EditorMode != EditorEditMode || f.CommandBarToggled || f.picker != nil || f.prompt != nil ||
+		start != c.start || prefix == "" {
+		f.completion = nil
+		return
+	}
+	if prefix == c.prefix {
+		return
+	}
+
+	words := f.completionWords(prefix)
+	if len(words) == 0 {
+		f.completion = nil
+		return
+	}
+
+	selected := c.words[c.selected]
+	c.prefix, c.words, c.selected, c.scroll = prefix, words, 0, 0
+	if i := slices.Index(words, selected); i >= 0 {
+		f.moveCompletionSelection(i)
+	}
+}
+
+/*
+Inserts the rest of the selected word at every cursor, and closes the popup
+*/
+func (f *FileEditor) acceptCompletion() byte {
+	c := f.completion
+	f.completion = nil
+	rest := c.words[c.selected][len(c.prefix):]
+
+	f.pushUndo()
+
+	cursors := f.allCursors()
+	edits := make([]TextEdit, len(cursors))
+	for i, pos := range cursors {
+		edits[i] = TextEdit{Start: pos, End: pos, Text: rest}
+	}
+	f.setCursors(f.ApplyEdits(edits))
+
+	for i := 0; i < len(rest); i++ {
+		f.recordInsertedKey(rest[i])
+	}
+
+	return EnumKeyboardInput
+}
+
+/*
+Handles the keys pressed while the popup is open. Returns the flag that should
+be sent to the render loop, and false if the key should be handled as usual
+*/
+func (f *FileEditor) handleCompletionInput(key byte) (byte, bool) {
+	if key == NewLine || key == Tab {
+		return f.acceptCompletion(), true
+	}
+
+	// the word before the cursor is completed again after the key is typed
+	if key == Backspace || isWordChar(key, f.WordChars) {
+		return 0, false
+	}
+
+	if b, _ := f.Keybindings.Lookup(f.EditorMode, []byte{key}); b != nil &&
+		(b.Action == ActionCompleteNext || b.Action == ActionCompletePrev) {
+		return 0, false
+	}
+
+	f.CloseCompletion()
+	return 0, false
+}
+
+/*
+Handles the escape sequences received while the popup is open. Returns the flag
+that should be sent to the render loop, and false if it should be handled as usual
+*/
+func (f *FileEditor) handleCompletionEscapeInput(buf []byte, n int) (byte, bool) {
+	switch {
+	case n == 1:
+		f.CloseCompletion()
+		return EnumCursorPositionChange, true
+	case n == 3 && buf[2] == UpArrowKey:
+		f.moveCompletionSelection(-1)
+		return EnumCursorPositionChange, true
+	case n == 3 && buf[2] == DownArrowKey:
+		f.moveCompletionSelection(1)
+		return EnumCursorPositionChange, true
+	}
+
+	f.CloseCompletion()
+	return 0, false
+}
+
+/*
+Returns the size and position of the popup, which is under the cursor if it fits
+there and above it otherwise, with the words lined up with the word before the cursor
+*/
+func (f FileEditor) completionBox() (width, height, x, y int) {
+	c := f.completion
+
+	longest := 0
+	for _, word := range c.words {
+		longest = math.Max(longest, len(word))
+	}
+	width = math.Min(math.Min(longest, completionMaxWidth)+4, f.EditorWidth) // +4 for the borders and padding
+	height = math.Min(len(c.words), completionMaxHeight) + 2
+
+	cursorRow := f.EditorY + f.apparentCursorY
+	below := f.EditorY + f.GetViewportHeight() - cursorRow // rows under the cursor
+	above := cursorRow - 1 - f.EditorY
+
+	if height <= below || below >= above {
+		height = math.Max(math.Min(height, below), 3)
+		y = cursorRow
+	} else {
+		height = math.Max(math.Min(height, above), 3)
+		y = cursorRow - 1 - height
+	}
+
+	x = f.EditorX + f.apparentCursorX - len(c.prefix) - 3
+	x = math.Clamp(x, f.EditorX, math.Max(f.EditorX+f.EditorWidth-width, f.EditorX))
+	y = math.Max(y, 0)
+
+	return width, height, x, y
+}
+
+/*
+Draws the popup over the editor, with the selected word highlighted
+*/
+func (f FileEditor) PrintCompletion() {
+	c := f.completion
+	width, height, x, y := f.completionBox()
+	innerWidth := width - 4
+
+	render.DrawBox(render.Box{
+		Width: width, Height: height,
+		X: x, Y: y,
+		BorderColor: modeColors[f.EditorMode],
+	}, true)
+
+	prefix := make([]int, len(c.prefix))
+	for i := range prefix {
+		prefix[i] = i
+	}
+
+	for r := 0; r < height-2; r++ {
+		i := r + c.scroll
+		if i >= len(c.words) {
+			break
+		}
+
+		word := c.words[i]
+		if len(word) > innerWidth {
+			word = word[:innerWidth]
+		}
+
+		var bg string
+		if i == c.selected {
+			bg = pickerSelectedColor
+		}
+
+		ansi.MoveCursor(y+2+r, x+2)
+		fmt.Print(bg + " " + highlightMatches(word, prefix, bg) + bg)
+		fmt.Printf("%-*s%s", innerWidth-len(word)+1, "", Reset)
+	}
+}
@@ -0,0 +1,28 @@
+package fileeditor
+
+import (
+	"os"
+	"path/filepath"
+)
+
+/*
+This file is responsible for locating the user's config files,
+which are stored in the "intuitive" directory of the user's config
+directory (like ~/.config/intuitive on Linux)
+*/
+
+const configDirName string = "intuitive"
+
+const keymapConfigName string = "keymap.conf"
+
+/*
+Returns the path of the config file with the name
+*/
+func ConfigPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, configDirName, name), nil
+}
@@ -14,44 +14,24 @@ Since the mappedBuffer is always sorted, a binary search is the perfect solution
 func CalcBufferLineFromACY(acY int, mappedBuffer []int, viewportOffsetY int) int {
 	var target int = math.Clamp(1, acY, acY+1) + viewportOffsetY
 
-	if target < len(mappedBuffer) && mappedBuffer[target-1] == target { // O(1) check
-		return target - 1
-	}
-
+	/*
+		finds the first line that ends at or after the target row; lines hidden
+		by a fold end where the line before them does, so they are never found
+	*/
 	var left int = 0
 	var right int = len(mappedBuffer) - 1
-	var res int = 0
 
-	for left <= right {
+	for left < right {
 		mid := (left + right) / 2
 
-		v := mappedBuffer[mid]
-
-		if target == v {
-			return mid
-		}
-
-		if target <= mappedBuffer[left] {
-			return left
-		}
-
-		if target >= mappedBuffer[right] {
-			return right
-		}
-
-		if right-left == 1 && target > mappedBuffer[left] && target <= mappedBuffer[right] {
-			return right
-		}
-
-		if target < v {
+		if mappedBuffer[mid] < target {
+			left = mid + 1
+		} else {
 			right = mid
-		} else if target > v {
-			left = mid
 		}
-
 	}
 
-	return res
+	return left
 }
 
 /*
@@ -152,7 +132,7 @@ func (f *FileEditor) UpdateBufferIndicies() {
 			f.bufferLine, f.VisualBuffer, f.VisualBufferMapped, f.ViewportOffsetY,
 		)
 	} else {
-		f.bufferLine = f.apparentCursorY + f.ViewportOffsetY - 1
+		f.bufferLine = f.lineAtRow(f.apparentCursorY + f.ViewportOffsetY - 1)
 		f.bufferIndex = f.apparentCursorX + f.ViewportOffsetX - EditorLeftMargin
 	}
 }
@@ -217,3 +197,78 @@ func (f *FileEditor) CalculateNewCursorPos() {
 	f.apparentCursorX = newACX + EditorLeftMargin - 1
 	f.apparentCursorY = newACY
 }
+
+/*
+Scrolls the viewport vertically only as much as needed for the row (0-indexed)
+of the visual buffer to be visible, and places the cursor on it
+*/
+func (f *FileEditor) scrollToVisualRow(row int) {
+	height := f.GetViewportHeight()
+
+	if row < f.ViewportOffsetY {
+		f.ViewportOffsetY = row
+	} else if row >= f.ViewportOffsetY+height {
+		f.ViewportOffsetY = row - height + 1
+	}
+
+	f.apparentCursorY = row - f.ViewportOffsetY + 1
+}
+
+/*
+Moves the cursor to the given line and index of the FileBuffer, scrolling the
+viewport when the position is not visible. Both values are 0-indexed, and the
+index is the actual index in the line, not the visual one, so tabs are accounted for.
+
+The buffer indicies are updated as well, so this can be used right after
+mutating the FileBuffer
+*/
+func (f *FileEditor) MoveCursorToBufferPos(line int, index int) {
+	f.RefreshVisualBuffers()
+
+	line = math.Clamp(line, 0, len(f.FileBuffer)-1)
+	index = math.Clamp(index, 0, len(f.FileBuffer[line]))
+	if f.revealLine(line) {
+		f.RefreshVisualBuffers()
+	}
+	visualIndex := f.GetVisualIndex(f.FileBuffer[line], index)
+
+	if f.SoftWrapEnabled {
+		var start int = 0
+		if line > 0 {
+			start = f.VisualBufferMapped[line-1]
+		}
+		end := f.VisualBufferMapped[line]
+
+		// find the word-wrapped row of the line that the index lands on
+		row := start
+		col := visualIndex
+		for row < end-1 && col >= len(f.VisualBuffer[row]) {
+			col -= len(f.VisualBuffer[row])
+			row++
+		}
+
+		f.scrollToVisualRow(row)
+		f.ViewportOffsetX = 0
+		f.apparentCursorX = col + EditorLeftMargin
+	} else {
+		f.scrollToVisualRow(f.rowOfLine(line))
+
+		width := f.GetViewportWidth()
+		if visualIndex < f.ViewportOffsetX || visualIndex > f.ViewportOffsetX+width-1 {
+			f.ViewportOffsetX = math.Max(visualIndex-width+1, 0)
+		}
+		f.apparentCursorX = visualIndex - f.ViewportOffsetX + EditorLeftMargin
+	}
+
+	f.bufferLine = line
+	f.bufferIndex = visualIndex
+	setSavedCursorX(f.apparentCursorX, f.ViewportOffsetX, false)
+}
+
+/*
+Returns the line and the actual index (both 0-indexed) in the FileBuffer of the cursor
+*/
+func (f FileEditor) CursorBufferPos() (line int, index int) {
+	line = math.Clamp(f.bufferLine, 0, len(f.FileBuffer)-1)
+	return line, f.GetActualIndex(f.FileBuffer[line], f.bufferIndex)
+}
@@ -0,0 +1,398 @@
+package fileeditor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/render"
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/diff"
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for the diff view, which shows the changes between
+the FileBuffer and the file on disk (or another file) before saving.
+
+The view takes over the editor area until it is closed, and is read-only;
+the only way it modifies the FileBuffer is by reverting a hunk, which replaces
+the lines of the hunk in the FileBuffer with the lines of the other file.
+
+Keys:
+  - j/k or the up/down arrow keys scroll the view
+  - n/N select the next/previous hunk
+  - r reverts the selected hunk
+  - s switches between the unified and side-by-side layout
+  - q closes the view
+*/
+
+const diffContextLines int = 3
+
+const (
+	DiffRowContext byte = ' '
+	DiffRowDeleted byte = '-'
+	DiffRowAdded   byte = '+'
+	DiffRowHeader  byte = '@'
+)
+
+var diffRowColors = map[byte]string{
+	DiffRowContext: ansi.NewRGBColor(150, 150, 150).ToFgColorANSI(),
+	DiffRowDeleted: ansi.NewRGBColor(230, 70, 70).ToFgColorANSI(),
+	DiffRowAdded:   ansi.NewRGBColor(80, 200, 120).ToFgColorANSI(),
+	DiffRowHeader:  ansi.NewRGBColor(75, 176, 255).ToFgColorANSI(),
+}
+
+var diffSelectedColor string = ansi.NewRGBColor(40, 40, 40).ToBgColorANSI()
+
+/*
+Represents a single row of the diff view. In the side-by-side layout,
+the old line is shown on the left and the new line on the right; either
+one can be missing, in which case its number is -1
+*/
+type DiffRow struct {
+	Kind    byte
+	Hunk    int
+	OldNum  int
+	NewNum  int
+	OldText string
+	NewText string
+}
+
+type DiffView struct {
+	Path       string   // path of the file that the FileBuffer is compared against
+	OtherLines []string // lines of that file
+	SideBySide bool
+
+	hunks        []diff.Hunk
+	unifiedRows  []DiffRow
+	sideRows     []DiffRow
+	scrollOffset int
+	selectedHunk int
+}
+
+/*
+Reads the file at the path and opens the diff view with it.
+If the path is empty, the FileBuffer is compared against its own file on disk
+*/
+func (f *FileEditor) OpenDiffView(path string) error {
+	if path == "" {
+		path = f.Filename
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	f.diffView = &DiffView{
+		Path:       path,
+		OtherLines: SplitLines(string(content)),
+	}
+	f.refreshDiffView()
+
+	return nil
+}
+
+func (f *FileEditor) CloseDiffView() {
+	f.diffView = nil
+	ansi.ClearEntireScreen()
+}
+
+/*
+Diffs the FileBuffer against the other file and rebuilds the rows of both layouts
+*/
+func (f *FileEditor) refreshDiffView() {
+	d := f.diffView
+	d.hunks = diff.Lines(d.OtherLines, f.FileBuffer)
+	d.unifiedRows, d.sideRows = DiffRows(d.hunks, d.OtherLines, f.FileBuffer)
+
+	d.selectedHunk = math.Clamp(d.selectedHunk, 0, math.Max(len(d.hunks)-1, 0))
+	d.scrollOffset = math.Clamp(d.scrollOffset, 0, math.Max(len(d.rows())-1, 0))
+}
+
+/*
+Returns the rows of the unified and the side-by-side layout of the hunks
+between the old and the new lines, where every hunk has a header and up
+to diffContextLines unchanged lines around it
+*/
+func DiffRows(hunks []diff.Hunk, oldLines []string, newLines []string) (unified []DiffRow, sideBySide []DiffRow) {
+	unified = make([]DiffRow, 0)
+	sideBySide = make([]DiffRow, 0)
+
+	var shownUntil int = 0 // old lines before this index have already been added as context
+	for i, h := range hunks {
+		contextStart := math.Max(h.OldStart-diffContextLines, shownUntil)
+		contextEnd := math.Min(h.OldStart+h.OldLines+diffContextLines, len(oldLines))
+		if i+1 < len(hunks) {
+			// the lines of the next hunk are not context
+			contextEnd = math.Min(contextEnd, hunks[i+1].OldStart)
+		}
+
+		header := DiffRow{
+			Kind: DiffRowHeader, Hunk: i, OldNum: -1, NewNum: -1,
+			OldText: fmt.Sprintf("@@ -%d,%d +%d,%d @@",
+				h.OldStart+1, h.OldLines, h.NewStart+1, h.NewLines),
+		}
+		header.NewText = header.OldText
+		unified = append(unified, header)
+		sideBySide = append(sideBySide, header)
+
+		// the new line number of an unchanged old line is the old one + offset
+		addContext := func(from, to int, offset int) {
+			for j := from; j < to; j++ {
+				row := DiffRow{
+					Kind: DiffRowContext, Hunk: i,
+					OldNum: j, NewNum: j + offset,
+					OldText: oldLines[j], NewText: oldLines[j],
+				}
+				unified = append(unified, row)
+				sideBySide = append(sideBySide, row)
+			}
+		}
+
+		addContext(contextStart, h.OldStart, h.NewStart-h.OldStart)
+
+		for j := 0; j < h.OldLines; j++ {
+			unified = append(unified, DiffRow{
+				Kind: DiffRowDeleted, Hunk: i,
+				OldNum: h.OldStart + j, NewNum: -1,
+				OldText: oldLines[h.OldStart+j],
+			})
+		}
+		for j := 0; j < h.NewLines; j++ {
+			unified = append(unified, DiffRow{
+				Kind: DiffRowAdded, Hunk: i,
+				OldNum: -1, NewNum: h.NewStart + j,
+				NewText: newLines[h.NewStart+j],
+			})
+		}
+
+		// pair up the deleted and added lines side by side
+		for j := 0; j < math.Max(h.OldLines, h.NewLines); j++ {
+			row := DiffRow{Kind: DiffRowAdded, Hunk: i, OldNum: -1, NewNum: -1}
+			if j < h.OldLines {
+				row.OldNum = h.OldStart + j
+				row.OldText = oldLines[row.OldNum]
+				row.Kind = DiffRowDeleted
+			}
+			if j < h.NewLines {
+				row.NewNum = h.NewStart + j
+				row.NewText = newLines[row.NewNum]
+			}
+			sideBySide = append(sideBySide, row)
+		}
+
+		addContext(h.OldStart+h.OldLines, contextEnd, h.NewStart+h.NewLines-h.OldStart-h.OldLines)
+		shownUntil = math.Max(contextEnd, h.OldStart+h.OldLines)
+	}
+
+	return unified, sideBySide
+}
+
+/*
+Returns the rows of the current layout
+*/
+func (d *DiffView) rows() []DiffRow {
+	if d.SideBySide {
+		return d.sideRows
+	}
+	return d.unifiedRows
+}
+
+/*
+Selects the hunk at the index, wrapping around both ends,
+and scrolls the view to its header
+*/
+func (d *DiffView) selectHunk(index int) {
+	if len(d.hunks) == 0 {
+		return
+	}
+
+	d.selectedHunk = (index + len(d.hunks)) % len(d.hunks)
+
+	for i, row := range d.rows() {
+		if row.Kind == DiffRowHeader && row.Hunk == d.selectedHunk {
+			d.scrollOffset = i
+			return
+		}
+	}
+}
+
+/*
+Replaces the lines of the selected hunk in the FileBuffer with the lines of the other file
+*/
+func (f *FileEditor) revertDiffHunk() {
+	d := f.diffView
+	if len(d.hunks) == 0 {
+		return
+	}
+
+	if !f.writable() {
+		return
+	}
+
+	h := d.hunks[d.selectedHunk]
+	f.pushUndo()
+	f.ReplaceLines(h.NewStart, h.NewLines, d.OtherLines[h.OldStart:h.OldStart+h.OldLines])
+
+	f.bufferModified()
+	d.selectHunk(d.selectedHunk)
+
+	f.MoveCursorToBufferPos(h.NewStart, 0)
+}
+
+/*
+Handles the keys pressed while the diff view is open. Returns the
+flag that should be sent to the render loop
+*/
+func (f *FileEditor) handleDiffViewInput(key byte) byte {
+	d := f.diffView
+	height := f.GetViewportHeight() - 2
+
+	switch key {
+	case 'j':
+		d.scrollOffset = math.Clamp(d.scrollOffset+1, 0, math.Max(len(d.rows())-height, 0))
+	case 'k':
+		d.scrollOffset = math.Max(d.scrollOffset-1, 0)
+	case 'n':
+		d.selectHunk(d.selectedHunk + 1)
+	case 'N':
+		d.selectHunk(d.selectedHunk - 1)
+	case 'r':
+		f.revertDiffHunk()
+	case 's':
+		d.SideBySide = !d.SideBySide
+		d.selectHunk(d.selectedHunk)
+	case 'q':
+		f.CloseDiffView()
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Handles the escape sequences received while the diff view is open,
+where the up and down arrow keys scroll the view
+*/
+func (f *FileEditor) handleDiffViewEscapeInput(buf []byte, n int) byte {
+	if n == 3 {
+		switch buf[2] {
+		case UpArrowKey:
+			return f.handleDiffViewInput('k')
+		case DownArrowKey:
+			return f.handleDiffViewInput('j')
+		}
+	}
+
+	return 0
+}
+
+/*
+Expands tabs and cuts the text so it fits within the width
+*/
+func (f FileEditor) fitDiffText(text string, width int) string {
+	text = strings.ReplaceAll(text, string(Tab), strings.Repeat(" ", int(f.TabSize)))
+	if width <= 0 {
+		return ""
+	}
+	if len(text) > width {
+		return text[:width]
+	}
+	return text
+}
+
+/*
+Formats a line number of the diff view; missing lines are left blank
+*/
+func diffLineNum(num int) string {
+	if num < 0 {
+		return "    "
+	}
+	return fmt.Sprintf("%4d", num+1)
+}
+
+/*
+Draws the diff view over the editor area
+*/
+func (f *FileEditor) PrintDiffView() {
+	d := f.diffView
+	width := f.EditorWidth
+	height := f.EditorHeight
+	innerWidth := width - 2
+	borderRGB := modeColors[f.EditorMode]
+
+	render.DrawBox(render.Box{
+		Width: width, Height: height,
+		X: f.EditorX, Y: f.EditorY,
+		BorderColor: borderRGB,
+	}, true)
+
+	layout := "unified"
+	if d.SideBySide {
+		layout = "side-by-side"
+	}
+	ansi.MoveCursor(f.EditorY+1, f.EditorX+3)
+	fmt.Printf("%s diff %s (%d hunks, %s) %s", borderRGB.ToFgColorANSI(), d.Path, len(d.hunks), layout, Reset)
+
+	rows := d.rows()
+	half := (innerWidth - 1) / 2
+
+	for r := 0; r < height-2; r++ {
+		ansi.MoveCursor(f.EditorY+r+2, f.EditorX+2)
+		i := r + d.scrollOffset
+		if i >= len(rows) {
+			fmt.Printf("%*s", innerWidth, "")
+			continue
+		}
+
+		row := rows[i]
+		var bg string
+		if row.Hunk == d.selectedHunk && row.Kind == DiffRowHeader {
+			bg = diffSelectedColor
+		}
+
+		if row.Kind == DiffRowHeader {
+			fmt.Printf("%s%s%-*s%s", bg, diffRowColors[DiffRowHeader], innerWidth, f.fitDiffText(row.OldText, innerWidth), Reset)
+			continue
+		}
+
+		if !d.SideBySide {
+			var text string = row.NewText
+			if row.Kind == DiffRowDeleted {
+				text = row.OldText
+			}
+			textWidth := innerWidth - 12
+			fmt.Printf("%s%s %s %c %-*s%s",
+				diffRowColors[row.Kind], diffLineNum(row.OldNum), diffLineNum(row.NewNum), row.Kind,
+				textWidth, f.fitDiffText(text, textWidth), Reset)
+			continue
+		}
+
+		textWidth := half - 5
+		leftKind, rightKind := row.Kind, row.Kind
+		if row.Kind != DiffRowContext {
+			leftKind, rightKind = DiffRowDeleted, DiffRowAdded
+		}
+
+		var left, right string
+		if row.OldNum >= 0 {
+			left = diffLineNum(row.OldNum) + " " + f.fitDiffText(row.OldText, textWidth)
+		}
+		if row.NewNum >= 0 {
+			right = diffLineNum(row.NewNum) + " " + f.fitDiffText(row.NewText, textWidth)
+		}
+
+		fmt.Printf("%s%-*s%s", diffRowColors[leftKind], half, left, Reset)
+		ansi.MoveCursor(f.EditorY+r+2, f.EditorX+half+3)
+		fmt.Printf("%s%-*s%s", diffRowColors[rightKind], innerWidth-half-1, right, Reset)
+	}
+
+	if d.SideBySide {
+		render.DrawVerticalLine(render.Line{
+			Length: height - 2,
+			X:      f.EditorX + half + 1, Y: f.EditorY + 1,
+			LineColor: borderRGB,
+		}, true)
+	}
+}
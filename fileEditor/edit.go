@@ -0,0 +1,245 @@
+package fileeditor
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file contains editing primitives that work directly with the
+lines and indicies of the FileBuffer instead of the apparent cursor position.
+They do not move the cursor; callers are expected to place it afterwards
+with MoveCursorToBufferPos
+*/
+
+/*
+Replaces count lines of the FileBuffer, starting at the start line (0-indexed),
+with the given lines. The FileBuffer is never left without a line
+*/
+func (f *FileEditor) ReplaceLines(start int, count int, lines []string) {
+	result := make([]string, 0, len(f.FileBuffer)-count+len(lines))
+	result = append(result, f.FileBuffer[:start]...)
+	result = append(result, lines...)
+	result = append(result, f.FileBuffer[start+count:]...)
+
+	if len(result) == 0 {
+		result = append(result, "")
+	}
+
+	f.FileBuffer = result
+	f.Saved = false
+	f.linesReplaced(start, count, len(lines))
+}
+
+/*
+Refreshes everything that is derived from the FileBuffer
+after it has been modified
+*/
+func (f *FileEditor) bufferModified() {
+	f.foldRegionCache = nil
+	f.refreshGitHunks()
+	f.refreshBlameMapping()
+
+	if f.diffView != nil {
+		f.refreshDiffView()
+	}
+}
+
+/*
+Returns the text from the start position up to the end position, which
+is exclusive. Lines are joined with a new line
+*/
+func (f FileEditor) TextInRange(start BufferPos, end BufferPos) string {
+	if start.Line == end.Line {
+		return f.FileBuffer[start.Line][start.Index:end.Index]
+	}
+
+	lines := make([]string, 0, end.Line-start.Line+1)
+	lines = append(lines, f.FileBuffer[start.Line][start.Index:])
+	lines = append(lines, f.FileBuffer[start.Line+1:end.Line]...)
+	lines = append(lines, f.FileBuffer[end.Line][:end.Index])
+
+	return strings.Join(lines, "\n")
+}
+
+/*
+Deletes the text from the start position up to the end position, which is
+exclusive, joining the start and end lines
+*/
+func (f *FileEditor) DeleteRange(start BufferPos, end BufferPos) {
+	joined := f.FileBuffer[start.Line][:start.Index] + f.FileBuffer[end.Line][end.Index:]
+	f.ReplaceLines(start.Line, end.Line-start.Line+1, []string{joined})
+}
+
+/*
+Inserts the text at the position, splitting lines at every new line of the
+text. Returns the position right after the inserted text
+*/
+func (f *FileEditor) InsertText(pos BufferPos, text string) BufferPos {
+	line := f.FileBuffer[pos.Line]
+
+	lines := strings.Split(text, "\n")
+	last := len(lines) - 1
+
+	lines[0] = line[:pos.Index] + lines[0]
+	end := BufferPos{Line: pos.Line + last, Index: len(lines[last])}
+	lines[last] += line[pos.Index:]
+
+	f.ReplaceLines(pos.Line, 1, lines)
+
+	return end
+}
+
+/*
+Returns the text that one level of indentation is made of,
+according to how tabs are stored in the FileBuffer
+*/
+func (f FileEditor) indentUnit() string {
+	if f.TabIndentType == IndentWithSpace {
+		return strings.Repeat(" ", int(f.TabSize))
+	}
+
+	return string(Tab)
+}
+
+/*
+Returns the line indented by one level, or outdented if outdent is true.
+Empty lines are not indented
+*/
+func (f FileEditor) shiftLine(line string, outdent bool) string {
+	if !outdent {
+		if line != "" {
+			line = f.indentUnit() + line
+		}
+		return line
+	}
+
+	if strings.HasPrefix(line, string(Tab)) {
+		return line[1:]
+	}
+
+	// remove up to a tab's worth of spaces
+	n := 0
+	for n < len(line) && n < int(f.TabSize) && line[n] == Space {
+		n++
+	}
+	return line[n:]
+}
+
+/*
+Indents the lines from the start line to the end line (inclusive) by one
+level, or outdents them if outdent is true
+*/
+func (f *FileEditor) ShiftLines(start int, end int, outdent bool) {
+	lines := make([]string, 0, end-start+1)
+	for _, line := range f.FileBuffer[start : end+1] {
+		lines = append(lines, f.shiftLine(line, outdent))
+	}
+
+	f.ReplaceLines(start, len(lines), lines)
+}
+
+/*
+Replaces the lines from the start line with the lines, which only differ
+from them around their text, like in their indentation or comment markers,
+keeping every cursor on the same text
+*/
+func (f *FileEditor) reindentLines(start int, lines []string) {
+	cursors := f.allCursors()
+	for i, c := range cursors {
+		if c.Line < start || c.Line >= start+len(lines) {
+			continue
+		}
+
+		old, line := f.FileBuffer[c.Line], lines[c.Line-start]
+		if c.Index >= FirstNonBlank(old) {
+			cursors[i].Index = math.Clamp(c.Index+len(line)-len(old), FirstNonBlank(line), len(line))
+		} else {
+			cursors[i].Index = math.Min(c.Index, FirstNonBlank(line))
+		}
+	}
+
+	f.ReplaceLines(start, len(lines), lines)
+	f.setCursors(cursors)
+}
+
+type TextEdit struct {
+	Start BufferPos
+	End   BufferPos // exclusive
+	Text  string    // replaces the text from Start up to End
+}
+
+/*
+Returns where the position, which is after the old end of an edit, moves to
+after the edit, when the text of the edit ends at the new end
+*/
+func shiftPos(pos BufferPos, oldEnd BufferPos, newEnd BufferPos) BufferPos {
+	if pos.Line == oldEnd.Line {
+		return BufferPos{Line: newEnd.Line, Index: newEnd.Index + pos.Index - oldEnd.Index}
+	}
+
+	return BufferPos{Line: pos.Line + newEnd.Line - oldEnd.Line, Index: pos.Index}
+}
+
+/*
+Applies every edit at once, as if each was made on the FileBuffer as it was
+before any of them. An edit whose range overlaps the edit before it, or starts
+where it does, is merged into it, so that the text of the edit before it
+replaces both ranges, and no text is deleted or inserted twice.
+
+Returns the position right after the text of each edit, in the order of the
+edits, where merged edits share the position of the edit they were merged into
+*/
+func (f *FileEditor) ApplyEdits(edits []TextEdit) []BufferPos {
+	order := make([]int, len(edits))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return edits[order[a]].Start.Before(edits[order[b]].Start)
+	})
+
+	type group struct {
+		TextEdit
+		members []int // indicies of the edits merged into the group
+	}
+
+	groups := make([]group, 0, len(edits))
+	for _, i := range order {
+		e := edits[i]
+		if n := len(groups); n > 0 && (e.Start.Before(groups[n-1].End) || e.Start == groups[n-1].Start) {
+			g := &groups[n-1]
+			if g.End.Before(e.End) {
+				g.End = e.End
+			}
+			g.members = append(g.members, i)
+			continue
+		}
+
+		groups = append(groups, group{TextEdit: e, members: []int{i}})
+	}
+
+	// the groups are applied from the first to the last, shifting the ones after each
+	ends := make([]BufferPos, len(edits))
+	for k, g := range groups {
+		if g.Start != g.End {
+			f.DeleteRange(g.Start, g.End)
+		}
+		end := g.Start
+		if g.Text != "" {
+			end = f.InsertText(g.Start, g.Text)
+		}
+
+		for _, i := range g.members {
+			ends[i] = end
+		}
+		for j := k + 1; j < len(groups); j++ {
+			groups[j].Start = shiftPos(groups[j].Start, g.End, end)
+			groups[j].End = shiftPos(groups[j].End, g.End, end)
+		}
+	}
+
+	return ends
+}
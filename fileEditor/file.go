@@ -11,20 +11,28 @@ import (
 Writes the current FileBuffer to the opened file
 */
 func (f *FileEditor) SaveFile() {
+	if !f.writable() {
+		return
+	}
+
 	f.Saved = true
 
 	data := strings.Join(f.FileBuffer, "\n")
 	os.WriteFile(f.Filename, []byte(data), 0644)
+
+	f.LoadGitBase()
+	f.SaveMarks()
 }
 
 /*
 Opens the file or creates a new one if it cannot be found,
-reads its content into the buffer
+reads its content into the buffer. In read-only mode, a missing
+file is not created, and the file is left nil
 */
 func (f *FileEditor) OpenFile() {
 	// file, err := os.OpenFile(f.Filename, os.O_WRONLY, 0644)
 	file, err := os.Open(f.Filename)
-	if err != nil {
+	if err != nil && !f.ReadOnly {
 		file, _ = os.Create(f.Filename)
 	}
 
@@ -3,6 +3,8 @@ package fileeditor
 import (
 	"bufio"
 	"os"
+	"regexp"
+	"sync"
 
 	"fmt"
 
@@ -10,6 +12,7 @@ import (
 
 	"github.com/Asiandayboy/CLITextEditor/render"
 	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/diff"
 	"golang.org/x/term"
 )
 
@@ -44,6 +47,8 @@ const (
 	EnumSoftWrapEnabled
 	EnumSoftWrapDisabled
 	EnumToggleCommandBar
+	EnumKeySequenceTimeout
+	EnumFollowTick
 )
 
 const (
@@ -68,45 +73,114 @@ var modeColors = map[byte]ansi.RGBColor{
 left margin accounts for the line numbers, and the vertical border
 
 4 for the digits (I doubt a single file will exceed 9999 lines)
-1 for the space between the line numbers and the vertical border
+1 for the git change marker between the line numbers and the vertical border
 1 for the vertical border
 1 for the space between the vertical border and the start of the line
 1 for the start of the line
 */
 const EditorLeftMargin int = 8
 
+/*
+The state of a file that is open in the editor. The editor can have multiple
+buffers open, but only the current one is stored in the FileEditor itself
+*/
+type bufferState struct {
+	Saved      bool // refers to whether the file has been saved since the last modification
+	file       *os.File
+	Filename   string
+	FileBuffer []string // contains each line of the actual file
+
+	undoStack       []undoState
+	redoStack       []undoState
+	typingUndoGroup bool // true while consecutive typed edits are grouped into the same undo step
+
+	gitBaseLines []string     // lines of the file as staged in the git index; nil if the file isn't tracked
+	gitHunks     []diff.Hunk  // changes between gitBaseLines and the FileBuffer
+	gitMarkers   map[int]byte // gutter marker for each changed line of the FileBuffer
+
+	blameEnabled bool
+	blameLines   []BlameLine // blamed lines of the saved file
+	blameMapped  []int       // index of the blamed line for each line of the FileBuffer; -1 if it has changed
+
+	diffView *DiffView // nil when the diff view is closed
+
+	marks     map[byte]BufferPos // marks a-z of the buffer
+	bookmarks []int              // bookmarked lines, in order
+
+	cursors []BufferPos // secondary cursors, besides the cursor, in order
+
+	folds           []FoldRegion // closed folds
+	foldRegionCache []FoldRegion // regions that can be folded; nil until they are needed after a change
+	foldMethod      uint8        // how the regions are found; 0 to fold as the filetype does
+
+	TabIndentType uint8 // determines how tabs are stored in the FileBuffer (either as ASCII 9 or ASCII 32); detected when the file is read
+	TabSize       uint8
+}
+
+/*
+The position of the cursor and the viewport over a buffer
+*/
+type viewState struct {
+	apparentCursorX int // cursor's X position
+	apparentCursorY int // cursor's Y position
+	bufferLine      int // refers to current line of FileBuffer; used when editing FileBuffer
+	bufferIndex     int // refers to current index of current line of FileBuffer; used when editing FileBuffer
+	ViewportOffsetX int // used for horizontal scrolling
+	ViewportOffsetY int // used for vertical scrolling
+}
+
 type FileEditor struct {
-	Saved           bool // refers to whether the file has been saved since the last modification
+	bufferState
+	viewState
+
 	EditorMode      byte
 	Keybindings     Keybind
-	file            *os.File
-	Filename        string
+	pendingKeys     *pendingKeys // keys typed so far of a key sequence
+	modal           modalState   // the command being typed in Command mode, the register and the last change
+	macros          macroState   // the recorded macros, and the one being recorded or played
 	inputChan       chan byte
+	inputLock       *sync.Mutex // held while the input is handled, and while the render loop renders
 	QuitProgramFlag bool
 
 	CommandBarBuffer  string
 	CommandBarCursorX int
 
-	FileBuffer         []string   // contains each line of the actual file
+	buffers       []*openBuffer // every open buffer; the entry of the current buffer is only updated when switching away from it
+	currentBuffer int           // index of the current buffer in buffers
+
+	layoutRoot    *layoutNode
+	windows       []*window
+	focusedWindow *window
+	EditorX       int // offset of the focused window from the left of the terminal
+	EditorY       int // offset of the focused window from the top of the terminal
+	EditorWidth   int // width of the focused window, including the line numbers
+	EditorHeight  int // height of the focused window
+
 	VisualBuffer       []string   // contains word wrapped lines; this is what gets rendered to the screen
 	VisualBufferMapped []int      // contains the ending index (1-indexed) of word-wrapped lines
-	apparentCursorX    int        // cursor's X position
-	apparentCursorY    int        // cursor's Y position
-	bufferLine         int        // refers to current line of FileBuffer; used when editing FileBuffer
-	bufferIndex        int        // refers to current index of current line of FileBuffer; used when editing FileBuffer
 	TermWidth          int        // width of the terminal window
 	TermHeight         int        // height of the terminal window
 	StatusBarHeight    int        // height of the status bar
-	ViewportOffsetX    int        // used for horizontal scrolling
-	ViewportOffsetY    int        // used for vertical scrolling
 	TabMap             TabMapType // stores the start and end indicies of each tab character; used only when TabIndentType = IndentWithTab
 	CommandBarToggled  bool
+	statusMessage      string      // shown in the status bar until the next key is pressed
+	statusInfo         bool        // true if the status message is information rather than an error
+	picker             *Picker     // nil when no picker is open
+	prompt             *Prompt     // nil when no prompt is open
+	completion         *Completion // nil when no completion popup is open
+	fileTree           *FileTree   // nil until the file tree is first shown
+	grepPanel          *GrepPanel  // nil when the results panel is closed
+	lastSearch         *regexp.Regexp
+	follow             *followState // nil when the file isn't being followed
+	jumps              JumpList
+	globalMarks        map[byte]Jump // marks A-Z; nil until the marks are first loaded
+	blockAnchorLine    int           // the line an alt+drag of the mouse started on
 
 	// Configs
+	ReadOnly        bool // no buffer can be modified or saved
 	SoftWrapEnabled bool
-	PrintEmptyLines bool  // print tildes for empty lines
-	TabIndentType   uint8 // determines how tabs are stored in the FileBuffer (either as ASCII 9 or ASCII 32)
-	TabSize         uint8
+	PrintEmptyLines bool   // print tildes for empty lines
+	WordChars       string // characters other than letters and digits that words are made of
 
 	// debugging
 	actualBufferIndex int
@@ -118,29 +192,56 @@ func NewFileEditor(filename string) FileEditor {
 		panic(err)
 	}
 
-	return FileEditor{
-		Filename:           filename,
-		FileBuffer:         make([]string, 0),
+	return NewFileEditorWithSize(filename, width, height)
+}
+
+/*
+Returns an editor for a terminal of the given size, rather than the size
+of the terminal the editor runs in
+*/
+func NewFileEditorWithSize(filename string, width int, height int) FileEditor {
+	f := FileEditor{
+		bufferState: bufferState{
+			Filename:      filename,
+			FileBuffer:    make([]string, 0),
+			Saved:         true,
+			TabIndentType: defaultTabIndentType,
+			TabSize:       defaultTabSize,
+		},
+		buffers:            []*openBuffer{{}},
 		VisualBuffer:       make([]string, 0),
 		VisualBufferMapped: make([]int, 0),
 		TermWidth:          width,
 		TermHeight:         height,
 		StatusBarHeight:    3,
-		Saved:              true,
 		EditorMode:         EditorCommandMode,
 		Keybindings:        NewKeybind(),
+		pendingKeys:        &pendingKeys{},
 		inputChan:          make(chan byte, 1),
+		inputLock:          &sync.Mutex{},
 		CommandBarToggled:  false,
 		QuitProgramFlag:    false,
 
 		SoftWrapEnabled: true,
 		PrintEmptyLines: false,
-		TabIndentType:   IndentWithTab,
-		TabSize:         4,
+		WordChars:       "_",
+	}
+	f.initLayout()
+
+	if path, err := ConfigPath(keymapConfigName); err == nil {
+		if err := f.Keybindings.LoadConfig(path); err != nil {
+			f.statusMessage = err.Error()
+		}
 	}
+
+	return f
 }
 
 func (f *FileEditor) ReadFileToBuffer() error {
+	if f.file == nil { // missing files aren't created in read-only mode
+		return fmt.Errorf("%s: %w", f.Filename, os.ErrNotExist)
+	}
+
 	scanner := bufio.NewScanner(f.file)
 	var rowCount int = 0
 	for scanner.Scan() {
@@ -166,6 +267,10 @@ func (f *FileEditor) ReadFileToBuffer() error {
 	f.bufferLine = 0
 	f.bufferIndex = 0
 
+	f.detectIndent()
+	f.LoadGitBase()
+	f.LoadMarks()
+
 	return scanner.Err()
 }
 
@@ -198,6 +303,38 @@ func (f FileEditor) PrintStatusBar() {
 		fmt.Print(Green + f.Filename + Blue + Italic + savedText + Reset)
 	}
 
+	if f.ReadOnly {
+		fmt.Print(Grey + " [Read-only]" + Reset)
+	}
+
+	if len(f.buffers) > 1 {
+		fmt.Printf(Grey+" [%d/%d]"+Reset, f.currentBuffer+1, len(f.buffers))
+	}
+
+	if len(f.cursors) > 0 {
+		fmt.Printf(Grey+" [%d cursors]"+Reset, len(f.cursors)+1)
+	}
+
+	if f.statusMessage != "" {
+		fmt.Print(Grey + "  " + f.statusMessage + Reset)
+	}
+
+	if keys := f.PendingKeys(); len(keys) > 0 {
+		fmt.Print(Yellow + "  " + keySequenceName(keys) + " ..." + Reset)
+	}
+
+	if f.modal.pending() {
+		fmt.Print(Yellow + "  " + string(f.modal.keys) + Reset)
+	}
+
+	if f.follow != nil {
+		fmt.Print(Yellow + "  Following (press any key to stop)" + Reset)
+	}
+
+	if f.macros.recording != 0 {
+		fmt.Printf(Yellow+"  Recording @%c"+Reset, f.macros.recording)
+	}
+
 	// draw buffer indicies position + 1
 	ansi.MoveCursor(yOffset+2, f.TermWidth-8)
 	fmt.Printf(modeColors[f.EditorMode].ToFgColorANSI()+"%d:%d"+Reset, f.bufferLine, f.bufferIndex)
@@ -228,8 +365,15 @@ func (f FileEditor) PrintStatusBar() {
 	fmt.Printf(modeColors[f.EditorMode].ToFgColorANSI()+"[%c]"+Reset, f.EditorMode)
 }
 
-func (f *FileEditor) Render(flag byte) {
-	ansi.HideCursor()
+/*
+Updates the state of the editor that depends on the flag sent to the render
+loop, like the visual buffers and the position of the cursor in the FileBuffer,
+without drawing anything
+*/
+func (f *FileEditor) updateForFlag(flag byte) {
+	if flag == EnumWindowResize {
+		f.layoutWindows()
+	}
 
 	// visual buffers are already refreshed when a new line is inserted at the end of a line
 	if f.SoftWrapEnabled && flag != EnumNewLineInsertedAtLineEnd {
@@ -251,6 +395,7 @@ func (f *FileEditor) Render(flag byte) {
 		flag == EnumNewLineInsertedAtLineEnd) {
 
 		f.Saved = false
+		f.bufferModified()
 	}
 
 	switch flag {
@@ -262,10 +407,61 @@ func (f *FileEditor) Render(flag byte) {
 		f.ToggleCommandBar(!f.CommandBarToggled)
 	}
 
+	if f.completion != nil {
+		f.refreshCompletion()
+	}
+}
+
+func (f *FileEditor) Render(flag byte) {
+	ansi.HideCursor()
+
+	f.updateForFlag(flag)
+
+	f.PrintUnfocusedWindows()
+
+	if f.fileTree != nil && f.fileTree.Visible {
+		f.PrintFileTree()
+	}
+
+	if f.grepPanel != nil {
+		f.PrintGrepPanel()
+	}
+
+	if f.diffView != nil {
+		f.PrintDiffView()
+		f.PrintStatusBar()
+		if f.CommandBarToggled {
+			f.UpdateCommandBarState()
+		}
+		return
+	}
+
 	f.PrintBuffer()
+	f.PrintSecondaryCursors()
 	f.PrintStatusBar()
 
-	ansi.MoveCursor(f.apparentCursorY, f.apparentCursorX)
+	if f.prompt != nil {
+		f.PrintPrompt()
+		ansi.ShowCursor()
+		return
+	}
+
+	if f.picker != nil {
+		f.PrintPicker()
+		ansi.ShowCursor()
+		return
+	}
+
+	if f.completion != nil {
+		f.PrintCompletion()
+	}
+
+	// the selection of the file tree or the results panel stands in for the cursor
+	if (f.fileTree != nil && f.fileTree.Focused) || (f.grepPanel != nil && f.grepPanel.Focused) {
+		return
+	}
+
+	ansi.MoveCursor(f.EditorY+f.apparentCursorY, f.EditorX+f.apparentCursorX)
 
 	if f.CommandBarToggled {
 		f.UpdateCommandBarState()
@@ -288,12 +484,31 @@ func (editor *FileEditor) RenderLoop() int {
 
 	select {
 	case inputCode := <-editor.inputChan:
-		switch inputCode {
-		case EnumQuit:
+		if inputCode == EnumQuit {
 			return 1
+		}
+
+		// rendering, and the follow ticks and key timeouts handled here, change the
+		// editor state, and drawing the other windows loads them into the editor,
+		// so the keys typed in the meantime wait until this is done
+		editor.inputLock.Lock()
+		defer editor.inputLock.Unlock()
+
+		switch inputCode {
 		case EnumKeyboardInput, EnumEditorModeChange, EnumCursorPositionChange, EnumToggleCommandBar,
 			EnumNewLineInserted, EnumNewLineInsertedAtLineEnd, EnumSoftWrapDisabled, EnumSoftWrapEnabled:
 			editor.Render(inputCode)
+		case EnumKeySequenceTimeout:
+			flag := editor.ResolvePendingKeys()
+			if flag == EnumQuit {
+				editor.QuitProgramFlag = true
+				return 1
+			}
+			editor.Render(flag)
+		case EnumFollowTick:
+			if flag := editor.followFile(); flag != 0 {
+				editor.Render(flag)
+			}
 		case EnumWindowResize:
 			ansi.ClearEntireScreen()
 			editor.TermHeight = termH
@@ -314,37 +529,92 @@ func (editor *FileEditor) InputLoop() int {
 		return 1
 	}
 
+	editor.inputLock.Lock()
+	editor.recordMacroEvent(buf[:n])
+	flag := editor.dispatchInput(buf[:], n)
+	editor.inputLock.Unlock()
+
+	if flag == 0 && buf[0] == Escape {
+		return 0
+	}
+
+	editor.inputChan <- flag
+	if flag == EnumQuit {
+		return 1
+	}
+
+	return 0
+}
+
+/*
+Handles an event of the input as if it was read from the terminal, and updates
+the editor as a render would, without drawing anything. Returns the flag of the event
+*/
+func (editor *FileEditor) HandleEvent(event []byte) byte {
+	editor.inputLock.Lock()
+	defer editor.inputLock.Unlock()
+
+	editor.recordMacroEvent(event)
+	return editor.replayEvent(event)
+}
+
+/*
+Handles an event like HandleEvent, without recording it into the macro being recorded
+*/
+func (editor *FileEditor) replayEvent(event []byte) byte {
+	var buf [16]byte
+	n := copy(buf[:], event)
+
+	flag := editor.dispatchInput(buf[:], n)
+	if flag != EnumQuit {
+		editor.updateForFlag(flag)
+	}
+
+	return flag
+}
+
+/*
+Handles an event of the input, which is a key, an escape sequence or a mouse
+event made of the first n bytes of the buffer. Returns the flag that should be
+sent to the render loop, where escape sequences and mouse events that need no
+render return 0
+*/
+func (editor *FileEditor) dispatchInput(buf []byte, n int) byte {
+	editor.statusMessage = ""
+	editor.statusInfo = false
+
 	if buf[0] == Escape { // mouse input and arrow keys, etc.
-		isMouseInput, mouseEvent := ReadEscSequence(buf[:], n)
+		isMouseInput, mouseEvent := ReadEscSequence(buf, n)
 
-		if isMouseInput && !editor.CommandBarToggled {
+		if isMouseInput && !editor.CommandBarToggled && editor.picker == nil && editor.prompt == nil {
 			ret := HandleMouseInput(editor, mouseEvent)
 
 			switch ret {
 			case EnumCursorPositionChange, EnumWindowResize, EnumSoftWrapDisabled, EnumSoftWrapEnabled:
-				editor.inputChan <- ret
+				editor.breakUndoGroup()
+				return ret
 			}
-		} else {
-			ret := HandleEscapeInput(editor, buf[:], n)
+		} else if !isMouseInput {
+			// escape sequences end key sequences, commands and following the file
+			editor.takePendingKeys()
+			editor.modal.resetCommand()
+			editor.StopFollow()
+
+			ret := HandleEscapeInput(editor, buf, n)
 
 			switch ret {
-			case EnumCursorPositionChange:
-				editor.inputChan <- EnumCursorPositionChange
-			case EnumEditorModeChange:
-				editor.inputChan <- EnumEditorModeChange
+			case EnumCursorPositionChange, EnumEditorModeChange:
+				editor.breakUndoGroup()
+				return ret
 			}
 		}
-	} else {
-		ret := HandleKeyboardInput(editor, buf[0])
 
-		switch ret {
-		case EnumQuit:
-			editor.inputChan <- EnumQuit
-			return 1
-		default:
-			editor.inputChan <- ret
-		}
+		return 0
 	}
 
-	return 0
+	if editor.follow != nil && buf[0] != 'F' {
+		editor.StopFollow()
+	}
+
+	return HandleKeyboardInput(editor, buf[0])
 }
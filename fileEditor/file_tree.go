@@ -0,0 +1,661 @@
+package fileeditor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/render"
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for the file tree, a sidebar on the left of the
+windows that lists the files of the working directory, skipping the ones
+ignored by the .gitignore.
+
+While the file tree is focused, it receives the keyboard input:
+  - j/k or the up/down arrow keys move the selection
+  - enter or l opens the selected file, or expands/collapses the selected directory
+  - h collapses the selected directory, or selects the parent directory
+  - a creates a file (or a directory, if the name ends with a "/")
+  - r renames the selected file
+  - m moves the selected file to another path
+  - d deletes the selected file
+  - R reloads the tree from the disk
+  - escape gives the focus back to the windows
+
+Directories are only read the first time they are expanded, and again when
+the tree is reloaded, which happens after every file operation
+*/
+
+const fileTreeMaxWidth int = 30
+
+var fileTreeDirColor string = ansi.NewRGBColor(75, 176, 255).ToFgColorANSI()
+
+type fileTreeNode struct {
+	Name     string
+	Path     string // relative to the root of the tree
+	IsDir    bool
+	Expanded bool
+	Depth    int
+	parent   *fileTreeNode
+	children []*fileTreeNode // nil until the directory is expanded
+}
+
+type FileTree struct {
+	Root    string
+	Visible bool
+	Focused bool
+
+	ignore   *IgnoreMatcher
+	root     *fileTreeNode
+	rows     []*fileTreeNode // the nodes that are shown, in order
+	selected int             // index in rows
+	scroll   int
+}
+
+func NewFileTree(root string) *FileTree {
+	t := &FileTree{
+		Root:   root,
+		ignore: LoadIgnoreMatcher(root),
+		root:   &fileTreeNode{IsDir: true, Expanded: true, Depth: -1},
+	}
+	t.loadChildren(t.root)
+	t.refreshRows()
+
+	return t
+}
+
+/*
+Reads the entries of the directory, directories first, skipping ignored entries
+*/
+func (t *FileTree) loadChildren(n *fileTreeNode) {
+	n.children = make([]*fileTreeNode, 0)
+
+	entries, err := os.ReadDir(filepath.Join(t.Root, n.Path))
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(n.Path, entry.Name())
+		if t.ignore.IsIgnored(path, entry.IsDir()) {
+			continue
+		}
+
+		n.children = append(n.children, &fileTreeNode{
+			Name:   entry.Name(),
+			Path:   path,
+			IsDir:  entry.IsDir(),
+			Depth:  n.Depth + 1,
+			parent: n,
+		})
+	}
+
+	sort.SliceStable(n.children, func(i, j int) bool {
+		return n.children[i].IsDir && !n.children[j].IsDir
+	})
+}
+
+/*
+Flattens the expanded part of the tree into the rows that are shown
+*/
+func (t *FileTree) refreshRows() {
+	t.rows = t.rows[:0]
+
+	var walk func(n *fileTreeNode)
+	walk = func(n *fileTreeNode) {
+		for _, child := range n.children {
+			t.rows = append(t.rows, child)
+			if child.IsDir && child.Expanded {
+				walk(child)
+			}
+		}
+	}
+	walk(t.root)
+
+	t.selected = math.Clamp(t.selected, 0, math.Max(len(t.rows)-1, 0))
+}
+
+/*
+Reads the tree from the disk again, keeping the same directories expanded
+and, if it still exists, the same file selected
+*/
+func (t *FileTree) Reload() {
+	expanded := make(map[string]bool)
+	for _, n := range t.rows {
+		if n.IsDir && n.Expanded {
+			expanded[n.Path] = true
+		}
+	}
+
+	var selectedPath string
+	if n := t.Selected(); n != nil {
+		selectedPath = n.Path
+	}
+
+	var load func(n *fileTreeNode)
+	load = func(n *fileTreeNode) {
+		t.loadChildren(n)
+		for _, child := range n.children {
+			if child.IsDir && expanded[child.Path] {
+				child.Expanded = true
+				load(child)
+			}
+		}
+	}
+	load(t.root)
+
+	t.refreshRows()
+	t.selectPath(selectedPath)
+}
+
+/*
+Selects the node with the path, expanding its parent directories
+*/
+func (t *FileTree) selectPath(path string) {
+	if path == "" {
+		return
+	}
+
+	n := t.root
+	for _, name := range strings.Split(filepath.ToSlash(path), "/") {
+		if n.children == nil {
+			t.loadChildren(n)
+		}
+		n.Expanded = true
+
+		var next *fileTreeNode
+		for _, child := range n.children {
+			if child.Name == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		n = next
+	}
+
+	t.refreshRows()
+	for i, row := range t.rows {
+		if row == n {
+			t.selected = i
+		}
+	}
+}
+
+/*
+Returns the selected node, or nil if the tree is empty
+*/
+func (t *FileTree) Selected() *fileTreeNode {
+	if len(t.rows) == 0 {
+		return nil
+	}
+
+	return t.rows[t.selected]
+}
+
+func (t *FileTree) moveSelection(amount int, visibleRows int) {
+	if len(t.rows) == 0 {
+		return
+	}
+
+	t.selected = math.Clamp(t.selected+amount, 0, len(t.rows)-1)
+	t.scrollToSelection(visibleRows)
+}
+
+func (t *FileTree) scrollToSelection(visibleRows int) {
+	if t.selected < t.scroll {
+		t.scroll = t.selected
+	} else if t.selected >= t.scroll+visibleRows {
+		t.scroll = t.selected - visibleRows + 1
+	}
+}
+
+func (t *FileTree) toggleExpanded(n *fileTreeNode) {
+	n.Expanded = !n.Expanded
+	if n.Expanded && n.children == nil {
+		t.loadChildren(n)
+	}
+	t.refreshRows()
+}
+
+/*
+Returns the directory that new files are created in when the node is selected
+*/
+func (n *fileTreeNode) targetDir() string {
+	if n == nil {
+		return ""
+	}
+	if n.IsDir {
+		return n.Path
+	}
+
+	return filepath.Dir(n.Path)
+}
+
+/*
+Returns the width of the file tree, excluding its border. The
+file tree takes up at most a third of the terminal
+*/
+func (f FileEditor) fileTreeWidth() int {
+	if f.fileTree == nil || !f.fileTree.Visible {
+		return 0
+	}
+
+	return math.Min(fileTreeMaxWidth, f.TermWidth/3)
+}
+
+/*
+Returns the height of the file tree, which goes down to the status bar
+*/
+func (f FileEditor) fileTreeHeight() int {
+	return f.TermHeight - f.StatusBarHeight
+}
+
+/*
+Shows the file tree and focuses it, or hides it if it's already focused.
+The windows are laid out again to make room for the file tree
+*/
+func (f *FileEditor) ToggleFileTree() {
+	if f.fileTree == nil {
+		root, err := os.Getwd()
+		if err != nil {
+			f.statusMessage = err.Error()
+			return
+		}
+		f.fileTree = NewFileTree(root)
+	}
+
+	t := f.fileTree
+	if t.Visible && t.Focused {
+		t.Visible = false
+		t.Focused = false
+	} else {
+		t.Visible = true
+		t.Focused = true
+		if f.grepPanel != nil {
+			f.grepPanel.Focused = false
+		}
+	}
+
+	f.layoutWindows()
+	f.replaceCursor()
+
+	ansi.ClearEntireScreen()
+}
+
+/*
+Gives the focus back to the windows, keeping the file tree visible
+*/
+func (f *FileEditor) unfocusFileTree() {
+	f.fileTree.Focused = false
+}
+
+/*
+Opens the selected file in a buffer, or expands/collapses the selected directory
+*/
+func (f *FileEditor) fileTreeOpenSelected() {
+	t := f.fileTree
+	n := t.Selected()
+	if n == nil {
+		return
+	}
+
+	if n.IsDir {
+		t.toggleExpanded(n)
+		return
+	}
+
+	f.pushJump()
+	if err := f.OpenBuffer(n.Path); err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+	f.unfocusFileTree()
+}
+
+/*
+Handles the keys pressed while the file tree is focused. Returns
+the flag that should be sent to the render loop
+*/
+func (f *FileEditor) handleFileTreeInput(key byte) byte {
+	t := f.fileTree
+	visibleRows := f.fileTreeHeight()
+	n := t.Selected()
+
+	switch key {
+	case 'j':
+		t.moveSelection(1, visibleRows)
+	case 'k':
+		t.moveSelection(-1, visibleRows)
+	case NewLine, 'l':
+		f.fileTreeOpenSelected()
+	case 'h':
+		if n == nil {
+			break
+		}
+		if n.IsDir && n.Expanded {
+			t.toggleExpanded(n)
+		} else if n.parent != t.root {
+			t.selectPath(n.parent.Path)
+			t.scrollToSelection(visibleRows)
+		}
+	case 'a':
+		if f.writable() {
+			f.fileTreeCreate(n.targetDir())
+		}
+	case 'r':
+		if n != nil && f.writable() {
+			f.fileTreeRename(n)
+		}
+	case 'm':
+		if n != nil && f.writable() {
+			f.fileTreeMove(n)
+		}
+	case 'd':
+		if n != nil && f.writable() {
+			f.fileTreeDelete(n)
+		}
+	case 'R':
+		t.Reload()
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Handles the escape sequences received while the file tree is focused
+*/
+func (f *FileEditor) handleFileTreeEscapeInput(buf []byte, n int) byte {
+	if n == 1 {
+		f.unfocusFileTree()
+		return EnumCursorPositionChange
+	}
+
+	if n == 3 {
+		switch buf[2] {
+		case UpArrowKey:
+			return f.handleFileTreeInput('k')
+		case DownArrowKey:
+			return f.handleFileTreeInput('j')
+		case RightArrowKey:
+			return f.handleFileTreeInput('l')
+		case LeftArrowKey:
+			return f.handleFileTreeInput('h')
+		}
+	}
+
+	return 0
+}
+
+/*
+Handles the mouse events on the file tree. Clicking a row selects it, and
+opens the file or expands/collapses the directory
+*/
+func (f *FileEditor) handleFileTreeMouseInput(m MouseInput) byte {
+	t := f.fileTree
+	visibleRows := f.fileTreeHeight()
+
+	switch m.Event {
+	case MouseEventLeftClick:
+		t.Focused = true
+		if f.grepPanel != nil {
+			f.grepPanel.Focused = false
+		}
+		i := t.scroll + m.Y - 1
+		if i < 0 || i >= len(t.rows) {
+			return EnumCursorPositionChange
+		}
+		t.selected = i
+		f.fileTreeOpenSelected()
+	case MouseEventScrollUp:
+		t.scroll = math.Max(t.scroll-1, 0)
+	case MouseEventScrollDown:
+		t.scroll = math.Clamp(t.scroll+1, 0, math.Max(len(t.rows)-visibleRows, 0))
+	default:
+		return 0
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Returns the path that the absolute path has after the file, or the directory,
+at the old path moved to the new path, or false if the path didn't move
+*/
+func renamedPath(path, oldPath, newPath string) (string, bool) {
+	if path == oldPath {
+		return newPath, true
+	}
+	if strings.HasPrefix(path, oldPath+string(filepath.Separator)) {
+		return filepath.Join(newPath, path[len(oldPath)+1:]), true
+	}
+	return path, false
+}
+
+/*
+Renames the open buffers of the file, or of the files in the directory, that moved,
+along with their marks, and reads the staged version of their files again
+*/
+func (f *FileEditor) renameOpenBuffers(oldPath, newPath string) {
+	t := f.fileTree
+	oldPath, newPath = filepath.Join(t.Root, oldPath), filepath.Join(t.Root, newPath)
+
+	f.storeCurrentBuffer()
+	for _, b := range f.buffers {
+		if filename, ok := renamedPath(absPath(b.Filename), oldPath, newPath); ok {
+			b.Filename = filename
+			b.LoadGitBase()
+		}
+	}
+	f.bufferState = f.buffers[f.currentBuffer].bufferState
+
+	f.renameMarks(oldPath, newPath)
+
+	ansi.SetTerminalWindowTitle(f.Filename)
+}
+
+/*
+Moves the file at the path (relative to the root of the tree) to the new path.
+Fails instead of overwriting a file that already exists
+*/
+func (f *FileEditor) fileTreeRenamePath(oldPath, newPath string) {
+	t := f.fileTree
+	newPath = filepath.Clean(newPath)
+
+	if _, err := os.Stat(filepath.Join(t.Root, newPath)); err == nil {
+		f.statusMessage = newPath + " already exists"
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Join(t.Root, filepath.Dir(newPath)), 0755); err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	if err := os.Rename(filepath.Join(t.Root, oldPath), filepath.Join(t.Root, newPath)); err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	f.renameOpenBuffers(oldPath, newPath)
+	t.Reload()
+	t.selectPath(newPath)
+	f.showInfo(fmt.Sprintf("Moved %s to %s", oldPath, newPath))
+}
+
+func (f *FileEditor) fileTreeCreate(dir string) {
+	prefix := ""
+	if dir != "" {
+		prefix = dir + string(filepath.Separator)
+	}
+
+	f.OpenPrompt("New file (end with / for a directory):", prefix, func(f *FileEditor, input string) {
+		if strings.TrimSpace(input) == "" {
+			return
+		}
+
+		t := f.fileTree
+		path := filepath.Join(t.Root, input)
+
+		if strings.HasSuffix(input, "/") {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				f.statusMessage = err.Error()
+				return
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				f.statusMessage = err.Error()
+				return
+			}
+			file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+			if err != nil {
+				f.statusMessage = err.Error()
+				return
+			}
+			file.Close()
+		}
+
+		t.Reload()
+		t.selectPath(filepath.Clean(input))
+		f.showInfo("Created " + input)
+	})
+}
+
+func (f *FileEditor) fileTreeRename(n *fileTreeNode) {
+	oldPath := n.Path
+	f.OpenPrompt("Rename "+n.Name+" to:", n.Name, func(f *FileEditor, input string) {
+		if input == "" || strings.ContainsRune(input, filepath.Separator) || input == filepath.Base(oldPath) {
+			return
+		}
+		f.fileTreeRenamePath(oldPath, filepath.Join(filepath.Dir(oldPath), input))
+	})
+}
+
+func (f *FileEditor) fileTreeMove(n *fileTreeNode) {
+	oldPath := n.Path
+	f.OpenPrompt("Move "+n.Name+" to:", oldPath, func(f *FileEditor, input string) {
+		if input == "" || filepath.Clean(input) == oldPath {
+			return
+		}
+		f.fileTreeRenamePath(oldPath, input)
+	})
+}
+
+func (f *FileEditor) fileTreeDelete(n *fileTreeNode) {
+	path := n.Path
+	message := "Delete " + path + "?"
+	if n.IsDir {
+		message = "Delete " + path + " and everything in it?"
+	}
+
+	f.OpenConfirmPrompt(message, func(f *FileEditor) {
+		t := f.fileTree
+		if err := os.RemoveAll(filepath.Join(t.Root, path)); err != nil {
+			f.statusMessage = err.Error()
+			return
+		}
+
+		t.Reload()
+
+		switch kept := f.closeDeletedBuffers(path); len(kept) {
+		case 0:
+			f.showInfo("Deleted " + path)
+		case 1:
+			f.statusMessage = fmt.Sprintf("Deleted %s, but %s is still open (saving it creates it again)", path, kept[0])
+		default:
+			f.statusMessage = fmt.Sprintf("Deleted %s, but %d of its files are still open (saving them creates them again)", path, len(kept))
+		}
+	})
+}
+
+/*
+Closes the open buffers of the file, or of the files in the directory, that was
+deleted. Buffers with unsaved changes, and the last buffer, are kept open and
+marked as unsaved instead, and their filenames are returned
+*/
+func (f *FileEditor) closeDeletedBuffers(deleted string) []string {
+	deleted = filepath.Join(f.fileTree.Root, deleted)
+
+	f.storeCurrentBuffer()
+	kept := make([]string, 0)
+	for i := len(f.buffers) - 1; i >= 0; i-- {
+		b := f.buffers[i]
+		name, err := filepath.Abs(b.Filename)
+		if err != nil || (name != deleted && !strings.HasPrefix(name, deleted+string(filepath.Separator))) {
+			continue
+		}
+
+		if b.Saved && len(f.buffers) > 1 {
+			f.removeBuffer(i)
+			continue
+		}
+
+		b.Saved = false
+		if i == f.currentBuffer {
+			f.Saved = false
+		}
+		kept = append(kept, b.Filename)
+	}
+
+	return kept
+}
+
+/*
+Draws the file tree on the left of the windows, along with its border
+*/
+func (f FileEditor) PrintFileTree() {
+	t := f.fileTree
+	width := f.fileTreeWidth()
+	height := f.fileTreeHeight()
+	colorRGB := modeColors[f.EditorMode]
+
+	for r := 0; r < height; r++ {
+		ansi.MoveCursor(r+1, 1)
+
+		i := r + t.scroll
+		if i >= len(t.rows) {
+			fmt.Printf("%*s", width, "")
+			continue
+		}
+
+		n := t.rows[i]
+		icon := "  "
+		color := ""
+		if n.IsDir {
+			icon = "▸ "
+			if n.Expanded {
+				icon = "▾ "
+			}
+			color = fileTreeDirColor
+		}
+
+		text := strings.Repeat("  ", n.Depth) + icon + n.Name
+		if len([]rune(text)) > width {
+			text = string([]rune(text)[:width])
+		}
+
+		var bg string
+		if i == t.selected {
+			bg = pickerSelectedColor
+			if t.Focused {
+				color = colorRGB.ToFgColorANSI()
+			}
+		}
+
+		fmt.Printf("%s%s%s%*s%s", bg, color, text, width-len([]rune(text)), "", Reset)
+	}
+
+	render.DrawVerticalLine(render.Line{
+		Length:    height,
+		X:         width,
+		LineColor: windowBorderColor,
+	}, true)
+}
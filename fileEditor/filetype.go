@@ -0,0 +1,165 @@
+package fileeditor
+
+import "path/filepath"
+
+/*
+This file is responsible for the settings of each filetype, which are used by
+automatic indentation (indent.go), pairs of brackets and quotes (pairs.go),
+comments (comment.go) and folding (fold.go). The filetype of a file is found by its extension, or by
+its name for files like Makefile. Files of other types get the settings of
+plainFiletype, which indents after brackets and pairs every bracket and quote
+*/
+
+type Filetype struct {
+	Name string
+
+	Openers       string // characters that indent the line after a line ending with one
+	Closers       string // characters that dedent a line starting with one
+	TabIndentType uint8  // how files without indented lines are indented; 0 for the editor's default
+	TabSize       uint8
+
+	Pairs  string // every opener followed by its closer
+	Quotes string // characters that start and end a string
+
+	LineComment  string    // starts a comment that ends with the line; empty if there is none
+	BlockComment [2]string // start and end of a comment that can span lines; empty if there is none
+
+	FoldMethod uint8 // how the regions that can be folded are found; 0 to fold by brackets
+}
+
+const defaultPairs string = "()[]{}\"\"''``"
+
+var plainFiletype = Filetype{Name: "text", Openers: "{([", Closers: "})]", Pairs: defaultPairs}
+
+var (
+	goFiletype = Filetype{
+		Name: "go", Openers: "{([", Closers: "})]", TabIndentType: IndentWithTab, TabSize: 4,
+		Pairs: defaultPairs, Quotes: "\"'`",
+		LineComment: "//", BlockComment: [2]string{"/*", "*/"},
+	}
+	cFiletype = Filetype{
+		Name: "c", Openers: "{([", Closers: "})]",
+		Pairs: defaultPairs, Quotes: "\"'",
+		LineComment: "//", BlockComment: [2]string{"/*", "*/"},
+	}
+	jsFiletype = Filetype{
+		Name: "javascript", Openers: "{([", Closers: "})]", TabIndentType: IndentWithSpace, TabSize: 2,
+		Pairs: defaultPairs, Quotes: "\"'`",
+		LineComment: "//", BlockComment: [2]string{"/*", "*/"},
+	}
+	rustFiletype = Filetype{
+		Name: "rust", Openers: "{([", Closers: "})]", TabIndentType: IndentWithSpace, TabSize: 4,
+		Pairs: "()[]{}\"\"``", Quotes: "\"",
+		LineComment: "//", BlockComment: [2]string{"/*", "*/"},
+	}
+	cssFiletype = Filetype{
+		Name: "css", Openers: "{([", Closers: "})]", TabIndentType: IndentWithSpace, TabSize: 2,
+		Pairs: defaultPairs, Quotes: "\"'",
+		BlockComment: [2]string{"/*", "*/"},
+	}
+	jsonFiletype = Filetype{
+		Name: "json", Openers: "{[", Closers: "}]", TabIndentType: IndentWithSpace, TabSize: 2,
+		Pairs: "[]{}\"\"", Quotes: "\"",
+	}
+	pythonFiletype = Filetype{
+		Name: "python", Openers: ":{([", Closers: "})]", TabIndentType: IndentWithSpace, TabSize: 4,
+		Pairs: defaultPairs, Quotes: "\"'",
+		LineComment: "#",
+		FoldMethod:  FoldByIndent,
+	}
+	shellFiletype = Filetype{
+		Name: "shell", Openers: "{([", Closers: "})]",
+		Pairs: defaultPairs, Quotes: "\"'",
+		LineComment: "#",
+	}
+	yamlFiletype = Filetype{
+		Name: "yaml", Openers: ":", TabIndentType: IndentWithSpace, TabSize: 2,
+		Pairs: defaultPairs, Quotes: "\"'",
+		LineComment: "#",
+		FoldMethod:  FoldByIndent,
+	}
+	makeFiletype = Filetype{
+		Name: "make", TabIndentType: IndentWithTab, TabSize: 4,
+		Pairs: "()[]{}\"\"''", Quotes: "\"'",
+		LineComment: "#",
+		FoldMethod:  FoldByIndent,
+	}
+	luaFiletype = Filetype{
+		Name: "lua", Openers: "{([", Closers: "})]",
+		Pairs: "()[]{}\"\"''", Quotes: "\"'",
+		LineComment: "--", BlockComment: [2]string{"--[[", "]]"},
+	}
+	sqlFiletype = Filetype{
+		Name: "sql", Openers: "(", Closers: ")",
+		Pairs: "()\"\"''", Quotes: "\"'",
+		LineComment: "--", BlockComment: [2]string{"/*", "*/"},
+	}
+	haskellFiletype = Filetype{
+		Name: "haskell", Openers: "{([", Closers: "})]",
+		Pairs: "()[]{}\"\"", Quotes: "\"",
+		LineComment: "--", BlockComment: [2]string{"{-", "-}"},
+	}
+	htmlFiletype = Filetype{
+		Name: "html", Openers: "{([", Closers: "})]",
+		Pairs: "()[]{}\"\"''", Quotes: "\"'",
+		BlockComment: [2]string{"<!--", "-->"},
+	}
+	markdownFiletype = Filetype{
+		Name:         "markdown",
+		Pairs:        "()[]{}\"\"``",
+		BlockComment: [2]string{"<!--", "-->"},
+		FoldMethod:   FoldByIndent,
+	}
+	lispFiletype = Filetype{
+		Name: "lisp", Openers: "([", Closers: ")]",
+		Pairs: "()[]{}\"\"", Quotes: "\"",
+		LineComment: ";",
+	}
+)
+
+// filetypes by file extension, or by file name for files without one
+var filetypes = map[string]Filetype{
+	".go":      goFiletype,
+	".c":       cFiletype,
+	".h":       cFiletype,
+	".cpp":     cFiletype,
+	".hpp":     cFiletype,
+	".java":    cFiletype,
+	".cs":      cFiletype,
+	".js":      jsFiletype,
+	".jsx":     jsFiletype,
+	".ts":      jsFiletype,
+	".tsx":     jsFiletype,
+	".rs":      rustFiletype,
+	".css":     cssFiletype,
+	".json":    jsonFiletype,
+	".py":      pythonFiletype,
+	".sh":      shellFiletype,
+	".toml":    shellFiletype,
+	".yaml":    yamlFiletype,
+	".yml":     yamlFiletype,
+	"Makefile": makeFiletype,
+	".lua":     luaFiletype,
+	".sql":     sqlFiletype,
+	".hs":      haskellFiletype,
+	".html":    htmlFiletype,
+	".xml":     htmlFiletype,
+	".md":      markdownFiletype,
+	".txt":     {Name: "text", Pairs: "()[]{}\"\""},
+	".lisp":    lispFiletype,
+	".el":      lispFiletype,
+}
+
+/*
+Returns the filetype of the file
+*/
+func FiletypeFor(filename string) Filetype {
+	if ft, ok := filetypes[filepath.Ext(filename)]; ok {
+		return ft
+	}
+	if ft, ok := filetypes[filepath.Base(filename)]; ok {
+		return ft
+	}
+
+	return plainFiletype
+}
@@ -0,0 +1,65 @@
+package fileeditor
+
+import (
+	"context"
+	"os"
+)
+
+/*
+This file is responsible for the file finder, a picker that lists every file
+of the project and opens the chosen one in a buffer.
+
+The files are indexed in the background while the picker is open, so it can
+be typed in right away. Closing the picker cancels the indexing
+*/
+
+// the number of files sent to the picker at once while indexing
+const finderBatchSize int = 256
+
+func (f *FileEditor) OpenFileFinder() {
+	root, err := os.Getwd()
+	if err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := NewPicker("Files", nil, func(f *FileEditor, item PickerItem) byte {
+		f.pushJump()
+		if err := f.OpenBuffer(item.Value.(string)); err != nil {
+			f.statusMessage = err.Error()
+		}
+		return 0
+	})
+	p.OnClose = cancel
+	p.Loading = true
+	f.OpenPicker(p)
+
+	inputChan := f.inputChan
+	go func() {
+		batch := make([]PickerItem, 0, finderBatchSize)
+		flush := func() {
+			p.AddItems(batch)
+			batch = make([]PickerItem, 0, finderBatchSize)
+
+			// render the new items without blocking the walk on the render loop
+			select {
+			case inputChan <- EnumCursorPositionChange:
+			default:
+			}
+		}
+
+		WalkProjectFiles(ctx, root, func(relPath string) {
+			batch = append(batch, PickerItem{Label: relPath, Value: relPath})
+			if len(batch) == finderBatchSize {
+				flush()
+			}
+		})
+
+		p.SetLoading(false)
+		if ctx.Err() == nil {
+			flush()
+		}
+	}()
+}
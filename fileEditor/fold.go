@@ -0,0 +1,465 @@
+package fileeditor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+)
+
+/*
+This file is responsible for code folding.
+
+The regions that can be folded are found either by indentation, where a line
+starts a region of the lines after it that are indented more, or by brackets,
+where a line with an opener whose closer is on a later line starts a region
+that ends on the line of the closer. That line is left out of the region when
+another region starts on it, like } else {. Filetypes like python and yaml are
+folded by indentation, and the others by brackets, which the foldmethod command
+changes for the buffer:
+
+	foldmethod [indent|brackets]
+
+A closed fold hides the lines of its region but the first, which is drawn with
+the number of hidden lines after it. Hidden lines have no rows in the
+VisualBuffer, so moving the cursor, scrolling and clicking treat a fold as one
+row. Moving the cursor to a hidden line, like with goto or a search, opens the
+folds that hide it, and so does editing the lines of a fold.
+
+In Command mode, the fold commands follow vim:
+
+	za   opens or closes the fold of the cursor
+	zo   opens the fold on the line of the cursor
+	zc   closes the innermost region that the cursor is in
+	zR   opens every fold
+	zM   closes every region
+
+The gutter shows a marker on the first line of every region, which is
+different for the regions that are closed
+*/
+
+const (
+	FoldByBrackets uint8 = iota + 1
+	FoldByIndent
+)
+
+const (
+	foldOpenMarker   string = "▾"
+	foldClosedMarker string = "▸"
+)
+
+var foldColor string = ansi.NewRGBColor(120, 120, 120).ToFgColorANSI()
+
+type FoldRegion struct {
+	Start int // the line that stays visible when the region is folded (0-indexed)
+	End   int // the last line of the region, inclusive
+}
+
+/*
+Returns the regions of the lines found by indentation, in the order of their
+first line, where tabs are tabSize wide. Blank lines don't end a region, but
+the blank lines at the end of a region are left out of it
+*/
+func IndentFoldRegions(lines []string, tabSize int) []FoldRegion {
+	type level struct {
+		line  int
+		width int
+	}
+
+	regions := make([]FoldRegion, 0)
+	stack := make([]level, 0)
+	lastLine := -1 // the last line that isn't blank
+
+	closeLevels := func(width int) {
+		for len(stack) > 0 && stack[len(stack)-1].width >= width {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if lastLine > top.line {
+				regions = append(regions, FoldRegion{Start: top.line, End: lastLine})
+			}
+		}
+	}
+
+	for i, line := range lines {
+		if isBlankLine(line) {
+			continue
+		}
+
+		width := 0
+		for _, c := range line[:FirstNonBlank(line)] {
+			if c == rune(Tab) {
+				width += tabSize - width%tabSize
+			} else {
+				width++
+			}
+		}
+
+		closeLevels(width)
+		stack = append(stack, level{line: i, width: width})
+		lastLine = i
+	}
+	closeLevels(0)
+
+	sortFoldRegions(regions)
+	return regions
+}
+
+/*
+Returns the regions of the lines found by brackets, in the order of their first
+line, skipping the brackets inside of strings and comments of the filetype
+*/
+func BracketFoldRegions(lines []string, ft Filetype) []FoldRegion {
+	const openers, closers = "([{", ")]}"
+
+	text, starts := joinLines(lines)
+	mask := codeMask(text, ft)
+
+	type opener struct {
+		bracket byte
+		line    int
+	}
+
+	ends := make(map[int]int) // the last line of the region that starts on each line
+	stack := make([]opener, 0)
+	for i := 0; i < len(text); i++ {
+		if !mask[i] {
+			continue
+		}
+
+		if k := strings.IndexByte(openers, text[i]); k >= 0 {
+			stack = append(stack, opener{bracket: text[i], line: offsetToPos(starts, i).Line})
+			continue
+		}
+
+		k := strings.IndexByte(closers, text[i])
+		if k < 0 || len(stack) == 0 || stack[len(stack)-1].bracket != openers[k] {
+			continue
+		}
+
+		open := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if line := offsetToPos(starts, i).Line; line > open.line && line > ends[open.line] {
+			ends[open.line] = line
+		}
+	}
+
+	regions := make([]FoldRegion, 0, len(ends))
+	for start, end := range ends {
+		// the line of the closer is left to the region that starts on it
+		if _, ok := ends[end]; ok {
+			end--
+		}
+		if end > start {
+			regions = append(regions, FoldRegion{Start: start, End: end})
+		}
+	}
+
+	sortFoldRegions(regions)
+	return regions
+}
+
+/*
+Sorts the regions by their first line, where a region
+goes before the regions that it contains
+*/
+func sortFoldRegions(regions []FoldRegion) {
+	slices.SortFunc(regions, func(a, b FoldRegion) int {
+		if a.Start != b.Start {
+			return a.Start - b.Start
+		}
+		return b.End - a.End
+	})
+}
+
+/*
+Returns how the regions of the current buffer are found
+*/
+func (f FileEditor) currentFoldMethod() uint8 {
+	if f.foldMethod != 0 {
+		return f.foldMethod
+	}
+	if ft := FiletypeFor(f.Filename); ft.FoldMethod != 0 {
+		return ft.FoldMethod
+	}
+
+	return FoldByBrackets
+}
+
+/*
+Returns the regions of the current buffer, which are found again
+after the buffer has been modified
+*/
+func (f *FileEditor) foldRegions() []FoldRegion {
+	if f.foldRegionCache != nil {
+		return f.foldRegionCache
+	}
+
+	if f.currentFoldMethod() == FoldByIndent {
+		f.foldRegionCache = IndentFoldRegions(f.FileBuffer, int(f.TabSize))
+	} else {
+		f.foldRegionCache = BracketFoldRegions(f.FileBuffer, FiletypeFor(f.Filename))
+	}
+
+	return f.foldRegionCache
+}
+
+/*
+Returns which lines of the buffer are hidden by a closed fold,
+or nil if no fold is closed
+*/
+func (f FileEditor) hiddenLines() []bool {
+	if len(f.folds) == 0 {
+		return nil
+	}
+
+	hidden := make([]bool, len(f.FileBuffer))
+	for _, fold := range f.folds {
+		for line := fold.Start + 1; line <= fold.End && line < len(hidden); line++ {
+			hidden[line] = true
+		}
+	}
+
+	return hidden
+}
+
+/*
+Returns the closed fold that starts on the line, the outermost one if several do
+*/
+func (f FileEditor) closedFoldAt(line int) (FoldRegion, bool) {
+	found := false
+	var fold FoldRegion
+	for _, c := range f.folds {
+		if c.Start == line && (!found || c.End > fold.End) {
+			fold, found = c, true
+		}
+	}
+
+	return fold, found
+}
+
+/*
+Closes the innermost region that the cursor is in and isn't closed yet,
+and moves the cursor to its first line
+*/
+func (f *FileEditor) CloseFold() {
+	line := f.cursorPos().Line
+
+	regions := f.foldRegions()
+	for i := len(regions) - 1; i >= 0; i-- {
+		r := regions[i]
+		if r.Start <= line && line <= r.End && !slices.Contains(f.folds, r) {
+			f.folds = append(f.folds, r)
+			f.ClearCursors()
+			f.MoveCursorToBufferPos(r.Start, FirstNonBlank(f.FileBuffer[r.Start]))
+			return
+		}
+	}
+
+	f.statusMessage = "No fold found"
+}
+
+/*
+Opens the folds that start on the line of the cursor
+*/
+func (f *FileEditor) OpenFold() {
+	pos := f.cursorPos()
+	if !f.openFoldsAt(pos.Line) {
+		f.statusMessage = "No fold found"
+		return
+	}
+
+	f.moveCursorTo(pos)
+}
+
+/*
+Opens the folds that start on the line of the cursor,
+or closes the innermost region that the cursor is in
+*/
+func (f *FileEditor) ToggleFold() {
+	if _, ok := f.closedFoldAt(f.cursorPos().Line); ok {
+		f.OpenFold()
+	} else {
+		f.CloseFold()
+	}
+}
+
+/*
+Closes every region of the buffer
+*/
+func (f *FileEditor) CloseAllFolds() {
+	f.folds = slices.Clone(f.foldRegions())
+	if len(f.folds) == 0 {
+		f.statusMessage = "No fold found"
+		return
+	}
+
+	f.ClearCursors()
+
+	// the cursor goes to the line of the outermost fold that hides it
+	pos := f.cursorPos()
+	for _, fold := range f.folds {
+		if fold.Start < pos.Line && pos.Line <= fold.End {
+			pos = BufferPos{Line: fold.Start, Index: FirstNonBlank(f.FileBuffer[fold.Start])}
+			break
+		}
+	}
+	f.moveCursorTo(pos)
+}
+
+/*
+Opens every fold of the buffer
+*/
+func (f *FileEditor) OpenAllFolds() {
+	pos := f.cursorPos()
+	f.folds = nil
+	f.moveCursorTo(pos)
+}
+
+/*
+Opens the folds that start on the line. Returns false if there is none
+*/
+func (f *FileEditor) openFoldsAt(line int) bool {
+	n := len(f.folds)
+	f.folds = slices.DeleteFunc(f.folds, func(fold FoldRegion) bool { return fold.Start == line })
+	return len(f.folds) < n
+}
+
+/*
+Opens the folds that hide the line. Returns false if the line isn't hidden
+*/
+func (f *FileEditor) revealLine(line int) bool {
+	n := len(f.folds)
+	f.folds = slices.DeleteFunc(f.folds, func(fold FoldRegion) bool { return fold.Start < line && line <= fold.End })
+	return len(f.folds) < n
+}
+
+/*
+Moves the folds of the buffer after count lines from the start line were
+replaced with newCount lines. The folds with replaced lines are opened
+*/
+func (f *FileEditor) foldsReplaced(start int, count int, newCount int) {
+	f.foldRegionCache = nil
+
+	folds := f.folds[:0]
+	for _, fold := range f.folds {
+		switch {
+		case fold.End < start:
+			folds = append(folds, fold)
+		case fold.Start >= start+count:
+			fold.Start += newCount - count
+			fold.End += newCount - count
+			folds = append(folds, fold)
+		}
+	}
+	f.folds = folds
+}
+
+/*
+Runs the foldmethod command with its argument, which shows
+how the buffer is folded when it is empty
+*/
+func (f *FileEditor) SetFoldMethod(arg string) {
+	switch strings.TrimSpace(arg) {
+	case "":
+	case "indent":
+		f.foldMethod = FoldByIndent
+	case "brackets":
+		f.foldMethod = FoldByBrackets
+	default:
+		f.statusMessage = fmt.Sprintf("Unknown fold method: %s", arg)
+		return
+	}
+
+	pos := f.cursorPos()
+	f.foldRegionCache = nil
+	f.folds = nil
+	f.moveCursorTo(pos)
+
+	if f.currentFoldMethod() == FoldByIndent {
+		f.showInfo("Folding by indentation")
+	} else {
+		f.showInfo("Folding by brackets")
+	}
+}
+
+/*
+Runs a fold command of Command mode, which is z followed by the key
+*/
+func (f *FileEditor) foldCommand(key byte) {
+	switch key {
+	case 'a':
+		f.ToggleFold()
+	case 'o':
+		f.OpenFold()
+	case 'c':
+		f.CloseFold()
+	case 'R':
+		f.OpenAllFolds()
+	case 'M':
+		f.CloseAllFolds()
+	}
+}
+
+/*
+Returns the row (0-indexed) of the VisualBuffer that the line starts on
+*/
+func (f FileEditor) rowOfLine(line int) int {
+	if f.VisualBufferMapped == nil || line == 0 {
+		return line
+	}
+
+	return f.VisualBufferMapped[line-1]
+}
+
+/*
+Returns the line of the FileBuffer that is drawn on the row (0-indexed) of the VisualBuffer
+*/
+func (f FileEditor) lineAtRow(row int) int {
+	if f.VisualBufferMapped == nil {
+		return row
+	}
+
+	return CalcBufferLineFromACY(row+1, f.VisualBufferMapped, 0)
+}
+
+/*
+Returns the marker of the fold that starts on the line, or an empty string if there is none
+*/
+func (f FileEditor) foldGutterMarker(line int) string {
+	if _, ok := f.closedFoldAt(line); ok {
+		return foldColor + foldClosedMarker
+	}
+
+	_, ok := slices.BinarySearchFunc(f.foldRegionCache, line, func(r FoldRegion, line int) int {
+		return r.Start - line
+	})
+	if ok {
+		return foldColor + foldOpenMarker
+	}
+
+	return ""
+}
+
+/*
+Returns the text drawn after the first line of a closed fold, with the number of lines
+it hides, which fits in the viewport after the visual line of the given length.
+Also returns how many characters wide the text is, without its colour codes
+*/
+func (f FileEditor) foldVirtualText(line int, visualLineLength int) (string, int) {
+	fold, ok := f.closedFoldAt(line)
+	if !ok {
+		return "", 0
+	}
+
+	text := fmt.Sprintf(" ... %d lines", fold.End-fold.Start)
+	space := f.GetViewportWidth() - visualLineLength - 1
+	if space <= 0 {
+		return "", 0
+	}
+	if len(text) > space {
+		text = text[:space]
+	}
+
+	return foldColor + text + Reset, len(text)
+}
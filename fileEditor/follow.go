@@ -0,0 +1,172 @@
+package fileeditor
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+/*
+This file is responsible for follow mode, which keeps reading the file of the
+current buffer as it grows, like tail -f, and keeps the end of it in view.
+
+A ticker asks the render loop to check the file every followInterval, and the
+render loop reads whatever was appended since the last check. If the file gets
+smaller, it was truncated or replaced, so it is read again from the start.
+Pressing any key stops following
+*/
+
+const followInterval time.Duration = 500 * time.Millisecond
+
+type followState struct {
+	stop    context.CancelFunc
+	offset  int64 // how much of the file has been read
+	partial bool  // true if the file doesn't end with a new line, so its last line isn't finished
+}
+
+/*
+Splits the content of a file into lines, the way ReadFileToBuffer stores
+them in the FileBuffer. The new line at the end of the file doesn't start
+another line, and an empty file has one empty line
+*/
+func splitFileLines(data string) []string {
+	lines := strings.Split(strings.TrimSuffix(data, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return lines
+}
+
+/*
+Returns the lines with the text that was appended to their file. If the last
+line isn't finished, which partial tells, the text continues it. Also returns
+whether the last line is unfinished after the text
+*/
+func AppendFileText(lines []string, partial bool, data string) ([]string, bool) {
+	if data == "" {
+		return lines, partial
+	}
+
+	appended := splitFileLines(data)
+	if partial {
+		lines[len(lines)-1] += appended[0]
+		appended = appended[1:]
+	}
+
+	return append(lines, appended...), !strings.HasSuffix(data, "\n")
+}
+
+func (f *FileEditor) StartFollow() {
+	if f.follow != nil {
+		return
+	}
+
+	if !f.Saved {
+		f.statusMessage = "Save the buffer before following its file"
+		return
+	}
+
+	data, err := os.ReadFile(f.Filename)
+	if err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.follow = &followState{stop: cancel, offset: int64(len(data)), partial: !strings.HasSuffix(string(data), "\n")}
+
+	f.FileBuffer = splitFileLines(string(data))
+	f.bufferModified()
+	f.MoveCursorToBufferPos(len(f.FileBuffer)-1, 0)
+
+	inputChan := f.inputChan
+	go func() {
+		ticker := time.NewTicker(followInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// skip the check if the render loop is busy
+				select {
+				case inputChan <- EnumFollowTick:
+				default:
+				}
+			}
+		}
+	}()
+}
+
+func (f *FileEditor) StopFollow() {
+	if f.follow == nil {
+		return
+	}
+
+	f.follow.stop()
+	f.follow = nil
+}
+
+/*
+Reads what was appended to the file since the last check. Returns the flag
+that should be rendered, or 0 if the file hasn't changed
+*/
+func (f *FileEditor) followFile() byte {
+	fs := f.follow
+	if fs == nil {
+		return 0
+	}
+
+	info, err := os.Stat(f.Filename)
+	if err != nil {
+		f.StopFollow()
+		f.statusMessage = err.Error()
+		return EnumCursorPositionChange
+	}
+
+	size := info.Size()
+	switch {
+	case size == fs.offset:
+		return 0
+
+	case size < fs.offset:
+		data, err := os.ReadFile(f.Filename)
+		if err != nil {
+			f.StopFollow()
+			f.statusMessage = err.Error()
+			return EnumCursorPositionChange
+		}
+
+		f.FileBuffer = splitFileLines(string(data))
+		fs.offset = int64(len(data))
+		fs.partial = !strings.HasSuffix(string(data), "\n")
+
+	default:
+		file, err := os.Open(f.Filename)
+		if err != nil {
+			f.StopFollow()
+			f.statusMessage = err.Error()
+			return EnumCursorPositionChange
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.NewSectionReader(file, fs.offset, size-fs.offset))
+		if err != nil {
+			f.StopFollow()
+			f.statusMessage = err.Error()
+			return EnumCursorPositionChange
+		}
+
+		f.FileBuffer, fs.partial = AppendFileText(f.FileBuffer, fs.partial, string(data))
+		fs.offset += int64(len(data))
+	}
+
+	f.bufferModified()
+	f.MoveCursorToBufferPos(len(f.FileBuffer)-1, 0)
+
+	return EnumCursorPositionChange
+}
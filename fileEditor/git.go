@@ -0,0 +1,213 @@
+package fileeditor
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/diff"
+)
+
+/*
+This file is responsible for comparing the FileBuffer against the version
+of the file that is staged in the git index, and showing the changes as
+markers in the gutter next to the line numbers.
+
+The staged version is read with the local git binary once when the file
+is opened (and again when it is saved), and the FileBuffer is diffed
+against it every time it is modified
+*/
+
+const (
+	gitLineAdded    byte = '+'
+	gitLineModified byte = '~'
+	gitLineDeleted  byte = '_'
+)
+
+var gitMarkerColors = map[byte]string{
+	gitLineAdded:    ansi.NewRGBColor(80, 200, 120).ToFgColorANSI(),
+	gitLineModified: ansi.NewRGBColor(230, 190, 60).ToFgColorANSI(),
+	gitLineDeleted:  ansi.NewRGBColor(230, 70, 70).ToFgColorANSI(),
+}
+
+/*
+Returns the lines of the file as it is staged in the git index by running
+`git show :./<file>` from the directory of the file. An error is returned
+if git is not installed, the file is not in a repository or is not tracked
+*/
+func GitIndexLines(filename string) ([]string, error) {
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("git", "show", ":./"+filepath.Base(absPath))
+	cmd.Dir = filepath.Dir(absPath)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return SplitLines(string(out)), nil
+}
+
+/*
+Splits the content of a file into lines the same way they would be
+read into the FileBuffer, dropping carriage returns and the trailing newline
+*/
+func SplitLines(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.TrimSuffix(content, "\n")
+
+	return strings.Split(content, "\n")
+}
+
+/*
+Reads the staged version of the file and refreshes the gutter markers.
+If the file isn't tracked by git, the gutter is left empty
+*/
+func (f *bufferState) LoadGitBase() {
+	lines, err := GitIndexLines(f.Filename)
+	if err != nil {
+		f.gitBaseLines = nil
+		f.gitHunks = nil
+		f.gitMarkers = nil
+		return
+	}
+
+	f.gitBaseLines = lines
+	f.refreshGitHunks()
+}
+
+/*
+Diffs the FileBuffer against the staged version of the file and
+rebuilds the markers shown in the gutter for each line
+*/
+func (f *bufferState) refreshGitHunks() {
+	if f.gitBaseLines == nil {
+		return
+	}
+
+	f.gitHunks = diff.Lines(f.gitBaseLines, f.FileBuffer)
+	f.gitMarkers = make(map[int]byte)
+
+	for _, h := range f.gitHunks {
+		if h.IsDeletion() {
+			// deleted lines are marked on the line that follows them
+			line := h.NewStart
+			if line >= len(f.FileBuffer) {
+				line = len(f.FileBuffer) - 1
+			}
+			if _, exists := f.gitMarkers[line]; !exists {
+				f.gitMarkers[line] = gitLineDeleted
+			}
+			continue
+		}
+
+		var marker byte = gitLineModified
+		if h.IsInsertion() {
+			marker = gitLineAdded
+		}
+
+		for i := h.NewStart; i < h.NewStart+h.NewLines; i++ {
+			f.gitMarkers[i] = marker
+		}
+	}
+}
+
+/*
+Returns the colored gutter marker of the line (0-indexed), or a
+space if the line hasn't changed since it was staged
+*/
+func (f FileEditor) gitGutterMarker(line int) string {
+	marker, exists := f.gitMarkers[line]
+	if !exists {
+		return " "
+	}
+
+	return gitMarkerColors[marker] + string(marker)
+}
+
+/*
+Returns the index of the hunk that the line (0-indexed) is in,
+or -1 if the line isn't part of a hunk
+*/
+func (f FileEditor) gitHunkAtLine(line int) int {
+	for i, h := range f.gitHunks {
+		if h.IsDeletion() {
+			if line == h.NewStart || (h.NewStart >= len(f.FileBuffer) && line == len(f.FileBuffer)-1) {
+				return i
+			}
+		} else if line >= h.NewStart && line < h.NewStart+h.NewLines {
+			return i
+		}
+	}
+
+	return -1
+}
+
+/*
+Moves the cursor to the start of the next hunk after the cursor,
+wrapping around to the first hunk of the file
+*/
+func (f *FileEditor) actionNextHunk() {
+	if len(f.gitHunks) == 0 {
+		return
+	}
+
+	target := f.gitHunks[0].NewStart
+	for _, h := range f.gitHunks {
+		if h.NewStart > f.bufferLine {
+			target = h.NewStart
+			break
+		}
+	}
+
+	f.MoveCursorToBufferPos(target, 0)
+}
+
+/*
+Moves the cursor to the start of the previous hunk before the cursor,
+wrapping around to the last hunk of the file
+*/
+func (f *FileEditor) actionPrevHunk() {
+	if len(f.gitHunks) == 0 {
+		return
+	}
+
+	current := f.gitHunkAtLine(f.bufferLine)
+
+	target := f.gitHunks[len(f.gitHunks)-1].NewStart
+	for i := len(f.gitHunks) - 1; i >= 0; i-- {
+		if i != current && f.gitHunks[i].NewStart < f.bufferLine {
+			target = f.gitHunks[i].NewStart
+			break
+		}
+	}
+
+	f.MoveCursorToBufferPos(target, 0)
+}
+
+/*
+Replaces the lines of the hunk under the cursor with
+the lines of the staged version of the file
+*/
+func (f *FileEditor) actionRevertHunk() {
+	i := f.gitHunkAtLine(f.bufferLine)
+	if i < 0 {
+		return
+	}
+
+	if !f.writable() {
+		return
+	}
+
+	h := f.gitHunks[i]
+	f.pushUndo()
+	f.ReplaceLines(h.NewStart, h.NewLines, f.gitBaseLines[h.OldStart:h.OldStart+h.OldLines])
+
+	f.bufferModified()
+	f.MoveCursorToBufferPos(h.NewStart, 0)
+}
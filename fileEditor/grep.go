@@ -0,0 +1,585 @@
+package fileeditor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Asiandayboy/CLITextEditor/render"
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for searching the text of every file of the project,
+and for the results panel that shows the matches under the windows.
+
+The files are searched by a pool of workers fed by a walk of the project, and
+the matches of each file are added to the panel as soon as the file has been
+searched, so the panel fills up while the search is running.
+
+While the results panel is focused, it receives the keyboard input:
+  - j/k or the up/down arrow keys move the selection between matches
+  - enter opens the file of the selected match at its line
+  - x stops the search, keeping the matches found so far
+  - q stops the search and closes the panel
+  - escape gives the focus back to the windows
+*/
+
+const (
+	grepPanelMaxHeight int = 12
+	grepMaxMatches     int = 10000 // the search stops once this many matches are found
+	grepMaxLineLength  int = 1024 * 1024
+)
+
+type GrepMatch struct {
+	Line   int      // 0-indexed
+	Text   string   // the whole line
+	Ranges [][2]int // start and end indicies of each match in the line
+}
+
+type GrepFileResult struct {
+	Path    string // relative to the root of the search
+	Matches []GrepMatch
+}
+
+/*
+Returns the regex to search with. In literal mode, the pattern
+is matched as is instead of being parsed as a regex
+*/
+func CompileSearchPattern(pattern string, literal bool) (*regexp.Regexp, error) {
+	if literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+
+	return regexp.Compile(pattern)
+}
+
+/*
+Returns the matches of the regex in the file, line by line
+*/
+func SearchFile(path string, re *regexp.Regexp) ([]GrepMatch, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	matches := make([]GrepMatch, 0)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), grepMaxLineLength)
+	for line := 0; scanner.Scan(); line++ {
+		text := scanner.Text()
+		indicies := re.FindAllStringIndex(text, -1)
+		if len(indicies) == 0 {
+			continue
+		}
+
+		ranges := make([][2]int, 0, len(indicies))
+		for _, idx := range indicies {
+			if idx[1] > idx[0] { // empty matches can't be highlighted
+				ranges = append(ranges, [2]int{idx[0], idx[1]})
+			}
+		}
+		if len(ranges) > 0 {
+			matches = append(matches, GrepMatch{Line: line, Text: text, Ranges: ranges})
+		}
+	}
+
+	return matches, scanner.Err()
+}
+
+/*
+Searches every text file of the project that isn't ignored, sending the
+matches of each file that has any to the results channel. Blocks until
+every file has been searched or the context is cancelled, and closes the
+results channel before returning
+*/
+func SearchProject(ctx context.Context, root string, re *regexp.Regexp, results chan<- GrepFileResult) {
+	defer close(results)
+
+	paths := make(chan string, 64)
+
+	go func() {
+		defer close(paths)
+		WalkProjectFiles(ctx, root, func(relPath string) {
+			select {
+			case paths <- relPath:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	var wg sync.WaitGroup
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for relPath := range paths {
+				if ctx.Err() != nil {
+					continue // drain the paths so the walk can end
+				}
+
+				matches, err := SearchFile(filepath.Join(root, relPath), re)
+				if err != nil || len(matches) == 0 {
+					continue
+				}
+
+				select {
+				case results <- GrepFileResult{Path: relPath, Matches: matches}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+type grepRow struct {
+	file  int // index in the files of the panel
+	match int // index in the matches of the file; -1 for the row showing the path of the file
+}
+
+type GrepPanel struct {
+	Pattern   string
+	Literal   bool
+	Searching bool
+	Focused   bool
+
+	files      []GrepFileResult
+	matchCount int
+	limited    bool // true if the search was stopped after grepMaxMatches matches
+	rows       []grepRow
+	selected   int // index in rows; always a match row once there are matches
+	scroll     int
+	cancel     context.CancelFunc
+
+	mu sync.Mutex
+}
+
+/*
+Adds the matches of a file to the panel, stopping the search once too many are found
+*/
+func (p *GrepPanel) addResult(r GrepFileResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.matchCount >= grepMaxMatches {
+		p.limited = true
+		p.cancel()
+		return
+	}
+
+	p.files = append(p.files, r)
+	file := len(p.files) - 1
+
+	p.rows = append(p.rows, grepRow{file: file, match: -1})
+	for i := range r.Matches {
+		p.rows = append(p.rows, grepRow{file: file, match: i})
+	}
+	p.matchCount += len(r.Matches)
+
+	// select the first match instead of the path above it
+	if file == 0 {
+		p.selected = 1
+	}
+}
+
+func (p *GrepPanel) setSearching(searching bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Searching = searching
+}
+
+/*
+Moves the selection by the amount of matches, skipping the rows of the paths
+*/
+func (p *GrepPanel) moveSelection(amount int, visibleRows int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	step := 1
+	if amount < 0 {
+		step, amount = -1, -amount
+	}
+
+	for ; amount > 0; amount-- {
+		i := p.selected + step
+		if i >= 0 && i < len(p.rows) && p.rows[i].match < 0 {
+			i += step
+		}
+		if i < 0 || i >= len(p.rows) {
+			break
+		}
+		p.selected = i
+	}
+
+	// keep the path of the first match visible when scrolling up to it
+	top := p.selected
+	if top == 1 {
+		top = 0
+	}
+
+	if top < p.scroll {
+		p.scroll = top
+	} else if p.selected >= p.scroll+visibleRows {
+		p.scroll = p.selected - visibleRows + 1
+	}
+}
+
+/*
+Returns the path and the match that is selected, and false if there are no matches
+*/
+func (p *GrepPanel) Selected() (string, GrepMatch, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.selected >= len(p.rows) || p.rows[p.selected].match < 0 {
+		return "", GrepMatch{}, false
+	}
+
+	row := p.rows[p.selected]
+	file := p.files[row.file]
+	return file.Path, file.Matches[row.match], true
+}
+
+/*
+Returns the height of the results panel, including its title but
+excluding the border above it, or 0 if the panel isn't open
+*/
+func (f FileEditor) grepPanelHeight() int {
+	if f.grepPanel == nil {
+		return 0
+	}
+
+	return math.Min(grepPanelMaxHeight, f.TermHeight/3)
+}
+
+/*
+Returns the number of matches that fit in the panel under its title
+*/
+func (f FileEditor) grepPanelVisibleRows() int {
+	return math.Max(f.grepPanelHeight()-1, 1)
+}
+
+/*
+Searches the project for the pattern and shows the results in the panel.
+A running search is cancelled first. Prefixing the pattern with "-F "
+matches it literally instead of as a regex
+*/
+func (f *FileEditor) StartGrep(args string) {
+	literal := false
+	if strings.HasPrefix(args, "-F ") {
+		literal = true
+		args = strings.TrimSpace(args[3:])
+	}
+
+	re, err := CompileSearchPattern(args, literal)
+	if err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	if f.grepPanel != nil {
+		f.grepPanel.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &GrepPanel{
+		Pattern:   args,
+		Literal:   literal,
+		Searching: true,
+		Focused:   true,
+		cancel:    cancel,
+	}
+	f.grepPanel = p
+	if f.fileTree != nil {
+		f.unfocusFileTree()
+	}
+
+	f.layoutWindows()
+	f.replaceCursor()
+	ansi.ClearEntireScreen()
+
+	inputChan := f.inputChan
+	notify := func() {
+		// render the new matches without blocking the search on the render loop
+		select {
+		case inputChan <- EnumCursorPositionChange:
+		default:
+		}
+	}
+
+	go func() {
+		results := make(chan GrepFileResult)
+		go SearchProject(ctx, root, re, results)
+
+		for r := range results {
+			p.addResult(r)
+			notify()
+		}
+
+		p.setSearching(false)
+		notify()
+	}()
+}
+
+/*
+Stops the search and closes the results panel
+*/
+func (f *FileEditor) CloseGrepPanel() {
+	f.grepPanel.cancel()
+	f.grepPanel = nil
+
+	f.layoutWindows()
+	f.replaceCursor()
+	ansi.ClearEntireScreen()
+}
+
+/*
+Gives the focus to the results panel, if there is one
+*/
+func (f *FileEditor) FocusGrepPanel() {
+	if f.grepPanel == nil {
+		f.statusMessage = "Usage: " + CMDBAR_GREP + " [-F] <pattern>"
+		return
+	}
+
+	f.grepPanel.Focused = true
+	if f.fileTree != nil {
+		f.unfocusFileTree()
+	}
+}
+
+/*
+Opens the file of the selected match and moves the cursor to the match
+*/
+func (f *FileEditor) openGrepResult() {
+	path, match, ok := f.grepPanel.Selected()
+	if !ok {
+		return
+	}
+
+	f.pushJump()
+	if err := f.OpenBuffer(path); err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	f.MoveCursorToBufferPos(match.Line, match.Ranges[0][0])
+	f.grepPanel.Focused = false
+}
+
+/*
+Handles the keys pressed while the results panel is focused. Returns
+the flag that should be sent to the render loop
+*/
+func (f *FileEditor) handleGrepPanelInput(key byte) byte {
+	p := f.grepPanel
+
+	switch key {
+	case 'j':
+		p.moveSelection(1, f.grepPanelVisibleRows())
+	case 'k':
+		p.moveSelection(-1, f.grepPanelVisibleRows())
+	case NewLine:
+		f.openGrepResult()
+	case 'x':
+		p.cancel()
+	case 'q':
+		f.CloseGrepPanel()
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Handles the escape sequences received while the results panel is focused
+*/
+func (f *FileEditor) handleGrepPanelEscapeInput(buf []byte, n int) byte {
+	if n == 1 {
+		f.grepPanel.Focused = false
+		return EnumCursorPositionChange
+	}
+
+	if n == 3 && buf[2] == UpArrowKey {
+		return f.handleGrepPanelInput('k')
+	} else if n == 3 && buf[2] == DownArrowKey {
+		return f.handleGrepPanelInput('j')
+	}
+
+	return 0
+}
+
+/*
+Returns true if the position (1-indexed) on the screen is in the results panel
+*/
+func (f FileEditor) inGrepPanel(x, y int) bool {
+	if f.grepPanel == nil {
+		return false
+	}
+
+	area := f.layoutArea()
+	return x > area.X && y > area.Y+area.Height+1 && y <= area.Y+area.Height+1+f.grepPanelHeight()
+}
+
+/*
+Handles the mouse events on the results panel. Clicking a
+match selects it and opens its file at its line
+*/
+func (f *FileEditor) handleGrepPanelMouseInput(m MouseInput) byte {
+	p := f.grepPanel
+	area := f.layoutArea()
+
+	switch m.Event {
+	case MouseEventLeftClick:
+		p.Focused = true
+		if f.fileTree != nil {
+			f.unfocusFileTree()
+		}
+
+		// the first row of the panel is its title
+		row := m.Y - (area.Y + area.Height + 2)
+		p.mu.Lock()
+		i := p.scroll + row - 1
+		clicked := row > 0 && i < len(p.rows) && p.rows[i].match >= 0
+		if clicked {
+			p.selected = i
+		}
+		p.mu.Unlock()
+
+		if clicked {
+			f.openGrepResult()
+		}
+	case MouseEventScrollUp:
+		p.mu.Lock()
+		p.scroll = math.Max(p.scroll-1, 0)
+		p.mu.Unlock()
+	case MouseEventScrollDown:
+		p.mu.Lock()
+		p.scroll = math.Clamp(p.scroll+1, 0, math.Max(len(p.rows)-f.grepPanelVisibleRows(), 0))
+		p.mu.Unlock()
+	default:
+		return 0
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Returns the line shortened to the width with the matches highlighted.
+Leading whitespace is dropped and tabs are shown as spaces
+*/
+func grepPreview(match GrepMatch, width int, bg string) string {
+	text := strings.ReplaceAll(match.Text, "\t", " ")
+	trimmed := len(text) - len(strings.TrimLeft(text, " "))
+	text = text[trimmed:]
+	if len(text) > width {
+		text = text[:width]
+	}
+
+	var out string
+	last := 0
+	for _, r := range match.Ranges {
+		start, end := r[0]-trimmed, r[1]-trimmed
+		start = math.Clamp(start, last, len(text))
+		end = math.Clamp(end, start, len(text))
+
+		out += text[last:start] + pickerMatchColor + text[start:end] + Reset + bg
+		last = end
+	}
+
+	return out + text[last:] + strings.Repeat(" ", width-len(text))
+}
+
+/*
+Draws the results panel under the windows, along with the border above it
+*/
+func (f FileEditor) PrintGrepPanel() {
+	p := f.grepPanel
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	area := f.layoutArea()
+	width := area.Width
+	top := area.Y + area.Height // 0-indexed row of the border
+	colorRGB := modeColors[f.EditorMode]
+	color := colorRGB.ToFgColorANSI()
+
+	render.DrawHorizontalLine(render.Line{
+		Length:    width,
+		X:         area.X,
+		Y:         top,
+		LineColor: windowBorderColor,
+	}, true)
+
+	mode := "regex"
+	if p.Literal {
+		mode = "literal"
+	}
+	status := ""
+	if p.Searching {
+		status = " searching..."
+	} else if p.limited {
+		status = " (stopped after too many matches)"
+	}
+
+	title := fmt.Sprintf(" grep %s: %q  %d matches in %d files%s", mode, p.Pattern, p.matchCount, len(p.files), status)
+	if len(title) > width {
+		title = title[:width]
+	}
+	ansi.MoveCursor(top+2, area.X+1)
+	fmt.Printf("%s%-*s%s", color, width, title, Reset)
+
+	visibleRows := f.grepPanelVisibleRows()
+	for r := 0; r < visibleRows; r++ {
+		ansi.MoveCursor(top+3+r, area.X+1)
+
+		i := r + p.scroll
+		if i >= len(p.rows) {
+			fmt.Printf("%*s", width, "")
+			continue
+		}
+
+		row := p.rows[i]
+		file := p.files[row.file]
+		if row.match < 0 {
+			header := fmt.Sprintf("%s (%d)", file.Path, len(file.Matches))
+			if len(header) > width {
+				header = header[:width]
+			}
+			fmt.Printf("%s%s%-*s%s", Green, Italic, width, header, Reset)
+			continue
+		}
+
+		var bg string
+		if i == p.selected {
+			bg = pickerSelectedColor
+		}
+
+		match := file.Matches[row.match]
+		lineNum := fmt.Sprintf("%6d  ", match.Line+1)
+		if len(lineNum) >= width {
+			fmt.Printf("%*s", width, "")
+			continue
+		}
+
+		fmt.Print(bg + Grey + lineNum + Reset + bg)
+		fmt.Print(grepPreview(match, width-len(lineNum), bg) + Reset)
+	}
+}
@@ -0,0 +1,125 @@
+package fileeditor
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+/*
+This file is responsible for deciding which files of the project are ignored,
+according to the .gitignore file at the root of the project.
+
+Only the .gitignore at the root is read. The supported syntax covers what is
+commonly used: glob patterns, negation with a leading "!", patterns that only
+match directories with a trailing "/", and patterns anchored to the root with
+a leading "/" or a "/" in the middle, unless they start with "**" and a "/".
+The .git directory is always ignored
+*/
+
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool // matched against the whole path instead of any ending of it
+}
+
+type IgnoreMatcher struct {
+	Root  string
+	rules []ignoreRule
+}
+
+/*
+Reads the .gitignore file at the root. A missing .gitignore
+results in a matcher that only ignores the .git directory
+*/
+func LoadIgnoreMatcher(root string) *IgnoreMatcher {
+	m := &IgnoreMatcher{Root: root}
+
+	file, err := os.Open(filepath.Join(root, ".gitignore"))
+	if err != nil {
+		return m
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.HasPrefix(line, "**/") {
+			// matches in any directory, so it's never anchored
+			line = strings.TrimPrefix(line, "**/")
+		} else if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+
+		rule.pattern = line
+		m.rules = append(m.rules, rule)
+	}
+
+	return m
+}
+
+/*
+Returns true if the path, relative to the root, is ignored. The last rule
+that matches the path decides, so a negated rule can unignore a path
+*/
+func (m *IgnoreMatcher) IsIgnored(relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(relPath)
+	name := path.Base(relPath)
+
+	if name == ".git" {
+		return true
+	}
+
+	var ignored bool = false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		if rule.matches(relPath) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+/*
+Returns true if the rule matches the path. A rule that isn't anchored matches
+the path if it matches any ending of it, starting after a slash
+*/
+func (rule ignoreRule) matches(relPath string) bool {
+	if rule.anchored {
+		matched, _ := path.Match(rule.pattern, relPath)
+		return matched
+	}
+
+	for {
+		if matched, _ := path.Match(rule.pattern, relPath); matched {
+			return true
+		}
+
+		i := strings.Index(relPath, "/")
+		if i < 0 {
+			return false
+		}
+		relPath = relPath[i+1:]
+	}
+}
@@ -0,0 +1,349 @@
+package fileeditor
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for automatic indentation.
+
+When a new line is inserted, it keeps the indentation of the line it was split
+from, and gets one more level if that line ends with an opener of the filetype,
+like { or :. When the cursor is between an opener and its closer, the closer is
+moved to a line of its own. When a closer is typed as the first character of a
+line, the line gets the indentation of the line with the matching opener.
+
+When a file is opened, its indentation is detected from its lines, which sets
+whether the buffer is indented with tabs or spaces, and how many. Files without
+indented lines get the indentation of their filetype, or the editor's default.
+
+In Command mode, tab and shift+tab indent and outdent count lines from the
+cursor, or every line with a cursor when there are secondary cursors. In Edit
+mode, shift+tab outdents the lines of the cursors. The retab command converts
+the indentation of the buffer, or of a range of lines, to tabs or spaces:
+
+	retab [tabs|spaces] [size] [start,end]
+
+Without tabs or spaces, the indentation is converted to what the buffer is
+indented with. With a size, each level of indentation becomes that wide
+*/
+
+// escape sequence, without the escape, of shift+tab
+const shiftTabSequence string = "[Z"
+
+const (
+	defaultTabIndentType uint8 = IndentWithTab
+	defaultTabSize       uint8 = 4
+)
+
+/*
+Returns whether the lines are indented with tabs or spaces, and by how many
+spaces a level of indentation is when indented with spaces, which is the most
+common change of indentation between lines. Returns false if no line is
+indented, or the lines are indented with spaces but no level can be found
+*/
+func DetectIndent(lines []string) (indentType uint8, size uint8, ok bool) {
+	tabs, spaces := 0, 0
+	changes := make(map[int]int) // number of times the indentation changed by the amount
+
+	prev := -1 // spaces of the previous line, or -1 if it was indented with tabs
+	for _, line := range lines {
+		if isBlankLine(line) {
+			continue
+		}
+
+		if line[0] == Tab {
+			tabs++
+			prev = -1
+			continue
+		}
+
+		width := FirstNonBlank(line)
+		// a single space is more likely the alignment of a comment than indentation
+		if width > 1 {
+			spaces++
+		}
+
+		if change := width - prev; prev >= 0 && (change >= 2 || change <= -2) {
+			changes[math.Max(change, -change)]++
+		}
+		prev = width
+	}
+
+	if tabs == 0 && spaces == 0 {
+		return 0, 0, false
+	}
+	if tabs >= spaces {
+		return IndentWithTab, 0, true
+	}
+
+	best := 0
+	for change := 2; change <= 8; change++ {
+		if changes[change] > changes[best] {
+			best = change
+		}
+	}
+	if best == 0 {
+		return 0, 0, false
+	}
+
+	return IndentWithSpace, uint8(best), true
+}
+
+/*
+Sets how the current buffer is indented, from its lines,
+or else from its filetype or the editor's default
+*/
+func (f *FileEditor) detectIndent() {
+	f.TabIndentType, f.TabSize = defaultTabIndentType, defaultTabSize
+
+	if ft := FiletypeFor(f.Filename); ft.TabIndentType != 0 {
+		f.TabIndentType, f.TabSize = ft.TabIndentType, ft.TabSize
+	}
+
+	if indentType, size, ok := DetectIndent(f.FileBuffer); ok {
+		f.TabIndentType = indentType
+		if size > 0 {
+			f.TabSize = size
+		}
+	}
+}
+
+/*
+Returns the indentation of the line after a line is split into the text before
+and after the split, which is the indentation of the line, with one more level
+if the text before the split ends with an opener. Returns true as well if the text
+after the split starts with a closer, which then goes on a line of its own
+*/
+func NewLineIndent(before string, after string, ft Filetype, unit string) (string, bool) {
+	indent := before[:FirstNonBlank(before)]
+
+	trimmed := strings.TrimRight(before, " \t")
+	if trimmed == "" || strings.IndexByte(ft.Openers, trimmed[len(trimmed)-1]) < 0 {
+		return indent, false
+	}
+
+	after = strings.TrimLeft(after, " \t")
+	closed := after != "" && strings.IndexByte(ft.Closers, after[0]) >= 0
+
+	return indent + unit, closed
+}
+
+/*
+Returns the line of the closer at the position with the indentation of the line
+of the bracket that matches it, when the closer is the first character of its
+line. Returns false if the line keeps its indentation
+*/
+func DedentClosingBracket(lines []string, closer BufferPos, ft Filetype) (string, bool) {
+	line := lines[closer.Line]
+	if closer.Index >= len(line) || FirstNonBlank(line) != closer.Index ||
+		strings.IndexByte(ft.Closers, line[closer.Index]) < 0 {
+		return line, false
+	}
+
+	match, ok := MatchingBracketInCode(lines, closer, ft)
+	if !ok || match.Line == closer.Line {
+		return line, false
+	}
+
+	opener := lines[match.Line]
+	indent := opener[:FirstNonBlank(opener)]
+	if indent == line[:closer.Index] {
+		return line, false
+	}
+
+	return indent + line[closer.Index:], true
+}
+
+/*
+Dedents the line of the closer typed at the position, and moves the cursor
+after the closer. The position is passed in since the cursor position in
+the FileBuffer is only updated when the editor is rendered
+*/
+func (f *FileEditor) dedentClosingBracket(closer BufferPos) {
+	line, ok := DedentClosingBracket(f.FileBuffer, closer, FiletypeFor(f.Filename))
+	if !ok {
+		return
+	}
+
+	f.ReplaceLines(closer.Line, 1, []string{line})
+	f.MoveCursorToBufferPos(closer.Line, FirstNonBlank(line)+1)
+}
+
+/*
+Indents, or outdents if outdent is true, every line with a cursor when there
+are secondary cursors, or else count lines from the cursor
+*/
+func (f *FileEditor) ShiftSelection(count int, outdent bool) {
+	if !f.writable() {
+		return
+	}
+
+	cursors := f.allCursors()
+	first, last := cursors[0].Line, math.Min(cursors[0].Line+count-1, len(f.FileBuffer)-1)
+	selected := func(line int) bool { return true }
+
+	if len(cursors) > 1 {
+		first, last = cursors[0].Line, cursors[0].Line
+		for _, c := range cursors[1:] {
+			first, last = math.Min(first, c.Line), math.Max(last, c.Line)
+		}
+		selected = func(line int) bool {
+			return slices.ContainsFunc(cursors, func(c BufferPos) bool { return c.Line == line })
+		}
+	}
+
+	lines := make([]string, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		line := f.FileBuffer[i]
+		if selected(i) {
+			line = f.shiftLine(line, outdent)
+		}
+		lines = append(lines, line)
+	}
+
+	f.pushUndo()
+	f.reindentLines(first, lines)
+	f.bufferModified()
+}
+
+/*
+Handles shift+tab, which outdents like tab indents. Returns
+the flag that should be sent to the render loop
+*/
+func (f *FileEditor) handleShiftTab() byte {
+	switch f.EditorMode {
+	case EditorCommandMode:
+		count := f.modal.cmd.count
+		f.modal.resetCommand()
+		return f.executeCommand(modalCommand{count: count, motion: shiftTabSequence}, false)
+	case EditorEditMode:
+		f.ShiftSelection(1, true)
+		return EnumCursorPositionChange
+	}
+
+	return 0
+}
+
+/*
+Returns the line with its indentation converted to tabs, or to spaces if
+toTabs is false. The width of the indentation is measured with tabs of the
+old size, and each level of it becomes the new size wide. What is left of
+the indentation after the last full level is kept as spaces
+*/
+func RetabLine(line string, toTabs bool, oldSize int, newSize int) string {
+	width := 0
+	i := 0
+	for ; i < len(line) && (line[i] == Space || line[i] == Tab); i++ {
+		if line[i] == Tab {
+			width += oldSize - width%oldSize
+		} else {
+			width++
+		}
+	}
+	if i == len(line) { // blank lines lose their indentation
+		return ""
+	}
+
+	levels, rest := width/oldSize, width%oldSize
+
+	var indent string
+	if toTabs {
+		indent = strings.Repeat(string(Tab), levels)
+	} else {
+		indent = strings.Repeat(" ", levels*newSize)
+	}
+
+	return indent + strings.Repeat(" ", rest) + line[i:]
+}
+
+type retabArgs struct {
+	indentType uint8  // 0 to keep the indentation type of the buffer
+	size       int    // 0 to keep the tab size of the buffer
+	lineRange  string // range of lines like 10,20; empty for the whole buffer
+}
+
+/*
+Parses the arguments of the retab command, which can be given in any order
+*/
+func parseRetabArgs(arg string) (retabArgs, error) {
+	var args retabArgs
+
+	for _, field := range strings.Fields(arg) {
+		switch {
+		case field == "tabs":
+			args.indentType = IndentWithTab
+		case field == "spaces":
+			args.indentType = IndentWithSpace
+		case strings.Contains(field, ","):
+			args.lineRange = field
+		default:
+			size, err := strconv.Atoi(field)
+			if err != nil || size < 1 || size > 16 {
+				return args, fmt.Errorf("invalid argument %q", field)
+			}
+			args.size = size
+		}
+	}
+
+	return args, nil
+}
+
+/*
+Runs the retab command with its arguments
+*/
+func (f *FileEditor) Retab(arg string) {
+	args, err := parseRetabArgs(arg)
+	if err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	start, end := 0, len(f.FileBuffer)-1
+	if args.lineRange != "" {
+		if start, end, err = f.parseLineRange(args.lineRange); err != nil {
+			f.statusMessage = err.Error()
+			return
+		}
+	}
+
+	if !f.writable() {
+		return
+	}
+
+	if args.indentType != 0 {
+		f.TabIndentType = args.indentType
+	}
+	size := int(f.TabSize)
+	if args.size != 0 {
+		size = args.size
+	}
+
+	lines := make([]string, 0, end-start+1)
+	for _, line := range f.FileBuffer[start : end+1] {
+		lines = append(lines, RetabLine(line, f.TabIndentType == IndentWithTab, int(f.TabSize), size))
+	}
+
+	f.pushUndo()
+	f.TabSize = uint8(size)
+	f.reindentLines(start, lines)
+	f.bufferModified()
+
+	f.showInfo("Indented with " + f.indentName())
+}
+
+/*
+Returns how the buffer is indented, like "tabs" or "4 spaces"
+*/
+func (f FileEditor) indentName() string {
+	if f.TabIndentType == IndentWithTab {
+		return "tabs"
+	}
+
+	return fmt.Sprintf("%d spaces", f.TabSize)
+}
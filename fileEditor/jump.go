@@ -0,0 +1,196 @@
+package fileeditor
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for going to a line, and for the jump list.
+
+The jump list remembers where the cursor was before each large jump, like a
+search, a goto, opening a file or moving to the start or end of the buffer, so
+that the user can go back to it, and forward again. It works like the history
+of a browser: jumping somewhere new after going back drops the jumps that were
+ahead. The list is shared by every buffer, and going back to a jump in another
+buffer switches to it, opening the file again if its buffer was closed
+*/
+
+const maxJumps int = 100
+
+type Jump struct {
+	Filename string
+	Pos      BufferPos
+}
+
+type JumpList struct {
+	jumps []Jump
+	index int // the jump that was last gone back or forward to; len(jumps) when it hasn't been navigated
+}
+
+/*
+Adds the jump to the end of the list, dropping the jumps ahead of the current
+one, and any earlier jump to the same line, so that a line is only in the list once
+*/
+func (l *JumpList) Push(j Jump) {
+	jumps := l.jumps[:math.Min(l.index, len(l.jumps))]
+
+	kept := make([]Jump, 0, len(jumps)+1)
+	for _, existing := range jumps {
+		if existing.Filename != j.Filename || existing.Pos.Line != j.Pos.Line {
+			kept = append(kept, existing)
+		}
+	}
+	kept = append(kept, j)
+
+	if len(kept) > maxJumps {
+		kept = kept[len(kept)-maxJumps:]
+	}
+
+	l.jumps = kept
+	l.index = len(kept)
+}
+
+/*
+Returns the jump before the current one. The current position is added to the
+list the first time, so that going forward returns to it. Returns false if
+there is no jump before it
+*/
+func (l *JumpList) Back(current Jump) (Jump, bool) {
+	if l.index >= len(l.jumps) {
+		l.Push(current)
+		l.index--
+	}
+
+	if l.index == 0 {
+		return Jump{}, false
+	}
+
+	l.index--
+	return l.jumps[l.index], true
+}
+
+/*
+Returns the jump after the current one, or false if there is none
+*/
+func (l *JumpList) Forward() (Jump, bool) {
+	if l.index >= len(l.jumps)-1 {
+		return Jump{}, false
+	}
+
+	l.index++
+	return l.jumps[l.index], true
+}
+
+/*
+Parses a location like "12" or "12:5", as reported by compilers, into a
+line and a column, which are 1-indexed. The column is 0 if there is none
+*/
+func ParseLineColumn(location string) (line int, col int, err error) {
+	lineStr, colStr, hasCol := strings.Cut(strings.TrimSpace(location), ":")
+
+	line, err = strconv.Atoi(lineStr)
+	if err != nil || line < 1 {
+		return 0, 0, errors.New("Invalid line: " + lineStr)
+	}
+
+	if hasCol {
+		col, err = strconv.Atoi(colStr)
+		if err != nil || col < 1 {
+			return 0, 0, errors.New("Invalid column: " + colStr)
+		}
+	}
+
+	return line, col, nil
+}
+
+/*
+Adds the position of the cursor to the jump list, before a large jump
+*/
+func (f *FileEditor) pushJump() {
+	f.jumps.Push(Jump{Filename: f.Filename, Pos: f.cursorPos()})
+}
+
+/*
+Moves the cursor to the line and column, which are 1-indexed, and centers the
+viewport on it. Without a column, the cursor goes to the first non-blank
+character of the line. Lines and columns past the end go to the last one
+*/
+func (f *FileEditor) GotoLine(line int, col int) {
+	f.pushJump()
+
+	line = math.Clamp(line-1, 0, len(f.FileBuffer)-1)
+	index := FirstNonBlank(f.FileBuffer[line])
+	if col > 0 {
+		index = col - 1
+	}
+
+	f.MoveCursorToBufferPos(line, index)
+	f.centerViewport()
+}
+
+/*
+Asks for a location like 12 or 12:5, and goes to it
+*/
+func (f *FileEditor) OpenGotoPrompt() {
+	f.OpenPrompt("Go to line:", "", func(f *FileEditor, input string) {
+		if input == "" {
+			return
+		}
+
+		line, col, err := ParseLineColumn(input)
+		if err != nil {
+			f.statusMessage = err.Error()
+			return
+		}
+
+		f.GotoLine(line, col)
+	})
+}
+
+/*
+Scrolls the viewport so that the row of the cursor is in the middle of it
+*/
+func (f *FileEditor) centerViewport() {
+	height := f.GetViewportHeight()
+	row, _ := f.visualRowCol(f.cursorPos())
+	maxOffset := math.Max(len(f.VisualBuffer)-height, 0)
+
+	f.ViewportOffsetY = math.Clamp(row-height/2, 0, maxOffset)
+	f.apparentCursorY = row - f.ViewportOffsetY + 1
+}
+
+func (f *FileEditor) JumpBack() {
+	j, ok := f.jumps.Back(Jump{Filename: f.Filename, Pos: f.cursorPos()})
+	if !ok {
+		f.statusMessage = "Already at the oldest jump"
+		return
+	}
+
+	f.goToJump(j)
+}
+
+func (f *FileEditor) JumpForward() {
+	j, ok := f.jumps.Forward()
+	if !ok {
+		f.statusMessage = "Already at the newest jump"
+		return
+	}
+
+	f.goToJump(j)
+}
+
+func (f *FileEditor) goToJump(j Jump) {
+	if j.Filename != f.Filename {
+		if err := f.OpenBuffer(j.Filename); err != nil {
+			f.statusMessage = err.Error()
+			return
+		}
+	}
+
+	f.MoveCursorToBufferPos(j.Pos.Line, j.Pos.Index)
+	f.centerViewport()
+}
@@ -0,0 +1,207 @@
+package fileeditor
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+This file is responsible for matching the keys that are typed against the
+keymap of the current mode.
+
+Keys that start a key sequence are held as pending keys until the sequence
+is complete. When the pending keys are both a binding and the start of longer
+sequences, or when the next key doesn't arrive, the editor waits for the
+timeout of the keybindings and then runs the binding of the pending keys, if
+they have one. When the keys stop matching any sequence, the first pending key
+is handled as a regular key, and the keys after it are matched again, so that
+typing a key that happens to start a sequence still works as usual.
+
+The timeout fires on a timer, so the pending keys are guarded by a mutex, and
+the timeout is resolved by the render loop
+*/
+
+type pendingKeys struct {
+	mu    sync.Mutex
+	keys  []byte
+	gen   int // incremented whenever the keys change, so that outdated timers are ignored
+	timer *time.Timer
+}
+
+/*
+Returns a copy of the pending keys
+*/
+func (f FileEditor) PendingKeys() []byte {
+	p := f.pendingKeys
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]byte{}, p.keys...)
+}
+
+/*
+Holds the keys as the pending keys, and restarts the timeout
+*/
+func (f *FileEditor) setPendingKeys(keys []byte) {
+	p := f.pendingKeys
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.keys = keys
+	p.gen++
+	gen := p.gen
+
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+
+	inputChan := f.inputChan
+	p.timer = time.AfterFunc(f.Keybindings.Timeout, func() {
+		p.mu.Lock()
+		current := p.gen == gen && len(p.keys) > 0
+		p.mu.Unlock()
+
+		if current {
+			inputChan <- EnumKeySequenceTimeout
+		}
+	})
+}
+
+/*
+Clears the pending keys and returns them
+*/
+func (f *FileEditor) takePendingKeys() []byte {
+	p := f.pendingKeys
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := p.keys
+	p.keys = nil
+	p.gen++
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+
+	return keys
+}
+
+/*
+Returns true if the key is a control key that can be bound even while a
+panel is focused. Enter and tab are left out since panels use them
+*/
+func isControlKey(key byte) bool {
+	return key < Space && key != NewLine && key != Tab
+}
+
+/*
+Returns true if a panel or a view that handles its own keys has the focus
+*/
+func (f FileEditor) panelHasFocus() bool {
+	return (f.fileTree != nil && f.fileTree.Focused) ||
+		(f.grepPanel != nil && f.grepPanel.Focused) ||
+		f.diffView != nil
+}
+
+/*
+Matches the key, after the pending keys, against the keymap of the current
+mode. Returns the flag that should be sent to the render loop, and false if
+the key isn't part of a key sequence, in which case it should be handled as
+a regular key
+*/
+func (f *FileEditor) handleMappedKey(key byte) (byte, bool) {
+	pending := f.PendingKeys()
+
+	// panels handle their own keys, so only control keys can run bindings in them
+	if len(pending) == 0 && f.panelHasFocus() && !isControlKey(key) {
+		return 0, false
+	}
+
+	// the keys of a command that has been started in Command mode belong to the command
+	if len(pending) == 0 && f.EditorMode == EditorCommandMode && f.modal.pending() {
+		return 0, false
+	}
+
+	keys := append(pending, key)
+	binding, isPrefix := f.Keybindings.Lookup(f.EditorMode, keys)
+
+	switch {
+	case isPrefix:
+		f.setPendingKeys(keys)
+		return EnumCursorPositionChange, true
+	case binding != nil:
+		f.takePendingKeys()
+		return f.runBinding(*binding), true
+	case len(pending) > 0:
+		f.takePendingKeys()
+		return f.replayKeys(keys), true
+	default:
+		return 0, false
+	}
+}
+
+/*
+Resolves the pending keys once the timeout has passed, by running their
+binding or replaying them. Returns the flag that should be rendered
+*/
+func (f *FileEditor) ResolvePendingKeys() byte {
+	keys := f.takePendingKeys()
+	if len(keys) == 0 {
+		return 0
+	}
+
+	if binding, _ := f.Keybindings.Lookup(f.EditorMode, keys); binding != nil {
+		return f.runBinding(*binding)
+	}
+
+	return f.replayKeys(keys)
+}
+
+/*
+Handles the first key as a regular key, and matches the keys after it again
+*/
+func (f *FileEditor) replayKeys(keys []byte) byte {
+	flag := handleUnmappedKey(f, keys[0])
+	for _, key := range keys[1:] {
+		flag = combineInputFlags(flag, HandleKeyboardInput(f, key))
+	}
+
+	return flag
+}
+
+/*
+Runs the action or the command of the binding
+*/
+func (f *FileEditor) runBinding(b Binding) byte {
+	if b.Action != "" {
+		return f.RunAction(b.Action)
+	}
+
+	if flag := executeCommandBarStr(f, b.Command); flag != 0 {
+		return flag
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Combines the flags of two keys handled one after the other into a single
+flag for the render loop. An edit by either key is kept, so that the buffer
+is still marked as modified when the second key only moves the cursor
+*/
+func combineInputFlags(first, second byte) byte {
+	isEdit := func(flag byte) bool {
+		return flag == EnumKeyboardInput || flag == EnumNewLineInserted || flag == EnumNewLineInsertedAtLineEnd
+	}
+
+	switch {
+	case first == EnumQuit || second == EnumQuit:
+		return EnumQuit
+	case isEdit(first) && !isEdit(second):
+		return EnumKeyboardInput
+	case second == 0:
+		return first
+	default:
+		return second
+	}
+}
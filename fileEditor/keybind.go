@@ -1,5 +1,15 @@
 package fileeditor
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Definitons for action names that can be rebinded
 const (
 	ActionHighlightText  string = "HighlightText"
@@ -13,6 +23,63 @@ const (
 	ActionScrollLeft     string = "ScrollLeft"
 	ActionScrollRight    string = "ScrollRight"
 	ActionToggleFileTree string = "ToggleFileTree"
+
+	ActionCommandPalette     string = "CommandPalette"
+	ActionSave               string = "Save"
+	ActionQuit               string = "Quit"
+	ActionForceQuit          string = "ForceQuit"
+	ActionUndo               string = "Undo"
+	ActionRedo               string = "Redo"
+	ActionFindFile           string = "FindFile"
+	ActionFocusSearchResults string = "FocusSearchResults"
+	ActionNextBuffer         string = "NextBuffer"
+	ActionPrevBuffer         string = "PrevBuffer"
+	ActionCloseBuffer        string = "CloseBuffer"
+	ActionDiscardBuffer      string = "DiscardBuffer"
+	ActionListBuffers        string = "ListBuffers"
+	ActionSplitWindow        string = "SplitWindow"
+	ActionVSplitWindow       string = "VSplitWindow"
+	ActionCloseWindow        string = "CloseWindow"
+	ActionNextWindow         string = "NextWindow"
+	ActionNextHunk           string = "NextHunk"
+	ActionPrevHunk           string = "PrevHunk"
+	ActionRevertHunk         string = "RevertHunk"
+	ActionToggleBlame        string = "ToggleBlame"
+	ActionDiffSaved          string = "DiffSaved"
+	ActionWordLeft           string = "WordLeft"
+	ActionWordRight          string = "WordRight"
+	ActionLineStart          string = "LineStart"
+	ActionLineEnd            string = "LineEnd"
+	ActionPageUp             string = "PageUp"
+	ActionPageDown           string = "PageDown"
+	ActionBufferStart        string = "BufferStart"
+	ActionBufferEnd          string = "BufferEnd"
+	ActionParagraphUp        string = "ParagraphUp"
+	ActionParagraphDown      string = "ParagraphDown"
+	ActionMatchBracket       string = "MatchBracket"
+	ActionGotoLine           string = "GotoLine"
+	ActionJumpBack           string = "JumpBack"
+	ActionJumpForward        string = "JumpForward"
+	ActionToggleBookmark     string = "ToggleBookmark"
+	ActionListBookmarks      string = "ListBookmarks"
+	ActionAddCursorAbove     string = "AddCursorAbove"
+	ActionAddCursorBelow     string = "AddCursorBelow"
+	ActionAddCursorsAtWord   string = "AddCursorsAtWord"
+	ActionClearCursors       string = "ClearCursors"
+	ActionDuplicateLines     string = "DuplicateLines"
+	ActionMoveLinesUp        string = "MoveLinesUp"
+	ActionMoveLinesDown      string = "MoveLinesDown"
+	ActionJoinLines          string = "JoinLines"
+	ActionDeleteLines        string = "DeleteLines"
+	ActionToggleComment      string = "ToggleComment"
+	ActionToggleBlockComment string = "ToggleBlockComment"
+	ActionToggleFold         string = "ToggleFold"
+	ActionFold               string = "Fold"
+	ActionUnfold             string = "Unfold"
+	ActionFoldAll            string = "FoldAll"
+	ActionUnfoldAll          string = "UnfoldAll"
+	ActionCompleteNext       string = "CompleteNext"
+	ActionCompletePrev       string = "CompletePrev"
 )
 
 const (
@@ -22,22 +89,29 @@ const (
 	CtrlC        byte = 3
 	CtrlD        byte = 4
 	CtrlE        byte = 5
+	CtrlF        byte = 6
+	CtrlG        byte = 7
 	CtrlH        byte = 8
 	Tab          byte = 9
+	CtrlK        byte = 11
 	CtrlL        byte = 12
 	NewLine      byte = 13
 	CtrlN        byte = 14
 	CtrlO        byte = 15
+	CtrlP        byte = 16
 	CtrlR        byte = 18
 	CtrlS        byte = 19
 	CtrlT        byte = 20
 	CtrlV        byte = 22
+	CtrlW        byte = 23
 	CtrlX        byte = 24
 	CtrlY        byte = 25
 	Space        byte = 32
 	LBracket     byte = 91
 	RBracket     byte = 93
 	Escape       byte = 0x1b
+	CtrlRBracket byte = 0x1d
+	CtrlSlash    byte = 0x1f // sent by most terminals for ctrl+/
 	ForwardSlash byte = 47
 	Backspace    byte = 127
 
@@ -47,6 +121,37 @@ const (
 	LeftArrowKey  byte = 'D' // ASCII decimal -> 68
 )
 
+// the modes that have their own keymap
+var keymapModes = []byte{EditorCommandMode, EditorEditMode, EditorViewMode}
+
+// the default timeout for ambiguous key sequences
+const defaultKeySequenceTimeout time.Duration = 800 * time.Millisecond
+
+/*
+Default bindings for sequences of keys, on top of the default keys of the
+registered actions. The modes are the letters of the editor modes. View mode
+is left out, since space pages down in it
+*/
+var defaultSequenceBindings = []struct {
+	modes  string
+	keys   string
+	action string
+}{
+	{"C", "<leader> f f", ActionFindFile},
+	{"C", "<leader> f s", ActionSave},
+	{"C", "<leader> b b", ActionListBuffers},
+	{"C", "<leader> b n", ActionNextBuffer},
+	{"C", "<leader> b p", ActionPrevBuffer},
+	{"C", "<leader> e", ActionToggleFileTree},
+	{"C", "<leader> p", ActionCommandPalette},
+	{"C", "<leader> m", ActionListBookmarks},
+	{"C", "<leader> w s", ActionSplitWindow},
+	{"C", "<leader> w v", ActionVSplitWindow},
+	{"C", "<leader> w c", ActionCloseWindow},
+	{"E", "<C-n>", ActionCompleteNext},
+	{"E", "<C-p>", ActionCompletePrev},
+}
+
 // Represents the user's keybindings for each action
 type Keybind struct {
 	HighlightText  byte
@@ -60,6 +165,11 @@ type Keybind struct {
 	ScrollRight    byte
 	ToggleFileTree byte
 
+	Leader  byte          // replaces <leader> in key sequences
+	Timeout time.Duration // how long to wait for the next key of a sequence before giving up on it
+
+	keymaps map[byte]*Keymap // the keymap of each editor mode
+
 	// these keybinds cannot be changed
 	cursorLeft  byte
 	cursorRight byte
@@ -68,15 +178,76 @@ type Keybind struct {
 }
 
 /*
-Returns a default keybind
+Returns a default keybind, which binds every registered action to its
+default key in every mode, along with the default key sequences
 */
 func NewKeybind() Keybind {
-	return Keybind{
+	k := Keybind{
+		Leader:  Space,
+		Timeout: defaultKeySequenceTimeout,
+		keymaps: make(map[byte]*Keymap),
+
 		cursorUp:    'A',
 		cursorDown:  'B',
 		cursorRight: 'C',
 		cursorLeft:  'D',
 	}
+
+	for _, mode := range keymapModes {
+		k.keymaps[mode] = NewKeymap()
+	}
+
+	for _, a := range actionRegistry {
+		if a.DefaultKey != 0 {
+			k.ChangeKeybind(a.Name, a.DefaultKey)
+		}
+	}
+
+	for _, d := range defaultSequenceBindings {
+		keys, err := ParseKeySequence(d.keys, k.Leader)
+		if err != nil {
+			panic(err)
+		}
+		for _, mode := range []byte(d.modes) {
+			k.keymaps[mode].Bind(keys, Binding{Action: d.action})
+		}
+	}
+
+	return k
+}
+
+/*
+Returns the keymap of the editor mode
+*/
+func (k Keybind) Keymap(mode byte) *Keymap {
+	return k.keymaps[mode]
+}
+
+/*
+Looks up the sequence of keys in the keymap of the editor mode. Returns
+the binding of the sequence, or nil if it has none, and whether
+longer sequences start with it
+*/
+func (k Keybind) Lookup(mode byte, keys []byte) (*Binding, bool) {
+	m, ok := k.keymaps[mode]
+	if !ok {
+		return nil, false
+	}
+
+	return m.Lookup(keys)
+}
+
+/*
+Returns the shortest sequence of keys bound to the action
+in the editor mode, or nil if it isn't bound
+*/
+func (k Keybind) KeysForAction(mode byte, action string) []byte {
+	m, ok := k.keymaps[mode]
+	if !ok {
+		return nil
+	}
+
+	return m.KeysForAction(action)
 }
 
 /*
@@ -84,23 +255,26 @@ Executes the action binded to the key based on the user's
 keybindings
 */
 func (k Keybind) MapKeybindToAction(key byte, isArrowKey bool, editor *FileEditor) {
-	keybindings := map[byte]actionFunc{
-		k.cursorLeft:  editor.actionCursorLeft,
-		k.cursorRight: editor.actionCursorRight,
-		k.cursorUp:    editor.actionCursorUp,
-		k.cursorDown:  editor.actionCursorDown,
-	}
-
-	action, exists := keybindings[key]
-	if exists {
-		if key == UpArrowKey || key == DownArrowKey || key == RightArrowKey || key == LeftArrowKey {
-			if isArrowKey { // typing arrow keys, which are also A, B, C or D
-				action()
-			}
-		}
+	if !isArrowKey { // typing arrow keys, which are also A, B, C or D
+		return
+	}
+
+	switch key {
+	case k.cursorLeft:
+		editor.actionCursorLeft()
+	case k.cursorRight:
+		editor.actionCursorRight()
+	case k.cursorUp:
+		editor.actionCursorUp()
+	case k.cursorDown:
+		editor.actionCursorDown()
 	}
 }
 
+/*
+Binds the action to the key in every mode, replacing
+every other key sequence bound to the action
+*/
 func (k *Keybind) ChangeKeybind(action string, keybind byte) {
 	switch action {
 	case ActionHighlightText:
@@ -123,7 +297,148 @@ func (k *Keybind) ChangeKeybind(action string, keybind byte) {
 		k.ScrollRight = keybind
 	case ActionToggleFileTree:
 		k.ToggleFileTree = keybind
-	default:
+	}
+
+	if LookupAction(action) == nil {
 		return
 	}
+
+	for _, m := range k.keymaps {
+		m.UnbindAction(action)
+		if keybind != 0 {
+			m.Bind([]byte{keybind}, Binding{Action: action})
+		}
+	}
+}
+
+/*
+Reads the user's keybindings from the config file. A missing file is not an
+error. Each line of the file is one of:
+
+	leader <key>                         sets the leader key for the lines after it
+	timeout <milliseconds>               sets the timeout of ambiguous key sequences
+	map <modes> <keys> = <action>        binds the keys to a registered action
+	map <modes> <keys> = :<command>      binds the keys to a command bar string
+	unmap <modes> <keys>                 removes the binding of the keys
+
+where modes are the letters of the editor modes (C, E and V), or * for every
+mode, and keys are written as described in keymap.go. Empty lines and lines
+starting with # are ignored. Every valid line is applied, and the error lists
+the lines that are not valid
+*/
+func (k *Keybind) LoadConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	problems := make([]string, 0)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if err := k.applyConfigLine(line); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %s", i+1, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %s", filepath.Base(path), strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func (k *Keybind) applyConfigLine(line string) error {
+	directive, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch directive {
+	case "leader":
+		keys, err := ParseKeySequence(rest, k.Leader)
+		if err != nil {
+			return err
+		}
+		if len(keys) != 1 {
+			return fmt.Errorf("the leader must be a single key")
+		}
+		k.Leader = keys[0]
+	case "timeout":
+		ms, err := strconv.Atoi(rest)
+		if err != nil || ms <= 0 {
+			return fmt.Errorf("invalid timeout %q", rest)
+		}
+		k.Timeout = time.Duration(ms) * time.Millisecond
+	case "map", "unmap":
+		modeStr, rest, _ := strings.Cut(rest, " ")
+		modes, err := parseConfigModes(modeStr)
+		if err != nil {
+			return err
+		}
+
+		keyStr, target, hasTarget := strings.Cut(rest, "=")
+		if directive == "map" && !hasTarget {
+			return fmt.Errorf("expected: map <modes> <keys> = <action>")
+		} else if directive == "unmap" && hasTarget {
+			return fmt.Errorf("expected: unmap <modes> <keys>")
+		}
+
+		keys, err := ParseKeySequence(keyStr, k.Leader)
+		if err != nil {
+			return err
+		}
+
+		if directive == "unmap" {
+			for _, mode := range modes {
+				k.keymaps[mode].Unbind(keys)
+			}
+			return nil
+		}
+
+		var b Binding
+		target = strings.TrimSpace(target)
+		if strings.HasPrefix(target, ":") {
+			b.Command = strings.TrimSpace(target[1:])
+		} else if LookupAction(target) != nil {
+			b.Action = target
+		} else {
+			return fmt.Errorf("unknown action %q", target)
+		}
+
+		for _, mode := range modes {
+			k.keymaps[mode].Bind(keys, b)
+		}
+	default:
+		return fmt.Errorf("unknown directive %q", directive)
+	}
+
+	return nil
+}
+
+func parseConfigModes(s string) ([]byte, error) {
+	if s == "*" {
+		return keymapModes, nil
+	}
+
+	modes := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		mode := s[i]
+		if mode >= 'a' && mode <= 'z' {
+			mode -= 32
+		}
+		if mode != EditorCommandMode && mode != EditorEditMode && mode != EditorViewMode {
+			return nil, fmt.Errorf("unknown mode %q", s[i])
+		}
+		modes = append(modes, mode)
+	}
+
+	if len(modes) == 0 {
+		return nil, fmt.Errorf("missing modes")
+	}
+
+	return modes, nil
 }
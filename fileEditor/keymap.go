@@ -0,0 +1,206 @@
+package fileeditor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+/*
+This file is responsible for keymaps, which bind sequences of keys to actions
+or to commands. Each editor mode has its own keymap.
+
+A keymap is a trie: every node is a key, and the path from the root to a node
+is the sequence of keys that leads to it. A node can hold a binding and have
+children at the same time, in which case the sequence is ambiguous, and the
+editor waits a little for the next key before running the binding.
+
+Key sequences are written as space separated keys, where a key is either a
+single character, or one of the following names:
+  - <leader>, which is replaced by the leader key
+  - <Space>, <Tab>, <Enter> and <BS>
+  - <C-x> for Ctrl and a letter
+A word of several characters is a sequence of the characters, so "gg" is the
+same as "g g"
+*/
+
+type Binding struct {
+	Action  string // name of a registered action
+	Command string // a command bar string, run when Action is empty
+}
+
+func (b Binding) String() string {
+	if b.Action != "" {
+		return b.Action
+	}
+	return ":" + b.Command
+}
+
+type keymapNode struct {
+	children map[byte]*keymapNode
+	binding  *Binding
+}
+
+type Keymap struct {
+	root *keymapNode
+}
+
+func NewKeymap() *Keymap {
+	return &Keymap{root: &keymapNode{children: make(map[byte]*keymapNode)}}
+}
+
+/*
+Binds the sequence of keys, replacing the binding the sequence had
+*/
+func (m *Keymap) Bind(keys []byte, b Binding) {
+	n := m.root
+	for _, key := range keys {
+		child, ok := n.children[key]
+		if !ok {
+			child = &keymapNode{children: make(map[byte]*keymapNode)}
+			n.children[key] = child
+		}
+		n = child
+	}
+
+	n.binding = &b
+}
+
+/*
+Removes the binding of the sequence of keys, along with the
+nodes that no longer lead to any binding
+*/
+func (m *Keymap) Unbind(keys []byte) {
+	var unbind func(n *keymapNode, keys []byte) bool
+	unbind = func(n *keymapNode, keys []byte) bool {
+		if len(keys) == 0 {
+			n.binding = nil
+		} else if child, ok := n.children[keys[0]]; ok && unbind(child, keys[1:]) {
+			delete(n.children, keys[0])
+		}
+
+		return n.binding == nil && len(n.children) == 0
+	}
+
+	unbind(m.root, keys)
+}
+
+/*
+Removes every sequence bound to the action
+*/
+func (m *Keymap) UnbindAction(action string) {
+	for keys := m.KeysForAction(action); keys != nil; keys = m.KeysForAction(action) {
+		m.Unbind(keys)
+	}
+}
+
+/*
+Looks up the sequence of keys. Returns the binding of the sequence, or nil
+if it has none, and whether longer sequences start with it
+*/
+func (m *Keymap) Lookup(keys []byte) (b *Binding, isPrefix bool) {
+	n := m.root
+	for _, key := range keys {
+		child, ok := n.children[key]
+		if !ok {
+			return nil, false
+		}
+		n = child
+	}
+
+	return n.binding, len(n.children) > 0
+}
+
+/*
+Returns the shortest sequence of keys bound to the action, or nil
+*/
+func (m *Keymap) KeysForAction(action string) []byte {
+	type entry struct {
+		node *keymapNode
+		keys []byte
+	}
+
+	// breadth first, so that the shortest sequence is found first
+	queue := []entry{{node: m.root}}
+	for len(queue) > 0 {
+		e := queue[0]
+		queue = queue[1:]
+
+		if e.node.binding != nil && e.node.binding.Action == action {
+			return e.keys
+		}
+
+		// sorted so that the same sequence is found every time
+		children := make([]byte, 0, len(e.node.children))
+		for key := range e.node.children {
+			children = append(children, key)
+		}
+		sort.Slice(children, func(i, j int) bool { return children[i] < children[j] })
+
+		for _, key := range children {
+			keys := append(append([]byte{}, e.keys...), key)
+			queue = append(queue, entry{node: e.node.children[key], keys: keys})
+		}
+	}
+
+	return nil
+}
+
+/*
+Parses a sequence of keys written as described at the top of this file
+*/
+func ParseKeySequence(s string, leader byte) ([]byte, error) {
+	keys := make([]byte, 0)
+
+	for _, token := range strings.Fields(s) {
+		if strings.HasPrefix(token, "<") && strings.HasSuffix(token, ">") && len(token) > 2 {
+			name := token[1 : len(token)-1]
+
+			switch strings.ToLower(name) {
+			case "leader":
+				keys = append(keys, leader)
+			case "space":
+				keys = append(keys, Space)
+			case "tab":
+				keys = append(keys, Tab)
+			case "enter", "cr":
+				keys = append(keys, NewLine)
+			case "bs":
+				keys = append(keys, Backspace)
+			default:
+				lower := strings.ToLower(name)
+				if len(lower) == 3 && strings.HasPrefix(lower, "c-") && lower[2] >= 'a' && lower[2] <= 'z' {
+					keys = append(keys, lower[2]-'a'+1)
+				} else {
+					return nil, fmt.Errorf("unknown key %s", token)
+				}
+			}
+			continue
+		}
+
+		for i := 0; i < len(token); i++ {
+			if token[i] < Space || token[i] >= Backspace {
+				return nil, fmt.Errorf("invalid key in %q", token)
+			}
+			keys = append(keys, token[i])
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("empty key sequence")
+	}
+
+	return keys, nil
+}
+
+/*
+Returns a readable form of the sequence of keys, like "Space f s"
+*/
+func keySequenceName(keys []byte) string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = keyName(key)
+	}
+
+	return strings.Join(names, " ")
+}
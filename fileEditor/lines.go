@@ -0,0 +1,327 @@
+package fileeditor
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for the commands that work on whole lines:
+duplicating, moving, joining and deleting them, and sorting and reversing them.
+
+They work on the line of the cursor, or on the lines from the first cursor to
+the last one when there are secondary cursors. Sorting and reversing work on
+the whole buffer instead, unless there are secondary cursors or a range of
+lines is given to the command:
+
+	sort [n][r][u] [start,end]   sorts the lines, where n sorts them by the first
+	                             number in them, r reverses the order, and u
+	                             removes the lines that are the same as another
+	reverse [start,end]          reverses the order of the lines
+
+Every command is undone in one step
+*/
+
+var firstNumberRegexp = regexp.MustCompile(`-?\d+(\.\d+)?`)
+
+/*
+Returns the first and last line (0-indexed) of the lines from the first cursor to the last one
+*/
+func (f *FileEditor) cursorLines() (first int, last int) {
+	cursors := f.allCursors()
+	first, last = cursors[0].Line, cursors[0].Line
+
+	for _, c := range cursors[1:] {
+		first, last = math.Min(first, c.Line), math.Max(last, c.Line)
+	}
+
+	return first, last
+}
+
+/*
+Returns the first and last line (0-indexed) of the range argument of a command,
+like 10,20, which must be inside of the buffer
+*/
+func (f FileEditor) parseLineRange(s string) (int, int, error) {
+	startStr, endStr, _ := strings.Cut(s, ",")
+	start, err1 := strconv.Atoi(startStr)
+	end, err2 := strconv.Atoi(endStr)
+	if err1 != nil || err2 != nil || start < 1 || end < start {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	if start > len(f.FileBuffer) {
+		return 0, 0, fmt.Errorf("the buffer has %d lines", len(f.FileBuffer))
+	}
+
+	return start - 1, math.Min(end, len(f.FileBuffer)) - 1, nil
+}
+
+/*
+Returns the lines that sorting and reversing work on,
+which is the whole buffer unless there are secondary cursors
+*/
+func (f *FileEditor) rangeOrCursorLines(rangeArg string) (int, int, error) {
+	if rangeArg != "" {
+		return f.parseLineRange(rangeArg)
+	}
+	if len(f.cursors) > 0 {
+		first, last := f.cursorLines()
+		return first, last, nil
+	}
+
+	return 0, len(f.FileBuffer) - 1, nil
+}
+
+/*
+Copies the lines below themselves, and moves the cursors onto the copy
+*/
+func (f *FileEditor) DuplicateLines() {
+	if !f.writable() {
+		return
+	}
+
+	first, last := f.cursorLines()
+	lines := slices.Clone(f.FileBuffer[first : last+1])
+
+	cursors := f.allCursors()
+	for i := range cursors {
+		cursors[i].Line += len(lines)
+	}
+
+	f.pushUndo()
+	f.ReplaceLines(last+1, 0, lines)
+	f.bufferModified()
+	f.setCursors(cursors)
+}
+
+/*
+Moves the lines one line up, or down if down is true, along with the cursors
+*/
+func (f *FileEditor) MoveLines(down bool) {
+	if !f.writable() {
+		return
+	}
+
+	first, last := f.cursorLines()
+	if (!down && first == 0) || (down && last == len(f.FileBuffer)-1) {
+		return
+	}
+
+	// the line next to the lines goes to their other side
+	lines := slices.Clone(f.FileBuffer[first : last+1])
+	start, step := first-1, -1
+	if down {
+		lines = append([]string{f.FileBuffer[last+1]}, lines...)
+		start, step = first, 1
+	} else {
+		lines = append(lines, f.FileBuffer[first-1])
+	}
+
+	cursors := f.allCursors()
+	for i := range cursors {
+		cursors[i].Line += step
+	}
+
+	f.pushUndo()
+	f.ReplaceLines(start, len(lines), lines)
+	f.linesMoved(first, last, step)
+	f.bufferModified()
+	f.setCursors(cursors)
+}
+
+/*
+Returns the lines joined into one, where the indentation of every line but the
+first is removed, and the lines are separated by a space, unless the line
+after it is empty or starts with a closing bracket
+*/
+func JoinLineTexts(lines []string) string {
+	joined := strings.TrimRight(lines[0], " \t")
+
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line != "" && joined != "" && !strings.ContainsRune(")]}", rune(line[0])) {
+			joined += " "
+		}
+		joined += line
+	}
+
+	return joined
+}
+
+/*
+Joins count lines from the cursor, at least two, into one. With secondary
+cursors, the lines from the first cursor to the last one are joined
+*/
+func (f *FileEditor) JoinLines(count int) {
+	if !f.writable() {
+		return
+	}
+
+	first, last := f.cursorLines()
+	if first == last {
+		last = math.Min(first+math.Max(count, 2)-1, len(f.FileBuffer)-1)
+	}
+	if first == last {
+		return
+	}
+
+	// the cursor goes to where the last line was joined
+	joined := JoinLineTexts(f.FileBuffer[first : last+1])
+	index := len(JoinLineTexts(f.FileBuffer[first:last]))
+
+	f.pushUndo()
+	f.ReplaceLines(first, last-first+1, []string{joined})
+	f.bufferModified()
+	f.setCursors([]BufferPos{{Line: first, Index: index}})
+}
+
+/*
+Deletes the lines, which are put in the register so that they can be pasted
+*/
+func (f *FileEditor) DeleteLines() {
+	if !f.writable() {
+		return
+	}
+
+	first, last := f.cursorLines()
+	f.modal.register = register{text: strings.Join(f.FileBuffer[first:last+1], "\n"), linewise: true}
+
+	f.pushUndo()
+	f.ReplaceLines(first, last-first+1, nil)
+	f.bufferModified()
+
+	line := math.Min(first, len(f.FileBuffer)-1)
+	f.setCursors([]BufferPos{{Line: line, Index: FirstNonBlank(f.FileBuffer[line])}})
+}
+
+/*
+Returns the lines sorted, by the first number in them if numeric is true, where
+lines without a number go first. The order is reversed if reverse is true, and
+only the first of the lines that are the same is kept if unique is true
+*/
+func SortLineTexts(lines []string, numeric bool, reverse bool, unique bool) []string {
+	sorted := slices.Clone(lines)
+
+	if numeric {
+		number := func(line string) (float64, bool) {
+			n, err := strconv.ParseFloat(firstNumberRegexp.FindString(line), 64)
+			return n, err == nil
+		}
+
+		slices.SortStableFunc(sorted, func(a, b string) int {
+			na, okA := number(a)
+			nb, okB := number(b)
+			switch {
+			case !okA || !okB:
+				return boolCompare(okA, okB)
+			case na < nb:
+				return -1
+			case na > nb:
+				return 1
+			}
+			return 0
+		})
+	} else {
+		slices.SortStableFunc(sorted, strings.Compare)
+	}
+
+	if reverse {
+		slices.Reverse(sorted)
+	}
+
+	if unique {
+		seen := make(map[string]bool)
+		kept := sorted[:0]
+		for _, line := range sorted {
+			if !seen[line] {
+				seen[line] = true
+				kept = append(kept, line)
+			}
+		}
+		sorted = kept
+	}
+
+	return sorted
+}
+
+func boolCompare(a bool, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+/*
+Runs the sort command with its arguments
+*/
+func (f *FileEditor) SortLines(arg string) {
+	var numeric, reverse, unique bool
+	rangeArg := ""
+
+	for _, field := range strings.Fields(arg) {
+		if strings.Contains(field, ",") {
+			rangeArg = field
+			continue
+		}
+
+		for _, flag := range field {
+			switch flag {
+			case 'n':
+				numeric = true
+			case 'r':
+				reverse = true
+			case 'u':
+				unique = true
+			default:
+				f.statusMessage = fmt.Sprintf("Unknown sort flag: %c", flag)
+				return
+			}
+		}
+	}
+
+	first, last, err := f.rangeOrCursorLines(rangeArg)
+	if err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	f.replaceLineRange(first, last, SortLineTexts(f.FileBuffer[first:last+1], numeric, reverse, unique))
+}
+
+/*
+Runs the reverse command with its argument
+*/
+func (f *FileEditor) ReverseLines(arg string) {
+	first, last, err := f.rangeOrCursorLines(strings.TrimSpace(arg))
+	if err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	lines := slices.Clone(f.FileBuffer[first : last+1])
+	slices.Reverse(lines)
+	f.replaceLineRange(first, last, lines)
+}
+
+/*
+Replaces the lines from the first line to the last one with the lines,
+and moves the cursor to the first of them
+*/
+func (f *FileEditor) replaceLineRange(first int, last int, lines []string) {
+	if !f.writable() {
+		return
+	}
+
+	f.pushUndo()
+	f.ReplaceLines(first, last-first+1, lines)
+	f.bufferModified()
+	f.setCursors([]BufferPos{{Line: first, Index: FirstNonBlank(f.FileBuffer[first])}})
+}
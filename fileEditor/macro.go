@@ -0,0 +1,207 @@
+package fileeditor
+
+import (
+	"fmt"
+	"slices"
+)
+
+/*
+This file is responsible for keyboard macros.
+
+In Command mode, q followed by a name records the input into the register with
+the name, until q is typed in Command mode again. Every event of the input is
+recorded as it was read, so a macro can move the cursor, edit the buffer, run
+commands of the command bar and use the mouse. Recording into an uppercase
+name appends to the register of the lowercase one.
+
+@ followed by a name plays the macro count times, and @@ plays the last macro
+that was played. A macro is played by handling its events again, one after the
+other, without rendering between them. Playing stops at the first event that
+fails, like a search or an f motion that finds nothing, or an unknown command,
+whose error is shown in the status bar. The changes a macro makes to the current buffer are
+undone in one step
+*/
+
+// how deep macros can play other macros, which stops macros that play themselves
+const maxMacroDepth int = 100
+
+type macroState struct {
+	registers map[byte][][]byte // events of each macro by its name
+	recording byte              // name of the macro being recorded; 0 if none is
+	events    [][]byte          // events recorded so far
+	depth     int               // how many macros are being played, counting the macros they play
+	last      byte              // name of the last macro that was played
+	undoFile  string            // the buffer whose changes are undone in one step while playing
+}
+
+func isMacroName(name byte) bool {
+	return (name >= 'a' && name <= 'z') || (name >= 'A' && name <= 'Z')
+}
+
+/*
+Sets the status message to information rather than an error,
+so that it doesn't stop a macro from playing
+*/
+func (f *FileEditor) showInfo(message string) {
+	f.statusMessage = message
+	f.statusInfo = true
+}
+
+/*
+Starts recording the input into the macro with the name
+*/
+func (f *FileEditor) StartMacro(name byte) {
+	if !isMacroName(name) {
+		f.statusMessage = fmt.Sprintf("Invalid macro name: %c", name)
+		return
+	}
+
+	f.macros.recording = name
+	f.macros.events = nil
+}
+
+/*
+Stops recording, and stores the events recorded in the register
+of the macro, or appends them to it if the name is uppercase
+*/
+func (f *FileEditor) StopMacro() {
+	name := f.macros.recording
+	if name == 0 {
+		return
+	}
+
+	// the q that stopped the recording was recorded before it could be known
+	events := f.macros.events
+	if n := len(events); n > 0 && string(events[n-1]) == "q" {
+		events = events[:n-1]
+	}
+
+	if f.macros.registers == nil {
+		f.macros.registers = make(map[byte][][]byte)
+	}
+	if name >= 'A' && name <= 'Z' {
+		name += 'a' - 'A'
+		events = append(f.macros.registers[name], events...)
+	}
+	f.macros.registers[name] = events
+
+	f.macros.recording = 0
+	f.macros.events = nil
+	f.showInfo(fmt.Sprintf("Recorded @%c", name))
+}
+
+/*
+Records an event of the input while a macro is being recorded
+*/
+func (f *FileEditor) recordMacroEvent(event []byte) {
+	if f.macros.recording == 0 {
+		return
+	}
+
+	f.macros.events = append(f.macros.events, slices.Clone(event))
+}
+
+/*
+Plays the macro with the name count times, where @ is the last macro
+that was played. Returns the flag that should be sent to the render loop
+*/
+func (f *FileEditor) PlayMacro(name byte, count int) byte {
+	if name == '@' {
+		name = f.macros.last
+	}
+	if name >= 'A' && name <= 'Z' {
+		name += 'a' - 'A'
+	}
+
+	events, ok := f.macros.registers[name]
+	if !ok {
+		if name == 0 {
+			f.statusMessage = "No macro has been played"
+		} else {
+			f.statusMessage = fmt.Sprintf("Macro not recorded: %c", name)
+		}
+		return EnumCursorPositionChange
+	}
+	if f.macros.depth >= maxMacroDepth {
+		f.statusMessage = "Macros are played too deep"
+		return EnumCursorPositionChange
+	}
+	f.macros.last = name
+
+	// the outermost macro makes the undo step of the buffer
+	outermost := f.macros.depth == 0
+	before := slices.Clone(f.FileBuffer)
+	undoDepth := len(f.undoStack)
+	if outermost {
+		f.pushUndo()
+		f.macros.undoFile = f.Filename
+	}
+
+	f.macros.depth++
+	flag := f.playEvents(events, count)
+	f.macros.depth--
+
+	if outermost {
+		// a macro that changed nothing leaves nothing to undo. The snapshot of the
+		// macro is only on top of the stack if the macro didn't undo anything
+		if f.Filename == f.macros.undoFile && slices.Equal(before, f.FileBuffer) && len(f.undoStack) == undoDepth+1 {
+			f.undoStack = f.undoStack[:undoDepth]
+		}
+
+		f.macros.undoFile = ""
+		f.breakUndoGroup()
+	}
+
+	return flag
+}
+
+/*
+Handles the events count times, updating the editor after each of them as a
+render would. Stops at the first event that fails or quits. Returns the flag
+that should be sent to the render loop, which only redraws the editor since
+it has already been updated
+*/
+func (f *FileEditor) playEvents(events [][]byte, count int) byte {
+	for range count {
+		for _, event := range events {
+			if flag := f.replayEvent(event); flag == EnumQuit {
+				return flag
+			}
+
+			if f.statusMessage != "" && !f.statusInfo {
+				return f.macroFailed()
+			}
+		}
+	}
+
+	// keys left of a key sequence are resolved now rather than after the timeout
+	if len(f.PendingKeys()) > 0 {
+		flag := f.ResolvePendingKeys()
+		if flag == EnumQuit {
+			return flag
+		}
+		f.updateForFlag(flag)
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Stops the macro being played, along with the macros that play it,
+keeping the error of the event that failed
+*/
+func (f *FileEditor) macroFailed() byte {
+	if f.macros.depth == 1 {
+		f.statusMessage = "Macro stopped: " + f.statusMessage
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Returns true while a macro is played and the changes of the current
+buffer are part of the undo step of the macro
+*/
+func (f FileEditor) inMacroUndoStep() bool {
+	return f.macros.depth > 0 && f.Filename == f.macros.undoFile
+}
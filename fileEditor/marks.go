@@ -0,0 +1,506 @@
+package fileeditor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for marks and bookmarks.
+
+A mark remembers a position by its name. Marks a-z belong to the buffer they
+are set in, and marks A-Z are global, so going to one opens its file. In
+Command mode, m followed by the name sets a mark, ' followed by the name goes
+to the line of the mark, and ` followed by the name goes to its position. Both
+can follow an operator, like d'a.
+
+A bookmark is set on a whole line, and is toggled on the line of the cursor.
+The bookmark list shows the bookmarks and marks of every open buffer, along
+with the global marks.
+
+Marks and bookmarks are shown in the gutter, in place of the git marker, and
+move with their lines as lines are inserted and deleted. The marks of a line
+that is deleted are removed. They are written to the marks state file when
+they are set, and when the buffer is saved, so that the written positions
+match the saved file
+*/
+
+const marksStateName string = "marks.json"
+
+const bookmarkMarker byte = '*'
+
+var (
+	bookmarkColor string = ansi.NewRGBColor(90, 160, 250).ToFgColorANSI()
+	markColor     string = ansi.NewRGBColor(190, 130, 250).ToFgColorANSI()
+)
+
+// the marks of a file, as they are written to the state file
+type fileMarks struct {
+	Marks     map[string]BufferPos `json:"marks,omitempty"`
+	Bookmarks []int                `json:"bookmarks,omitempty"`
+}
+
+type marksState struct {
+	Files  map[string]fileMarks `json:"files"` // by absolute path
+	Global map[string]Jump      `json:"global"`
+}
+
+func isLocalMark(name byte) bool {
+	return name >= 'a' && name <= 'z'
+}
+
+func isGlobalMark(name byte) bool {
+	return name >= 'A' && name <= 'Z'
+}
+
+/*
+Returns the absolute path of the file, which is how
+marks refer to files, or the name itself if it fails
+*/
+func absPath(filename string) string {
+	if path, err := filepath.Abs(filename); err == nil {
+		return path
+	}
+
+	return filename
+}
+
+/*
+Returns the line (0-indexed) that a mark on the line moves to after count lines
+from the start line were replaced with newCount lines. Returns false if the
+line was deleted, in which case the mark is removed
+*/
+func AdjustMarkLine(line int, start int, count int, newCount int) (int, bool) {
+	switch {
+	case line < start:
+		return line, true
+	case line >= start+count:
+		return line + newCount - count, true
+	case newCount == 0:
+		return 0, false
+	default:
+		return math.Min(line, start+newCount-1), true
+	}
+}
+
+/*
+Moves the marks, bookmarks and folds of the current buffer after count lines
+from the start line were replaced with newCount lines
+*/
+func (f *FileEditor) linesReplaced(start int, count int, newCount int) {
+	// lines replaced with as many lines keep their marks, but not their closed folds
+	f.foldsReplaced(start, count, newCount)
+	if count == newCount {
+		return
+	}
+
+	for name, pos := range f.marks {
+		if line, ok := AdjustMarkLine(pos.Line, start, count, newCount); ok {
+			f.marks[name] = BufferPos{Line: line, Index: pos.Index}
+		} else {
+			delete(f.marks, name)
+		}
+	}
+
+	bookmarks := make([]int, 0, len(f.bookmarks))
+	for _, b := range f.bookmarks {
+		if line, ok := AdjustMarkLine(b, start, count, newCount); ok && !slices.Contains(bookmarks, line) {
+			bookmarks = append(bookmarks, line)
+		}
+	}
+	f.bookmarks = bookmarks
+
+	filename := absPath(f.Filename)
+	for name, j := range f.globalMarks {
+		if j.Filename != filename {
+			continue
+		}
+		if line, ok := AdjustMarkLine(j.Pos.Line, start, count, newCount); ok {
+			j.Pos.Line = line
+			f.globalMarks[name] = j
+		} else {
+			delete(f.globalMarks, name)
+		}
+	}
+}
+
+/*
+Returns the line (0-indexed) that a mark on the line moves to after the lines
+from first to last moved by step lines, over the lines that take their place
+*/
+func MovedMarkLine(line int, first int, last int, step int) int {
+	switch {
+	case line >= first && line <= last:
+		return line + step
+	case step < 0 && line >= first+step && line < first:
+		return line + last - first + 1
+	case step > 0 && line > last && line <= last+step:
+		return line - (last - first + 1)
+	default:
+		return line
+	}
+}
+
+/*
+Moves the marks and bookmarks of the current buffer along with the lines
+from first to last, which moved by step lines
+*/
+func (f *FileEditor) linesMoved(first int, last int, step int) {
+	for name, pos := range f.marks {
+		pos.Line = MovedMarkLine(pos.Line, first, last, step)
+		f.marks[name] = pos
+	}
+
+	for i, b := range f.bookmarks {
+		f.bookmarks[i] = MovedMarkLine(b, first, last, step)
+	}
+	slices.Sort(f.bookmarks)
+
+	filename := absPath(f.Filename)
+	for name, j := range f.globalMarks {
+		if j.Filename == filename {
+			j.Pos.Line = MovedMarkLine(j.Pos.Line, first, last, step)
+			f.globalMarks[name] = j
+		}
+	}
+}
+
+/*
+Sets the mark with the name at the position of the cursor
+*/
+func (f *FileEditor) SetMark(name byte) {
+	pos := f.cursorPos()
+
+	switch {
+	case isLocalMark(name):
+		if f.marks == nil {
+			f.marks = make(map[byte]BufferPos)
+		}
+		f.marks[name] = pos
+	case isGlobalMark(name):
+		if f.globalMarks == nil {
+			f.globalMarks = make(map[byte]Jump)
+		}
+		f.globalMarks[name] = Jump{Filename: absPath(f.Filename), Pos: pos}
+	default:
+		f.statusMessage = fmt.Sprintf("Invalid mark: %c", name)
+		return
+	}
+
+	f.SaveMarks()
+}
+
+/*
+Returns the position of the mark in the current buffer, kept inside of the
+FileBuffer, or false if the mark isn't set or is a global mark of another file
+*/
+func (f FileEditor) markPos(name byte) (BufferPos, bool) {
+	pos, ok := f.marks[name]
+	if isGlobalMark(name) {
+		j, set := f.globalMarks[name]
+		pos, ok = j.Pos, set && j.Filename == absPath(f.Filename)
+	}
+	if !ok {
+		return BufferPos{}, false
+	}
+
+	pos.Line = math.Clamp(pos.Line, 0, len(f.FileBuffer)-1)
+	pos.Index = math.Clamp(pos.Index, 0, len(f.FileBuffer[pos.Line]))
+
+	return pos, true
+}
+
+/*
+Moves the cursor to the mark, switching to its file for a global mark.
+If linewise is true, the cursor goes to the first non-blank character of
+the line of the mark instead of its position
+*/
+func (f *FileEditor) GoToMark(name byte, linewise bool) {
+	j, ok := f.globalMarks[name]
+	if isLocalMark(name) {
+		j.Filename = f.Filename
+		j.Pos, ok = f.marks[name]
+	}
+	if !ok {
+		f.statusMessage = fmt.Sprintf("Mark not set: %c", name)
+		return
+	}
+
+	f.pushJump()
+	if absPath(j.Filename) != absPath(f.Filename) {
+		if err := f.OpenBuffer(j.Filename); err != nil {
+			f.statusMessage = err.Error()
+			return
+		}
+	}
+
+	line := math.Clamp(j.Pos.Line, 0, len(f.FileBuffer)-1)
+	if linewise {
+		f.MoveCursorToBufferPos(line, FirstNonBlank(f.FileBuffer[line]))
+	} else {
+		f.MoveCursorToBufferPos(line, j.Pos.Index)
+	}
+}
+
+/*
+Adds a bookmark to the line of the cursor, or removes it if the line has one
+*/
+func (f *FileEditor) ToggleBookmark() {
+	line := f.cursorPos().Line
+
+	if i := slices.Index(f.bookmarks, line); i >= 0 {
+		f.bookmarks = slices.Delete(f.bookmarks, i, i+1)
+	} else {
+		f.bookmarks = append(f.bookmarks, line)
+		slices.Sort(f.bookmarks)
+	}
+
+	f.SaveMarks()
+}
+
+/*
+Returns the colored gutter marker of the line (0-indexed), which is the marker
+of a closed fold, the bookmark marker or the name of a mark on the line, or else
+the git marker of the line, or the marker of a fold that starts on it
+*/
+func (f FileEditor) gutterMarker(line int) string {
+	if _, ok := f.closedFoldAt(line); ok {
+		return f.foldGutterMarker(line)
+	}
+
+	if slices.Contains(f.bookmarks, line) {
+		return bookmarkColor + string(bookmarkMarker)
+	}
+
+	for name := byte('a'); name <= 'z'; name++ {
+		if pos, ok := f.marks[name]; ok && pos.Line == line {
+			return markColor + string(name)
+		}
+	}
+	if len(f.globalMarks) > 0 {
+		filename := absPath(f.Filename)
+		for name := byte('A'); name <= 'Z'; name++ {
+			if j, ok := f.globalMarks[name]; ok && j.Filename == filename && j.Pos.Line == line {
+				return markColor + string(name)
+			}
+		}
+	}
+
+	if marker := f.gitGutterMarker(line); marker != " " {
+		return marker
+	}
+	if marker := f.foldGutterMarker(line); marker != "" {
+		return marker
+	}
+
+	return " "
+}
+
+/*
+Opens a picker that lists the bookmarks and marks of every open
+buffer, along with the global marks, and goes to the chosen one
+*/
+func (f *FileEditor) OpenBookmarkList() {
+	f.storeCurrentBuffer()
+
+	lineText := func(lines []string, line int) string {
+		if line >= len(lines) {
+			return ""
+		}
+		return strings.TrimSpace(lines[line])
+	}
+
+	items := make([]PickerItem, 0)
+	for _, b := range f.buffers {
+		for _, line := range b.bookmarks {
+			items = append(items, PickerItem{
+				Label:  fmt.Sprintf("%c %s:%d", bookmarkMarker, b.Filename, line+1),
+				Detail: lineText(b.FileBuffer, line),
+				Value:  Jump{Filename: b.Filename, Pos: BufferPos{Line: line}},
+			})
+		}
+
+		for name := byte('a'); name <= 'z'; name++ {
+			if pos, ok := b.marks[name]; ok {
+				items = append(items, PickerItem{
+					Label:  fmt.Sprintf("%c %s:%d", name, b.Filename, pos.Line+1),
+					Detail: lineText(b.FileBuffer, pos.Line),
+					Value:  Jump{Filename: b.Filename, Pos: pos},
+				})
+			}
+		}
+	}
+
+	for name := byte('A'); name <= 'Z'; name++ {
+		if j, ok := f.globalMarks[name]; ok {
+			items = append(items, PickerItem{
+				Label: fmt.Sprintf("%c %s:%d", name, j.Filename, j.Pos.Line+1),
+				Value: j,
+			})
+		}
+	}
+
+	if len(items) == 0 {
+		f.statusMessage = "No bookmarks or marks"
+		return
+	}
+
+	f.OpenPicker(NewPicker("Bookmarks", items, func(f *FileEditor, item PickerItem) byte {
+		f.pushJump()
+		f.goToJump(item.Value.(Jump))
+		return 0
+	}))
+}
+
+func readMarksState() (marksState, error) {
+	state := marksState{Files: make(map[string]fileMarks), Global: make(map[string]Jump)}
+
+	path, err := ConfigPath(marksStateName)
+	if err != nil {
+		return state, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("%s: %w", path, err)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]fileMarks)
+	}
+	if state.Global == nil {
+		state.Global = make(map[string]Jump)
+	}
+
+	return state, nil
+}
+
+/*
+Reads the marks and bookmarks of the current buffer from the state file. The
+global marks are read along with the marks of the first buffer
+*/
+func (f *FileEditor) LoadMarks() {
+	state, err := readMarksState()
+	if err != nil {
+		f.statusMessage = err.Error()
+	}
+
+	if f.globalMarks == nil {
+		f.globalMarks = make(map[byte]Jump)
+		for name, j := range state.Global {
+			if len(name) == 1 && isGlobalMark(name[0]) {
+				f.globalMarks[name[0]] = j
+			}
+		}
+	}
+
+	saved := state.Files[absPath(f.Filename)]
+
+	f.marks = make(map[byte]BufferPos)
+	for name, pos := range saved.Marks {
+		if len(name) == 1 && isLocalMark(name[0]) {
+			f.marks[name[0]] = pos
+		}
+	}
+	f.bookmarks = saved.Bookmarks
+}
+
+/*
+Writes the marks and bookmarks of the current buffer, and the global marks,
+to the state file, keeping the marks of the other files that are in it
+*/
+func (f *FileEditor) SaveMarks() {
+	state, err := readMarksState()
+	if err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	saved := fileMarks{Marks: make(map[string]BufferPos), Bookmarks: f.bookmarks}
+	for name, pos := range f.marks {
+		saved.Marks[string(name)] = pos
+	}
+
+	filename := absPath(f.Filename)
+	if len(saved.Marks) == 0 && len(saved.Bookmarks) == 0 {
+		delete(state.Files, filename)
+	} else {
+		state.Files[filename] = saved
+	}
+
+	state.Global = make(map[string]Jump)
+	for name, j := range f.globalMarks {
+		state.Global[string(name)] = j
+	}
+
+	if err := writeMarksState(state); err != nil {
+		f.statusMessage = err.Error()
+	}
+}
+
+func writeMarksState(state marksState) error {
+	data, err := json.MarshalIndent(state, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	path, err := ConfigPath(marksStateName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+/*
+Moves the marks of the file, or of the files in the directory, that moved from
+the old path to the new one (both absolute), in the global marks and in the state file
+*/
+func (f *FileEditor) renameMarks(oldPath, newPath string) {
+	for name, j := range f.globalMarks {
+		if filename, ok := renamedPath(j.Filename, oldPath, newPath); ok {
+			j.Filename = filename
+			f.globalMarks[name] = j
+		}
+	}
+
+	state, err := readMarksState()
+	if err != nil {
+		f.statusMessage = err.Error()
+		return
+	}
+
+	files := make(map[string]fileMarks, len(state.Files))
+	for filename, marks := range state.Files {
+		filename, _ = renamedPath(filename, oldPath, newPath)
+		files[filename] = marks
+	}
+	state.Files = files
+
+	for name, j := range state.Global {
+		if filename, ok := renamedPath(j.Filename, oldPath, newPath); ok {
+			j.Filename = filename
+			state.Global[name] = j
+		}
+	}
+
+	if err := writeMarksState(state); err != nil {
+		f.statusMessage = err.Error()
+	}
+}
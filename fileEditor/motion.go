@@ -0,0 +1,346 @@
+package fileeditor
+
+import (
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for the motions of Command mode, which move a position
+in the FileBuffer. Motions work on lines and actual indicies of the FileBuffer,
+so they don't depend on where the cursor is drawn, and they are used both to
+move the cursor and to select the text an operator works on.
+
+A position may be one past the last character of its line, where the line break
+is. Word motions treat line breaks as whitespace, except that an empty line is
+a word of its own, so that moving by words stops at empty lines. Words are made
+of letters, digits and the characters in the WordChars config of the editor
+*/
+
+type BufferPos struct {
+	Line  int // 0-indexed line of the FileBuffer
+	Index int // actual index in the line
+}
+
+/*
+Returns true if the position comes before the other one
+*/
+func (p BufferPos) Before(other BufferPos) bool {
+	return p.Line < other.Line || (p.Line == other.Line && p.Index < other.Index)
+}
+
+// how the text an operator works on is selected by a motion
+const (
+	motionExclusive byte = iota // up to the position the motion ends on
+	motionInclusive             // up to and including the character the motion ends on
+	motionLinewise              // every line from the start to the end of the motion
+)
+
+type motion struct {
+	kind      byte
+	needsChar bool // true if the motion is followed by a character, like f
+	/*
+		Returns the position the motion ends on, or false if it can't move,
+		in which case the command is cancelled. hasCount is false when no
+		count was typed, in which case count is 1
+	*/
+	move func(f *FileEditor, pos BufferPos, count int, hasCount bool, char byte) (BufferPos, bool)
+}
+
+var motions = map[string]motion{
+	"h": {motionExclusive, false, func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+		pos.Index = math.Max(pos.Index-count, 0)
+		return pos, true
+	}},
+	"l": {motionExclusive, false, func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+		pos.Index = math.Min(pos.Index+count, len(f.FileBuffer[pos.Line]))
+		return pos, true
+	}},
+	"j": {motionLinewise, false, func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+		return f.verticalMotion(pos, count), true
+	}},
+	"k": {motionLinewise, false, func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+		return f.verticalMotion(pos, -count), true
+	}},
+	"w": {motionExclusive, false, func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+		for range count {
+			pos = NextWordStart(f.FileBuffer, pos, f.WordChars)
+		}
+		return pos, true
+	}},
+	"b": {motionExclusive, false, func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+		for range count {
+			pos = PrevWordStart(f.FileBuffer, pos, f.WordChars)
+		}
+		return pos, true
+	}},
+	"e": {motionInclusive, false, func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+		for range count {
+			pos = NextWordEnd(f.FileBuffer, pos, f.WordChars)
+		}
+		return pos, true
+	}},
+	"0": {motionExclusive, false, func(f *FileEditor, pos BufferPos, _ int, _ bool, _ byte) (BufferPos, bool) {
+		pos.Index = 0
+		return pos, true
+	}},
+	"$": {motionInclusive, false, func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+		pos.Line = math.Min(pos.Line+count-1, len(f.FileBuffer)-1)
+		pos.Index = math.Max(len(f.FileBuffer[pos.Line])-1, 0)
+		return pos, true
+	}},
+	"gg": {motionLinewise, false, func(f *FileEditor, pos BufferPos, count int, hasCount bool, _ byte) (BufferPos, bool) {
+		line := 0
+		if hasCount {
+			line = math.Min(count, len(f.FileBuffer)) - 1
+		}
+		return BufferPos{line, FirstNonBlank(f.FileBuffer[line])}, true
+	}},
+	"G": {motionLinewise, false, func(f *FileEditor, pos BufferPos, count int, hasCount bool, _ byte) (BufferPos, bool) {
+		line := len(f.FileBuffer) - 1
+		if hasCount {
+			line = math.Min(count, len(f.FileBuffer)) - 1
+		}
+		return BufferPos{line, FirstNonBlank(f.FileBuffer[line])}, true
+	}},
+	"f": {motionInclusive, true, func(f *FileEditor, pos BufferPos, count int, _ bool, char byte) (BufferPos, bool) {
+		return FindChar(f.FileBuffer[pos.Line], pos, char, count, true, false)
+	}},
+	"t": {motionInclusive, true, func(f *FileEditor, pos BufferPos, count int, _ bool, char byte) (BufferPos, bool) {
+		return FindChar(f.FileBuffer[pos.Line], pos, char, count, true, true)
+	}},
+	"F": {motionExclusive, true, func(f *FileEditor, pos BufferPos, count int, _ bool, char byte) (BufferPos, bool) {
+		return FindChar(f.FileBuffer[pos.Line], pos, char, count, false, false)
+	}},
+	"T": {motionExclusive, true, func(f *FileEditor, pos BufferPos, count int, _ bool, char byte) (BufferPos, bool) {
+		return FindChar(f.FileBuffer[pos.Line], pos, char, count, false, true)
+	}},
+	"'": {motionLinewise, true, func(f *FileEditor, pos BufferPos, _ int, _ bool, char byte) (BufferPos, bool) {
+		return f.markPos(char)
+	}},
+	"`": {motionExclusive, true, func(f *FileEditor, pos BufferPos, _ int, _ bool, char byte) (BufferPos, bool) {
+		return f.markPos(char)
+	}},
+	"%": {motionInclusive, false, func(f *FileEditor, pos BufferPos, _ int, _ bool, _ byte) (BufferPos, bool) {
+		return MatchingBracket(f.FileBuffer, pos)
+	}},
+	"{": {motionExclusive, false, func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+		return PrevParagraph(f.FileBuffer, pos, count), true
+	}},
+	"}": {motionExclusive, false, func(f *FileEditor, pos BufferPos, count int, _ bool, _ byte) (BufferPos, bool) {
+		return NextParagraph(f.FileBuffer, pos, count), true
+	}},
+}
+
+// motions that add the position they move from to the jump list
+var jumpMotions = map[string]bool{"gg": true, "G": true, "%": true}
+
+/*
+Moves the position by the number of lines, keeping it in the same
+visual column, which accounts for tabs being wider than a character
+*/
+func (f *FileEditor) verticalMotion(pos BufferPos, lines int) BufferPos {
+	column := f.GetVisualIndex(f.FileBuffer[pos.Line], pos.Index)
+
+	// lines hidden by a fold are skipped, so that a fold is moved over as one line
+	hidden := f.hiddenLines()
+	step := 1
+	if lines < 0 {
+		step, lines = -1, -lines
+	}
+	for line := pos.Line; lines > 0; {
+		line += step
+		if line < 0 || line >= len(f.FileBuffer) {
+			break
+		}
+		if hidden == nil || !hidden[line] {
+			pos.Line = line
+			lines--
+		}
+	}
+
+	pos.Index = f.GetActualIndex(f.FileBuffer[pos.Line], column)
+
+	return pos
+}
+
+// the classes of characters that words are made of
+const (
+	charClassSpace     byte = iota // whitespace and line breaks
+	charClassWord                  // letters, digits and the word characters of the editor
+	charClassPunct                 // everything else
+	charClassEmptyLine             // an empty line, which is a word of its own
+)
+
+/*
+Returns true if the character is a letter, a digit or one of the word characters
+*/
+func isWordChar(c byte, wordChars string) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || strings.IndexByte(wordChars, c) >= 0
+}
+
+func charClassAt(lines []string, pos BufferPos, wordChars string) byte {
+	line := lines[pos.Line]
+	if len(line) == 0 {
+		return charClassEmptyLine
+	}
+	if pos.Index >= len(line) {
+		return charClassSpace
+	}
+
+	c := line[pos.Index]
+	switch {
+	case c == Space || c == Tab:
+		return charClassSpace
+	case isWordChar(c, wordChars):
+		return charClassWord
+	default:
+		return charClassPunct
+	}
+}
+
+/*
+Returns the position after the given one, moving to the start of the next line
+after the line break. Returns false, and the same position, at the end of the lines
+*/
+func nextPos(lines []string, pos BufferPos) (BufferPos, bool) {
+	if pos.Index < len(lines[pos.Line]) {
+		return BufferPos{pos.Line, pos.Index + 1}, true
+	}
+	if pos.Line < len(lines)-1 {
+		return BufferPos{pos.Line + 1, 0}, true
+	}
+
+	return pos, false
+}
+
+/*
+Returns the position before the given one, moving to the line break of the
+previous line. Returns false, and the same position, at the start of the lines
+*/
+func prevPos(lines []string, pos BufferPos) (BufferPos, bool) {
+	if pos.Index > 0 {
+		return BufferPos{pos.Line, pos.Index - 1}, true
+	}
+	if pos.Line > 0 {
+		return BufferPos{pos.Line - 1, len(lines[pos.Line-1])}, true
+	}
+
+	return pos, false
+}
+
+/*
+Returns the start of the next word, or the end of the lines if there is none
+*/
+func NextWordStart(lines []string, pos BufferPos, wordChars string) BufferPos {
+	class := charClassAt(lines, pos, wordChars)
+
+	pos, ok := nextPos(lines, pos)
+	if class == charClassWord || class == charClassPunct {
+		for ok && charClassAt(lines, pos, wordChars) == class {
+			pos, ok = nextPos(lines, pos)
+		}
+	}
+
+	for ok && charClassAt(lines, pos, wordChars) == charClassSpace {
+		pos, ok = nextPos(lines, pos)
+	}
+
+	return pos
+}
+
+/*
+Returns the start of the word before the position, or the start of the lines
+*/
+func PrevWordStart(lines []string, pos BufferPos, wordChars string) BufferPos {
+	pos, ok := prevPos(lines, pos)
+	for ok && charClassAt(lines, pos, wordChars) == charClassSpace {
+		pos, ok = prevPos(lines, pos)
+	}
+
+	class := charClassAt(lines, pos, wordChars)
+	if class == charClassEmptyLine {
+		return pos
+	}
+
+	for prev, ok := prevPos(lines, pos); ok && charClassAt(lines, prev, wordChars) == class; prev, ok = prevPos(lines, pos) {
+		pos = prev
+	}
+
+	return pos
+}
+
+/*
+Returns the last character of the word after the position, skipping empty
+lines, or the end of the lines if there is none
+*/
+func NextWordEnd(lines []string, pos BufferPos, wordChars string) BufferPos {
+	pos, ok := nextPos(lines, pos)
+	for ok && (charClassAt(lines, pos, wordChars) == charClassSpace || charClassAt(lines, pos, wordChars) == charClassEmptyLine) {
+		pos, ok = nextPos(lines, pos)
+	}
+
+	return wordEndAt(lines, pos, wordChars)
+}
+
+/*
+Returns the last character of the word the position is on
+*/
+func wordEndAt(lines []string, pos BufferPos, wordChars string) BufferPos {
+	class := charClassAt(lines, pos, wordChars)
+	for next, ok := nextPos(lines, pos); ok && charClassAt(lines, next, wordChars) == class; next, ok = nextPos(lines, pos) {
+		pos = next
+	}
+
+	return pos
+}
+
+/*
+Finds the count-th occurrence of the character in the line, forward or
+backward from the position. If till is true, the position stops next to the
+character instead of on it, like the t and T motions.
+Returns false if the line doesn't have enough occurrences
+*/
+func FindChar(line string, pos BufferPos, char byte, count int, forward bool, till bool) (BufferPos, bool) {
+	i := pos.Index
+	for found := 0; found < count; {
+		if forward {
+			i++
+		} else {
+			i--
+		}
+
+		if i < 0 || i >= len(line) {
+			return pos, false
+		}
+
+		if line[i] == char {
+			found++
+		}
+	}
+
+	if till {
+		if forward {
+			i--
+		} else {
+			i++
+		}
+	}
+
+	pos.Index = i
+	return pos, true
+}
+
+/*
+Returns the index of the first character of the line that isn't whitespace
+*/
+func FirstNonBlank(line string) int {
+	for i := 0; i < len(line); i++ {
+		if line[i] != Space && line[i] != Tab {
+			return i
+		}
+	}
+
+	return len(line)
+}
@@ -0,0 +1,286 @@
+package fileeditor
+
+import (
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for moving the cursor by more than a cell, with the
+keys that do the same in every mode:
+
+	ctrl+left, ctrl+right   previous and next word
+	home, end               first non-blank character, or the start of the
+	                        line if already there, and the end of the line
+	page up, page down      a viewport height up or down
+	ctrl+home, ctrl+end     start and end of the buffer
+	ctrl+up, ctrl+down      previous and next paragraph
+	ctrl+]                  matching bracket
+
+The movements are registered as actions, and the keys that the terminal sends
+as escape sequences are mapped to them in specialKeyActions. Like the motions
+of Command mode, they work on positions of the FileBuffer, and
+MoveCursorToBufferPos places the cursor, so soft wrap and tabs are accounted for
+*/
+
+// escape sequences, without the escape, of the keys that run a movement
+var specialKeyActions = map[string]string{
+	"[1;5D": ActionWordLeft,
+	"[1;5C": ActionWordRight,
+	"[1;5A": ActionParagraphUp,
+	"[1;5B": ActionParagraphDown,
+	"[H":    ActionLineStart,
+	"[1~":   ActionLineStart,
+	"[7~":   ActionLineStart,
+	"OH":    ActionLineStart,
+	"[F":    ActionLineEnd,
+	"[4~":   ActionLineEnd,
+	"[8~":   ActionLineEnd,
+	"OF":    ActionLineEnd,
+	"[5~":   ActionPageUp,
+	"[6~":   ActionPageDown,
+	"[1;5H": ActionBufferStart,
+	"[1;5F": ActionBufferEnd,
+	"[1;3A": ActionAddCursorAbove, // alt+up
+	"[1;3B": ActionAddCursorBelow, // alt+down
+	"[1;7A": ActionAddCursorAbove, // ctrl+alt+up
+	"[1;7B": ActionAddCursorBelow, // ctrl+alt+down
+	"[1;6A": ActionMoveLinesUp,    // ctrl+shift+up
+	"[1;6B": ActionMoveLinesDown,  // ctrl+shift+down
+}
+
+func (f *FileEditor) cursorPos() BufferPos {
+	line, index := f.CursorBufferPos()
+	return BufferPos{Line: line, Index: index}
+}
+
+func (f *FileEditor) moveCursorTo(pos BufferPos) {
+	f.MoveCursorToBufferPos(pos.Line, pos.Index)
+}
+
+func (f *FileEditor) MoveWordLeft() {
+	f.moveCursorTo(PrevWordStart(f.FileBuffer, f.cursorPos(), f.WordChars))
+}
+
+func (f *FileEditor) MoveWordRight() {
+	f.moveCursorTo(NextWordStart(f.FileBuffer, f.cursorPos(), f.WordChars))
+}
+
+/*
+Moves the cursor to the first non-blank character of the line, or to
+the start of the line if it is already there
+*/
+func (f *FileEditor) MoveLineStart() {
+	pos := f.cursorPos()
+	first := FirstNonBlank(f.FileBuffer[pos.Line])
+
+	if pos.Index == first {
+		pos.Index = 0
+	} else {
+		pos.Index = first
+	}
+
+	f.moveCursorTo(pos)
+}
+
+func (f *FileEditor) MoveLineEnd() {
+	pos := f.cursorPos()
+	pos.Index = len(f.FileBuffer[pos.Line])
+	f.moveCursorTo(pos)
+}
+
+func (f *FileEditor) MoveBufferStart() {
+	f.pushJump()
+	f.moveCursorTo(BufferPos{})
+}
+
+func (f *FileEditor) MoveBufferEnd() {
+	f.pushJump()
+	last := len(f.FileBuffer) - 1
+	f.moveCursorTo(BufferPos{Line: last, Index: len(f.FileBuffer[last])})
+}
+
+func (f *FileEditor) MoveParagraph(forward bool) {
+	if forward {
+		f.moveCursorTo(NextParagraph(f.FileBuffer, f.cursorPos(), 1))
+	} else {
+		f.moveCursorTo(PrevParagraph(f.FileBuffer, f.cursorPos(), 1))
+	}
+}
+
+func (f *FileEditor) MoveMatchingBracket() {
+	pos, ok := MatchingBracket(f.FileBuffer, f.cursorPos())
+	if !ok {
+		f.statusMessage = "No matching bracket"
+		return
+	}
+
+	f.pushJump()
+	f.moveCursorTo(pos)
+}
+
+/*
+Scrolls the viewport by a page, and moves the cursor by as many visual rows,
+keeping it in the same column. At the ends of the buffer, where the viewport
+can't scroll, only the cursor moves
+*/
+func (f *FileEditor) MovePage(forward bool) {
+	f.RefreshVisualBuffers()
+
+	rows := f.GetViewportHeight()
+	if !forward {
+		rows = -rows
+	}
+
+	row, col := f.visualRowCol(f.cursorPos())
+	maxOffset := math.Max(len(f.VisualBuffer)-f.GetViewportHeight(), 0)
+	f.ViewportOffsetY = math.Clamp(f.ViewportOffsetY+rows, 0, maxOffset)
+
+	f.moveCursorTo(f.bufferPosAtVisual(math.Clamp(row+rows, 0, len(f.VisualBuffer)-1), col))
+}
+
+/*
+Returns the row of the VisualBuffer that the position is drawn on, and its
+column in that row. The visual buffers must be up to date
+*/
+func (f *FileEditor) visualRowCol(pos BufferPos) (row int, col int) {
+	col = f.GetVisualIndex(f.FileBuffer[pos.Line], pos.Index)
+	if !f.SoftWrapEnabled {
+		return f.rowOfLine(pos.Line), col
+	}
+
+	row = 0
+	if pos.Line > 0 {
+		row = f.VisualBufferMapped[pos.Line-1]
+	}
+	end := f.VisualBufferMapped[pos.Line]
+
+	for row < end-1 && col >= len(f.VisualBuffer[row]) {
+		col -= len(f.VisualBuffer[row])
+		row++
+	}
+
+	return row, col
+}
+
+/*
+Returns the position of the FileBuffer drawn at the row and column of the
+VisualBuffer, which is the inverse of visualRowCol. The visual buffers must be up to date
+*/
+func (f *FileEditor) bufferPosAtVisual(row int, col int) BufferPos {
+	if !f.SoftWrapEnabled {
+		line := f.lineAtRow(row)
+		return BufferPos{Line: line, Index: f.GetActualIndex(f.FileBuffer[line], col)}
+	}
+
+	line := 0
+	for line < len(f.VisualBufferMapped)-1 && f.VisualBufferMapped[line] <= row {
+		line++
+	}
+
+	// the visual index of the row is the length of the rows of the line before it
+	start := 0
+	if line > 0 {
+		start = f.VisualBufferMapped[line-1]
+	}
+	visualIndex := col
+	for r := start; r < row; r++ {
+		visualIndex += len(f.VisualBuffer[r])
+	}
+
+	return BufferPos{Line: line, Index: f.GetActualIndex(f.FileBuffer[line], visualIndex)}
+}
+
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+/*
+Returns the start of the count-th blank line after the paragraph the position
+is in, or the end of the lines if there is none, like the } motion
+*/
+func NextParagraph(lines []string, pos BufferPos, count int) BufferPos {
+	line := pos.Line
+	for range count {
+		for line < len(lines)-1 && isBlankLine(lines[line]) {
+			line++
+		}
+		for line < len(lines)-1 && !isBlankLine(lines[line]) {
+			line++
+		}
+	}
+
+	if isBlankLine(lines[line]) {
+		return BufferPos{Line: line}
+	}
+
+	return BufferPos{Line: line, Index: len(lines[line])}
+}
+
+/*
+Returns the start of the count-th blank line before the paragraph the
+position is in, or the start of the lines if there is none, like the { motion
+*/
+func PrevParagraph(lines []string, pos BufferPos, count int) BufferPos {
+	line := pos.Line
+	for range count {
+		for line > 0 && isBlankLine(lines[line]) {
+			line--
+		}
+		for line > 0 && !isBlankLine(lines[line]) {
+			line--
+		}
+	}
+
+	return BufferPos{Line: line}
+}
+
+/*
+Returns the position of the bracket that matches the bracket at the position,
+across lines and with nesting, where escaped brackets don't count. When the
+position isn't on a bracket, the first bracket after it on the line is used,
+like the % motion. Returns false if there is no bracket or it isn't matched
+*/
+func MatchingBracket(lines []string, pos BufferPos) (BufferPos, bool) {
+	const brackets = "([{)]}"
+
+	line := lines[pos.Line]
+	i := pos.Index
+	for i < len(line) && (strings.IndexByte(brackets, line[i]) < 0 || isEscaped(line, i)) {
+		i++
+	}
+	if i >= len(line) {
+		return pos, false
+	}
+
+	text, starts := joinLines(lines)
+	offset := starts[pos.Line] + i
+
+	k := strings.IndexByte(brackets, text[offset])
+	open, close := brackets[k%3], brackets[k%3+3]
+	step := 1
+	if k >= 3 {
+		step = -1
+		open, close = close, open
+	}
+
+	depth := 0
+	for j := offset; j >= 0 && j < len(text); j += step {
+		if isEscaped(text, j) {
+			continue
+		}
+
+		switch text[j] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return offsetToPos(starts, j), true
+			}
+		}
+	}
+
+	return pos, false
+}
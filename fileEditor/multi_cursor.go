@@ -0,0 +1,302 @@
+package fileeditor
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for multiple cursors. Besides the cursor, a buffer can
+have secondary cursors, which are added on the line above or below the cursors,
+at every occurrence of the word under the cursor, or in a column by dragging the
+mouse with Alt held down.
+
+In Edit mode, typing, deleting, and inserting tabs and new lines are done at
+every cursor, along with the pairs of pairs.go, and so is pasting in Command mode. The edits at the cursors are
+made at once with ApplyEdits, so they are undone in one step, and cursors that
+end up on the same position are merged. Every other key only moves the cursor.
+Pressing escape in Command mode removes the secondary cursors
+*/
+
+var secondaryCursorColor string = ansi.NewRGBColor(150, 150, 150).ToBgColorANSI()
+
+/*
+Returns the cursor followed by the secondary cursors,
+which are kept inside of the FileBuffer
+*/
+func (f *FileEditor) allCursors() []BufferPos {
+	cursors := make([]BufferPos, 0, len(f.cursors)+1)
+	cursors = append(cursors, f.cursorPos())
+
+	for _, c := range f.cursors {
+		c.Line = math.Clamp(c.Line, 0, len(f.FileBuffer)-1)
+		c.Index = math.Clamp(c.Index, 0, len(f.FileBuffer[c.Line]))
+		cursors = append(cursors, c)
+	}
+
+	return cursors
+}
+
+/*
+Moves the cursor to the first position, and makes the other positions the
+secondary cursors. Positions that are the same as another one are dropped
+*/
+func (f *FileEditor) setCursors(positions []BufferPos) {
+	primary := positions[0]
+
+	others := make([]BufferPos, 0, len(positions)-1)
+	for _, pos := range positions[1:] {
+		if pos != primary && !slices.Contains(others, pos) {
+			others = append(others, pos)
+		}
+	}
+	slices.SortFunc(others, func(a, b BufferPos) int {
+		if a.Before(b) {
+			return -1
+		}
+		if b.Before(a) {
+			return 1
+		}
+		return 0
+	})
+
+	f.cursors = others
+	f.moveCursorTo(primary)
+}
+
+func (f *FileEditor) ClearCursors() {
+	f.cursors = nil
+}
+
+/*
+Adds a cursor on the line below the lowest cursor, or above the highest
+one, in the same visual column as that cursor
+*/
+func (f *FileEditor) AddCursorVertical(down bool) {
+	cursors := f.allCursors()
+
+	edge := cursors[0]
+	for _, c := range cursors[1:] {
+		if (down && c.Line > edge.Line) || (!down && c.Line < edge.Line) {
+			edge = c
+		}
+	}
+
+	lines := -1
+	if down {
+		lines = 1
+	}
+	if edge.Line+lines < 0 || edge.Line+lines >= len(f.FileBuffer) {
+		return
+	}
+
+	f.setCursors(append(cursors, f.verticalMotion(edge, lines)))
+}
+
+/*
+Adds a cursor at every other occurrence of the word under the cursor, at the
+same place in the word as the cursor
+*/
+func (f *FileEditor) AddCursorsAtWord() {
+	pos := f.cursorPos()
+	line := f.FileBuffer[pos.Line]
+
+	isWord := func(lines []string, p BufferPos) bool {
+		return charClassAt(lines, p, f.WordChars) == charClassWord
+	}
+	if !isWord(f.FileBuffer, pos) {
+		f.statusMessage = "No word under the cursor"
+		return
+	}
+
+	start, end := pos.Index, pos.Index
+	for start > 0 && isWord(f.FileBuffer, BufferPos{pos.Line, start - 1}) {
+		start--
+	}
+	for end < len(line) && isWord(f.FileBuffer, BufferPos{pos.Line, end}) {
+		end++
+	}
+	word := line[start:end]
+
+	cursors := []BufferPos{pos}
+	for i, text := range f.FileBuffer {
+		for _, s := range FindWordOccurrences(text, word, f.WordChars) {
+			if i != pos.Line || s != start {
+				cursors = append(cursors, BufferPos{Line: i, Index: s + pos.Index - start})
+			}
+		}
+	}
+
+	f.setCursors(cursors)
+}
+
+/*
+Returns the start index of every occurrence of the word in the line that
+isn't part of a longer word
+*/
+func FindWordOccurrences(line string, word string, wordChars string) []int {
+	lines := []string{line}
+	isWord := func(i int) bool {
+		return i >= 0 && i < len(line) && charClassAt(lines, BufferPos{Index: i}, wordChars) == charClassWord
+	}
+
+	starts := make([]int, 0)
+	for i := 0; i+len(word) <= len(line); i++ {
+		if line[i:i+len(word)] == word && !isWord(i-1) && !isWord(i+len(word)) {
+			starts = append(starts, i)
+			i += len(word) - 1
+		}
+	}
+
+	return starts
+}
+
+/*
+Returns the position of the FileBuffer at the screen position,
+which is relative to the focused window
+*/
+func (f *FileEditor) bufferPosAtScreen(x int, y int) BufferPos {
+	f.RefreshVisualBuffers()
+
+	row := math.Clamp(y-1+f.ViewportOffsetY, 0, len(f.VisualBuffer)-1)
+	col := math.Max(x-EditorLeftMargin, 0)
+	if !f.SoftWrapEnabled {
+		col += f.ViewportOffsetX
+	}
+
+	return f.bufferPosAtVisual(row, col)
+}
+
+/*
+Starts a column of cursors where the mouse was pressed with Alt held down
+*/
+func (f *FileEditor) startBlockCursors(m MouseInput) byte {
+	w := f.windowAt(m.X, m.Y)
+	if w == nil {
+		return 0
+	}
+	f.FocusWindow(w)
+
+	pos := f.bufferPosAtScreen(m.X-f.EditorX, m.Y-f.EditorY)
+	f.blockAnchorLine = pos.Line
+	f.setCursors([]BufferPos{pos})
+
+	return EnumCursorPositionChange
+}
+
+/*
+Puts a cursor on every line from the line the mouse was pressed on to the line
+it is dragged to, in the column it is dragged to. The cursor is the one under the mouse
+*/
+func (f *FileEditor) dragBlockCursors(m MouseInput) byte {
+	pos := f.bufferPosAtScreen(m.X-f.EditorX, m.Y-f.EditorY)
+	column := f.GetVisualIndex(f.FileBuffer[pos.Line], pos.Index)
+
+	cursors := []BufferPos{pos}
+	first, last := math.Min(f.blockAnchorLine, pos.Line), math.Max(f.blockAnchorLine, pos.Line)
+	for line := first; line <= last && line < len(f.FileBuffer); line++ {
+		if line != pos.Line {
+			cursors = append(cursors, BufferPos{Line: line, Index: f.GetActualIndex(f.FileBuffer[line], column)})
+		}
+	}
+
+	f.setCursors(cursors)
+	return EnumCursorPositionChange
+}
+
+/*
+Returns the text that the tab key inserts at the position
+*/
+func (f FileEditor) tabTextAt(pos BufferPos) string {
+	if f.TabIndentType == IndentWithTab {
+		return string(Tab)
+	}
+
+	width := f.GetSpaceWidthOfTabChar(f.GetVisualIndex(f.FileBuffer[pos.Line], pos.Index))
+	text := make([]byte, width)
+	for i := range text {
+		text[i] = Space
+	}
+
+	return string(text)
+}
+
+/*
+Types the key at every cursor in Edit mode. Returns the flag that
+should be sent to the render loop, or 0 if the key isn't typed
+*/
+func (f *FileEditor) typeAtCursors(key byte) byte {
+	if key != NewLine && key != Backspace && key != Tab && !ansi.IsAlphaChar(key) {
+		return 0
+	}
+
+	f.pushTypingUndo()
+
+	pairs := FiletypeFor(f.Filename).Pairs
+	cursors := f.allCursors()
+	edits := make([]TextEdit, len(cursors))
+	offsets := make([]int, len(cursors)) // how far from the end of its edit each cursor goes
+	for i, pos := range cursors {
+		if e, offset, ok := PairEdit(f.FileBuffer[pos.Line], pos, key, pairs, f.WordChars); ok {
+			edits[i], offsets[i] = e, offset
+			continue
+		}
+
+		e := TextEdit{Start: pos, End: pos}
+
+		switch key {
+		case NewLine:
+			indent, _ := NewLineIndent(f.FileBuffer[pos.Line][:pos.Index], "", FiletypeFor(f.Filename), f.indentUnit())
+			e.Text = "\n" + indent
+		case Tab:
+			e.Text = f.tabTextAt(pos)
+		case Backspace:
+			if pos.Index > 0 {
+				e.Start.Index--
+			} else if pos.Line > 0 {
+				e.Start = BufferPos{Line: pos.Line - 1, Index: len(f.FileBuffer[pos.Line-1])}
+			}
+		default:
+			e.Text = string(key)
+		}
+
+		edits[i] = e
+	}
+
+	ends := f.ApplyEdits(edits)
+	for i := range ends {
+		ends[i].Index += offsets[i]
+	}
+
+	f.setCursors(ends)
+
+	// closers typed at the start of their line are dedented at every cursor
+	if ft := FiletypeFor(f.Filename); strings.IndexByte(ft.Closers, key) >= 0 {
+		for _, pos := range f.allCursors() {
+			if pos.Index == 0 {
+				continue
+			}
+			if line, ok := DedentClosingBracket(f.FileBuffer, BufferPos{Line: pos.Line, Index: pos.Index - 1}, ft); ok {
+				f.reindentLines(pos.Line, []string{line})
+			}
+		}
+	}
+
+	return EnumKeyboardInput
+}
+
+/*
+Draws the secondary cursors that are in the viewport
+*/
+func (f *FileEditor) PrintSecondaryCursors() {
+	if len(f.cursors) == 0 {
+		return
+	}
+
+	for _, c := range f.allCursors()[1:] {
+		f.printCellOverlay(c, secondaryCursorColor)
+	}
+}
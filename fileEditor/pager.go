@@ -0,0 +1,179 @@
+package fileeditor
+
+import (
+	"regexp"
+
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for View mode, which works like a pager: the buffer
+can be scrolled and searched, but not edited. The keys are:
+
+	space, f        page down               b       page up
+	d               half a page down        u       half a page up
+	j, enter        one line down           k       one line up
+	g               top of the file         G       bottom of the file
+	/               search with a regex     n, N    next and previous match
+	F               follow the file as it grows (see follow.go)
+	q               quit
+
+When the editor is launched with -R, or as view, every file is opened
+read-only, and the buffers can't be modified in any mode
+*/
+
+/*
+Returns true if the buffer can be modified. Otherwise, tells the user why not
+*/
+func (f *FileEditor) writable() bool {
+	if f.ReadOnly {
+		f.statusMessage = "The files were opened read-only"
+		return false
+	}
+
+	return true
+}
+
+/*
+Handles a key typed in View mode. Returns the flag that should be sent to the render loop
+*/
+func (f *FileEditor) handleViewModeKey(key byte) byte {
+	height := f.GetViewportHeight()
+
+	switch key {
+	case Space, 'f':
+		f.scrollView(height)
+	case 'b':
+		f.scrollView(-height)
+	case 'd':
+		f.scrollView(height / 2)
+	case 'u':
+		f.scrollView(-height / 2)
+	case 'j', NewLine:
+		f.scrollView(1)
+	case 'k':
+		f.scrollView(-1)
+	case 'g':
+		f.pushJump()
+		f.MoveCursorToBufferPos(0, 0)
+	case 'G':
+		f.pushJump()
+		f.MoveCursorToBufferPos(len(f.FileBuffer)-1, 0)
+	case '/':
+		f.OpenSearchPrompt()
+	case 'n':
+		f.SearchNext(true)
+	case 'N':
+		f.SearchNext(false)
+	case 'F':
+		f.StartFollow()
+	case 'q':
+		return f.RunAction(ActionQuit)
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Scrolls the viewport by the number of rows, and places the cursor
+at the start of the top row, like a pager
+*/
+func (f *FileEditor) scrollView(rows int) {
+	maxOffset := math.Max(len(f.VisualBuffer)-f.GetViewportHeight(), 0)
+
+	f.ViewportOffsetY = math.Clamp(f.ViewportOffsetY+rows, 0, maxOffset)
+	f.ViewportOffsetX = 0
+	f.apparentCursorY = 1
+	f.apparentCursorX = EditorLeftMargin
+	setSavedCursorX(f.apparentCursorX, f.ViewportOffsetX, false)
+}
+
+/*
+Asks for a regex and moves the cursor to its first match after the cursor
+*/
+func (f *FileEditor) OpenSearchPrompt() {
+	f.OpenPrompt("Search:", "", func(f *FileEditor, input string) {
+		if input == "" {
+			return
+		}
+
+		re, err := CompileSearchPattern(input, false)
+		if err != nil {
+			f.statusMessage = err.Error()
+			return
+		}
+
+		f.lastSearch = re
+		f.SearchNext(true)
+	})
+}
+
+/*
+Moves the cursor to the next match of the last search, or the previous one
+*/
+func (f *FileEditor) SearchNext(forward bool) {
+	if f.lastSearch == nil {
+		f.statusMessage = "No previous search"
+		return
+	}
+
+	line, index := f.CursorBufferPos()
+	pos, ok := SearchLines(f.FileBuffer, f.lastSearch, BufferPos{Line: line, Index: index}, forward)
+	if !ok {
+		f.statusMessage = "Pattern not found: " + f.lastSearch.String()
+		return
+	}
+
+	f.pushJump()
+	f.MoveCursorToBufferPos(pos.Line, pos.Index)
+}
+
+/*
+Returns the start of the first match of the regex after the position, or of
+the last match before it when forward is false. The search wraps around the
+ends of the lines, back to the line of the position
+*/
+func SearchLines(lines []string, re *regexp.Regexp, from BufferPos, forward bool) (BufferPos, bool) {
+	n := len(lines)
+
+	for i := 0; i <= n; i++ {
+		line := (from.Line + i) % n
+		if !forward {
+			line = (from.Line - i%n + n) % n
+		}
+
+		matches := re.FindAllStringIndex(lines[line], -1)
+
+		// on the line of the position, only the matches on the searched side of it count,
+		// until the search has wrapped around to it
+		accept := func(start int) bool {
+			switch {
+			case i == 0 && forward:
+				return start > from.Index
+			case i == 0:
+				return start < from.Index
+			case i == n && forward:
+				return start <= from.Index
+			case i == n:
+				return start >= from.Index
+			}
+			return true
+		}
+
+		if forward {
+			for _, m := range matches {
+				if accept(m[0]) {
+					return BufferPos{Line: line, Index: m[0]}, true
+				}
+			}
+		} else {
+			for j := len(matches) - 1; j >= 0; j-- {
+				if accept(matches[j][0]) {
+					return BufferPos{Line: line, Index: matches[j][0]}, true
+				}
+			}
+		}
+	}
+
+	return from, false
+}
@@ -0,0 +1,226 @@
+package fileeditor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+)
+
+/*
+This file is responsible for pairs of brackets and quotes.
+
+In Edit mode, typing an opener of a pair inserts its closer after the cursor,
+unless the cursor is right before a word. Typing a closer that is already
+after the cursor moves over it instead, and backspace between an opener and
+its closer deletes both. Quotes are not paired after a word, where they are
+more likely an apostrophe. The pairs depend on the filetype (see filetype.go).
+
+When the cursor is on a bracket, the bracket that matches it is highlighted.
+Brackets inside of strings and comments are skipped when looking for the
+match, for the filetypes whose comments and strings are known
+*/
+
+var matchingBracketColor string = ansi.NewRGBColor(90, 90, 90).ToBgColorANSI()
+
+/*
+Returns the pair that the character is the opener or closer of
+in the pairs, and whether it is the opener
+*/
+func findPair(pairs string, c byte) (open byte, close byte, opener bool, ok bool) {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i] == c {
+			return pairs[i], pairs[i+1], true, true
+		} else if pairs[i+1] == c {
+			return pairs[i], pairs[i+1], false, true
+		}
+	}
+
+	return 0, 0, false, false
+}
+
+/*
+Returns the edit that typing the key at the position makes because of the
+pairs, and how far from the end of the edit the cursor goes. Returns false
+if the key is typed as usual
+*/
+func PairEdit(line string, pos BufferPos, key byte, pairs string, wordChars string) (TextEdit, int, bool) {
+	edit := TextEdit{Start: pos, End: pos}
+	before, after := byte(0), byte(0)
+	if pos.Index > 0 {
+		before = line[pos.Index-1]
+	}
+	if pos.Index < len(line) {
+		after = line[pos.Index]
+	}
+
+	isWord := func(c byte) bool {
+		return c != 0 && charClassAt([]string{string(c)}, BufferPos{}, wordChars) == charClassWord
+	}
+
+	if key == Backspace {
+		_, close, opener, ok := findPair(pairs, before)
+		if !ok || !opener || after != close {
+			return edit, 0, false
+		}
+		edit.Start.Index--
+		edit.End.Index++
+		return edit, 0, true
+	}
+
+	open, close, opener, ok := findPair(pairs, key)
+	if !ok {
+		return edit, 0, false
+	}
+
+	// typing the closer that is after the cursor moves over it
+	if key == close && after == close {
+		return edit, 1, true
+	}
+	if !opener {
+		return edit, 0, false
+	}
+
+	if isWord(after) || (open == close && isWord(before)) {
+		return edit, 0, false
+	}
+
+	edit.Text = string([]byte{open, close})
+	return edit, -1, true
+}
+
+/*
+Returns true if typing the key at the cursor is affected by the pairs
+*/
+func (f FileEditor) typesPair(key byte) bool {
+	pos := f.cursorPos()
+	_, _, ok := PairEdit(f.FileBuffer[pos.Line], pos, key, FiletypeFor(f.Filename).Pairs, f.WordChars)
+	return ok
+}
+
+/*
+Returns a mask of the text that is true for every byte that is code,
+and false for the bytes of strings and comments, quotes included
+*/
+func codeMask(text string, ft Filetype) []bool {
+	mask := make([]bool, len(text))
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		end := i + 1
+
+		switch {
+		case ft.LineComment != "" && strings.HasPrefix(rest, ft.LineComment):
+			end = i + len(rest)
+			if n := strings.IndexByte(rest, '\n'); n >= 0 {
+				end = i + n
+			}
+		case ft.BlockComment[0] != "" && strings.HasPrefix(rest, ft.BlockComment[0]):
+			end = i + len(rest)
+			if n := strings.Index(rest[len(ft.BlockComment[0]):], ft.BlockComment[1]); n >= 0 {
+				end = i + len(ft.BlockComment[0]) + n + len(ft.BlockComment[1])
+			}
+		case strings.IndexByte(ft.Quotes, text[i]) >= 0:
+			// a string ends at its quote, or at the end of the line unless it is quoted with backticks
+			quote := text[i]
+			for end < len(text) && (text[end] != quote || isEscaped(text, end)) && (text[end] != '\n' || quote == '`') {
+				end++
+			}
+			if end < len(text) && text[end] == quote {
+				end++
+			}
+		default:
+			mask[i] = true
+		}
+
+		i = end
+	}
+
+	return mask
+}
+
+/*
+Returns the position of the bracket that matches the bracket at the position,
+skipping the brackets inside of strings and comments. Returns false if the
+position isn't on a bracket of code, or the bracket isn't matched
+*/
+func MatchingBracketInCode(lines []string, pos BufferPos, ft Filetype) (BufferPos, bool) {
+	const brackets = "([{)]}"
+
+	line := lines[pos.Line]
+	if pos.Index >= len(line) || strings.IndexByte(brackets, line[pos.Index]) < 0 {
+		return pos, false
+	}
+
+	text, starts := joinLines(lines)
+	mask := codeMask(text, ft)
+	offset := starts[pos.Line] + pos.Index
+	if !mask[offset] {
+		return pos, false
+	}
+
+	k := strings.IndexByte(brackets, text[offset])
+	open, close := brackets[k%3], brackets[k%3+3]
+	step := 1
+	if k >= 3 {
+		step = -1
+		open, close = close, open
+	}
+
+	depth := 0
+	for j := offset; j >= 0 && j < len(text); j += step {
+		if !mask[j] {
+			continue
+		}
+
+		switch text[j] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return offsetToPos(starts, j), true
+			}
+		}
+	}
+
+	return pos, false
+}
+
+/*
+Highlights the bracket that matches the bracket under the cursor, if it is in the viewport
+*/
+func (f *FileEditor) printMatchingBracket() {
+	pos := f.cursorPos()
+	line := f.FileBuffer[pos.Line]
+	if pos.Index >= len(line) || strings.IndexByte("()[]{}", line[pos.Index]) < 0 {
+		return
+	}
+
+	if match, ok := MatchingBracketInCode(f.FileBuffer, pos, FiletypeFor(f.Filename)); ok {
+		f.printCellOverlay(match, matchingBracketColor)
+	}
+}
+
+/*
+Draws the character at the position with the color, if it is in the viewport
+*/
+func (f *FileEditor) printCellOverlay(pos BufferPos, color string) {
+	row, col := f.visualRowCol(pos)
+
+	char := " "
+	if col < len(f.VisualBuffer[row]) {
+		char = string(f.VisualBuffer[row][col])
+	}
+
+	if !f.SoftWrapEnabled {
+		col -= f.ViewportOffsetX
+	}
+	if row < f.ViewportOffsetY || row >= f.ViewportOffsetY+f.GetViewportHeight() ||
+		col < 0 || col >= f.GetViewportWidth() {
+		return
+	}
+
+	ansi.MoveCursor(f.EditorY+row-f.ViewportOffsetY+1, f.EditorX+EditorLeftMargin+col)
+	fmt.Print(color + char + Reset)
+}
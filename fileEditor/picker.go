@@ -0,0 +1,364 @@
+package fileeditor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Asiandayboy/CLITextEditor/render"
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/fuzzy"
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for the picker, a popup that lists items, lets the
+user filter them by typing, and runs a callback with the item that is chosen.
+
+While a picker is open, it receives all of the keyboard input:
+  - typing filters the items with fuzzy matching, best matches first
+  - the up/down arrow keys move the selection
+  - enter chooses the selected item and closes the picker
+  - escape closes the picker
+
+Items can be added while the picker is open, for pickers whose items are
+found in the background, so the items are guarded by a mutex
+*/
+
+const pickerMaxHeight int = 20
+const pickerPrefix string = "> "
+
+var pickerSelectedColor string = ansi.NewRGBColor(50, 50, 50).ToBgColorANSI()
+var pickerDetailColor string = ansi.NewRGBColor(110, 110, 110).ToFgColorANSI()
+var pickerMatchColor string = ansi.NewRGBColor(255, 200, 60).ToFgColorANSI()
+
+type PickerItem struct {
+	Label  string
+	Detail string // shown greyed out after the label
+	Value  any
+}
+
+type pickerMatch struct {
+	index     int // index of the item
+	score     int
+	positions []int // indicies of the matched characters in the label
+}
+
+type Picker struct {
+	Title    string
+	Items    []PickerItem
+	OnSelect func(f *FileEditor, item PickerItem) byte // returns the flag to send to the render loop, or 0 for the default one
+	OnClose  func()                                    // called when the picker is closed, whether an item was chosen or not
+	Loading  bool                                      // true while items are still being added
+
+	Query    string
+	filtered []pickerMatch // the items that match the query, ranked
+	selected int           // index in filtered
+	scroll   int
+
+	mu sync.Mutex
+}
+
+func NewPicker(title string, items []PickerItem, onSelect func(f *FileEditor, item PickerItem) byte) *Picker {
+	p := &Picker{
+		Title:    title,
+		Items:    items,
+		OnSelect: onSelect,
+	}
+	p.filter()
+
+	return p
+}
+
+/*
+Keeps the items whose label fuzzy matches the query, and ranks them by their
+score. Ties keep the order of the items, shorter labels first
+*/
+func (p *Picker) filter() {
+	p.filtered = p.matchItems(0)
+	p.selected = 0
+	p.scroll = 0
+}
+
+/*
+Returns the ranked matches of the items from the index on
+*/
+func (p *Picker) matchItems(from int) []pickerMatch {
+	matches := make([]pickerMatch, 0, len(p.Items)-from)
+
+	for i := from; i < len(p.Items); i++ {
+		if score, positions, ok := fuzzy.Match(p.Query, p.Items[i].Label); ok {
+			matches = append(matches, pickerMatch{index: i, score: score, positions: positions})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return p.ranksBefore(matches[i], matches[j])
+	})
+
+	return matches
+}
+
+/*
+Returns true if the match goes before the other one. Without
+a query, the items are kept in the order they were added
+*/
+func (p *Picker) ranksBefore(a, b pickerMatch) bool {
+	if p.Query == "" {
+		return false
+	}
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	return len(p.Items[a.index].Label) < len(p.Items[b.index].Label)
+}
+
+/*
+Adds items to the picker while it's open, keeping the selected item selected.
+Only the new items are matched, and merged into the ranked matches
+*/
+func (p *Picker) AddItems(items []PickerItem) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	selected := -1
+	if len(p.filtered) > 0 {
+		selected = p.filtered[p.selected].index
+	}
+
+	from := len(p.Items)
+	p.Items = append(p.Items, items...)
+	added := p.matchItems(from)
+
+	// the new items go after the old ones that they tie with
+	merged := make([]pickerMatch, 0, len(p.filtered)+len(added))
+	i, j := 0, 0
+	for i < len(p.filtered) || j < len(added) {
+		if j < len(added) && (i == len(p.filtered) || p.ranksBefore(added[j], p.filtered[i])) {
+			merged = append(merged, added[j])
+			j++
+		} else {
+			merged = append(merged, p.filtered[i])
+			i++
+		}
+	}
+	p.filtered = merged
+
+	for i, match := range p.filtered {
+		if match.index == selected {
+			p.selected = i
+			break
+		}
+	}
+}
+
+func (p *Picker) SetLoading(loading bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Loading = loading
+}
+
+/*
+Returns the item that is currently selected, and false if no item matches the query
+*/
+func (p *Picker) Selected() (PickerItem, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.filtered) == 0 {
+		return PickerItem{}, false
+	}
+
+	return p.Items[p.filtered[p.selected].index], true
+}
+
+func (p *Picker) moveSelection(amount int, visibleRows int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.filtered) == 0 {
+		return
+	}
+
+	p.selected = math.Clamp(p.selected+amount, 0, len(p.filtered)-1)
+
+	if p.selected < p.scroll {
+		p.scroll = p.selected
+	} else if p.selected >= p.scroll+visibleRows {
+		p.scroll = p.selected - visibleRows + 1
+	}
+}
+
+func (f *FileEditor) OpenPicker(p *Picker) {
+	f.picker = p
+}
+
+func (f *FileEditor) ClosePicker() {
+	if f.picker.OnClose != nil {
+		f.picker.OnClose()
+	}
+	f.picker = nil
+	ansi.ClearEntireScreen()
+}
+
+/*
+Returns the size and position of the picker popup, centered over the windows
+*/
+func (f FileEditor) pickerBox() (width, height, x, y int) {
+	width = math.Clamp(f.TermWidth*3/5, 20, f.TermWidth)
+	area := f.layoutArea()
+	height = math.Min(pickerMaxHeight, area.Height-2)
+	x = (area.Width - width) / 2
+	y = (area.Height - height) / 2
+
+	return width, height, x, y
+}
+
+/*
+Returns the number of items that fit in the picker; the borders
+and the query line take up the rest of the rows
+*/
+func (f FileEditor) pickerVisibleRows() int {
+	_, height, _, _ := f.pickerBox()
+	return math.Max(height-3, 1)
+}
+
+/*
+Handles the keys pressed while the picker is open. Returns the
+flag that should be sent to the render loop
+*/
+func (f *FileEditor) handlePickerInput(key byte) byte {
+	p := f.picker
+
+	switch {
+	case key == NewLine:
+		item, ok := p.Selected()
+		f.ClosePicker()
+		if ok && p.OnSelect != nil {
+			if flag := p.OnSelect(f, item); flag != 0 {
+				return flag
+			}
+		}
+	case key == Backspace:
+		p.mu.Lock()
+		if len(p.Query) > 0 {
+			p.Query = p.Query[:len(p.Query)-1]
+			p.filter()
+		}
+		p.mu.Unlock()
+	case ansi.IsAlphaChar(key):
+		p.mu.Lock()
+		p.Query += string(key)
+		p.filter()
+		p.mu.Unlock()
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Handles the escape sequences received while the picker is open
+*/
+func (f *FileEditor) handlePickerEscapeInput(buf []byte, n int) byte {
+	if n == 1 {
+		f.ClosePicker()
+		return EnumCursorPositionChange
+	}
+
+	if n == 3 && buf[2] == UpArrowKey {
+		f.picker.moveSelection(-1, f.pickerVisibleRows())
+	} else if n == 3 && buf[2] == DownArrowKey {
+		f.picker.moveSelection(1, f.pickerVisibleRows())
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Draws the picker popup over the editor
+*/
+func (f FileEditor) PrintPicker() {
+	p := f.picker
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	width, height, x, y := f.pickerBox()
+	innerWidth := width - 4
+	colorRGB := modeColors[f.EditorMode]
+	color := colorRGB.ToFgColorANSI()
+
+	render.DrawBox(render.Box{
+		Width: width, Height: height,
+		X: x, Y: y,
+		BorderColor: colorRGB,
+	}, true)
+
+	loading := ""
+	if p.Loading {
+		loading = " ..."
+	}
+
+	ansi.MoveCursor(y+1, x+3)
+	fmt.Printf("%s %s (%d/%d%s) %s", color, p.Title, len(p.filtered), len(p.Items), loading, Reset)
+
+	ansi.MoveCursor(y+2, x+3)
+	fmt.Printf("%s%s%s%-*s", color, pickerPrefix, Reset, innerWidth-len(pickerPrefix), p.Query)
+
+	visibleRows := f.pickerVisibleRows()
+	for r := 0; r < visibleRows; r++ {
+		ansi.MoveCursor(y+3+r, x+3)
+
+		i := r + p.scroll
+		if i >= len(p.filtered) {
+			fmt.Printf("%*s", innerWidth, "")
+			continue
+		}
+
+		match := p.filtered[i]
+		item := p.Items[match.index]
+		label := item.Label
+		detail := ""
+		if item.Detail != "" {
+			detail = "  " + item.Detail
+		}
+		if len(label) > innerWidth {
+			label = label[:innerWidth]
+		}
+		if len(label)+len(detail) > innerWidth {
+			detail = detail[:innerWidth-len(label)]
+		}
+
+		var bg string
+		if i == p.selected {
+			bg = pickerSelectedColor
+		}
+		fmt.Print(bg + highlightMatches(label, match.positions, bg) + bg)
+		fmt.Printf("%s%s%-*s%s", pickerDetailColor, Italic, innerWidth-len(label), detail, Reset)
+	}
+
+	// place the cursor at the end of the query
+	ansi.MoveCursor(y+2, x+3+len(pickerPrefix)+len(p.Query))
+}
+
+/*
+Returns the label with the characters at the positions colored, keeping
+the background color around them. Positions past the end are ignored
+*/
+func highlightMatches(label string, positions []int, bg string) string {
+	if len(positions) == 0 {
+		return label
+	}
+
+	var out string
+	last := 0
+	for _, pos := range positions {
+		if pos >= len(label) {
+			break
+		}
+		out += label[last:pos] + pickerMatchColor + label[pos:pos+1] + Reset + bg
+		last = pos + 1
+	}
+
+	return out + label[last:]
+}
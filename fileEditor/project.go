@@ -0,0 +1,76 @@
+package fileeditor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+/*
+This file is responsible for walking the files of the project, which is the
+working directory, for the features that look through every file of it
+*/
+
+// the number of bytes read from the start of a file to decide if it's binary
+const binarySniffLength int = 8000
+
+/*
+Returns true if the file looks binary, which is when its first bytes
+contain a NUL byte, the same heuristic git uses. Files that
+cannot be read are treated as binary
+*/
+func IsBinaryFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer file.Close()
+
+	buf := make([]byte, binarySniffLength)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return true
+	}
+
+	return bytes.IndexByte(buf[:n], 0) >= 0
+}
+
+/*
+Calls fn with the path, relative to the root, of every text file of the
+project that isn't ignored. The walk stops early when the context is
+cancelled, in which case the error of the context is returned
+*/
+func WalkProjectFiles(ctx context.Context, root string, fn func(relPath string)) error {
+	ignore := LoadIgnoreMatcher(root)
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || path == root {
+			return nil // unreadable entries are skipped
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+
+		if ignore.IsIgnored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() || !d.Type().IsRegular() || IsBinaryFile(path) {
+			return nil
+		}
+
+		fn(rel)
+		return nil
+	})
+}
@@ -0,0 +1,103 @@
+package fileeditor
+
+import (
+	"fmt"
+
+	"github.com/Asiandayboy/CLITextEditor/render"
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for prompts, which ask the user for a line of input
+(or a confirmation) in a popup drawn like the command bar.
+
+While a prompt is open, it receives all of the keyboard input. Enter submits
+the input to the callback of the prompt, and escape cancels the prompt
+*/
+
+type Prompt struct {
+	Message  string
+	Input    string
+	OnSubmit func(f *FileEditor, input string)
+}
+
+func (f *FileEditor) OpenPrompt(message string, input string, onSubmit func(f *FileEditor, input string)) {
+	f.prompt = &Prompt{Message: message, Input: input, OnSubmit: onSubmit}
+}
+
+/*
+Opens a prompt that only runs the callback if the user answers yes
+*/
+func (f *FileEditor) OpenConfirmPrompt(message string, onConfirm func(f *FileEditor)) {
+	f.OpenPrompt(message+" (y/n)", "", func(f *FileEditor, input string) {
+		if input == "y" || input == "Y" || input == "yes" {
+			onConfirm(f)
+		}
+	})
+}
+
+func (f *FileEditor) ClosePrompt() {
+	f.prompt = nil
+	ansi.ClearEntireScreen()
+}
+
+/*
+Handles the keys pressed while the prompt is open. Returns the
+flag that should be sent to the render loop
+*/
+func (f *FileEditor) handlePromptInput(key byte) byte {
+	p := f.prompt
+
+	switch {
+	case key == NewLine:
+		f.ClosePrompt()
+		if p.OnSubmit != nil {
+			p.OnSubmit(f, p.Input)
+		}
+	case key == Backspace:
+		if len(p.Input) > 0 {
+			p.Input = p.Input[:len(p.Input)-1]
+		}
+	case ansi.IsAlphaChar(key):
+		p.Input += string(key)
+	}
+
+	return EnumCursorPositionChange
+}
+
+/*
+Returns the size and position of the prompt, centered over the windows
+*/
+func (f FileEditor) promptBox() (width, x, y int) {
+	area := f.layoutArea()
+	width = math.Clamp(len(f.prompt.Message)+len(f.prompt.Input)+10, 40, f.TermWidth)
+	x = (f.TermWidth - width) / 2
+	y = area.Height/2 - 1
+
+	return width, x, y
+}
+
+/*
+Draws the prompt over the editor and places the cursor at the end of the input
+*/
+func (f FileEditor) PrintPrompt() {
+	p := f.prompt
+	width, x, y := f.promptBox()
+	colorRGB := modeColors[f.EditorMode]
+
+	render.DrawBox(render.Box{
+		Width: width, Height: 3,
+		X: x, Y: y,
+		BorderColor: colorRGB,
+	}, true)
+
+	text := p.Message + " " + p.Input
+	if len(text) > width-4 {
+		text = text[len(text)-(width-4):]
+	}
+
+	ansi.MoveCursor(y+2, x+3)
+	fmt.Printf("%s%-*s%s", colorRGB.ToFgColorANSI(), width-4, text, Reset)
+	ansi.MoveCursor(y+2, x+3+len(text))
+}
@@ -0,0 +1,472 @@
+package fileeditor
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for text objects, which select a piece of text around
+a position, like the word the cursor is on, or the inside of the parentheses
+the cursor is in. In Command mode, a text object is typed after an operator,
+with i for the inside of the object or a for all of it, like di( or ca".
+
+The objects are:
+  - w and W, a word, or a WORD, which is anything between whitespace
+  - s, a sentence, which ends with ., ! or ? followed by whitespace
+  - p, a paragraph, which is a run of lines that are all blank or all not blank
+  - ", ' and `, a quoted string on the line, where escaped quotes don't count
+  - ( ) b, [ ], { } B and < >, the text between a pair of brackets, which can
+    span lines. Brackets are matched with nesting, and escaped brackets don't count
+  - t, the text between an opening and a closing tag, like <div> and </div>
+
+The around version of words, sentences and quotes includes the whitespace after
+the object, or before it when there is none after it, and the around version of
+a paragraph includes the blank lines after it.
+
+Objects that span lines are resolved over the lines joined with new lines, so
+the resolvers work with offsets into that text
+*/
+
+type TextRange struct {
+	Start    BufferPos
+	End      BufferPos // exclusive
+	Linewise bool      // true if the range is made of whole lines
+}
+
+/*
+Returns the range of the text object around the position, or false if the
+position isn't in such an object. A count selects that many objects, or the
+object that encloses the count-1 objects around the position for brackets and tags
+*/
+func ResolveTextObject(lines []string, pos BufferPos, object byte, around bool, count int, wordChars string) (TextRange, bool) {
+	switch object {
+	case 'w', 'W':
+		return wordObject(lines, pos, object == 'W', around, count, wordChars)
+	case 'p':
+		return paragraphObject(lines, pos, around, count)
+	case '"', '\'', '`':
+		return quoteObject(lines, pos, object, around)
+	}
+
+	text, starts := joinLines(lines)
+	offset := starts[pos.Line] + pos.Index
+
+	var start, end int
+	var ok bool
+
+	switch object {
+	case 's':
+		start, end, ok = sentenceObject(text, offset, around, count)
+	case '(', ')', 'b':
+		start, end, ok = bracketObject(text, offset, '(', ')', around, count)
+	case '[', ']':
+		start, end, ok = bracketObject(text, offset, '[', ']', around, count)
+	case '{', '}', 'B':
+		start, end, ok = bracketObject(text, offset, '{', '}', around, count)
+	case '<', '>':
+		start, end, ok = bracketObject(text, offset, '<', '>', around, count)
+	case 't':
+		start, end, ok = tagObject(text, offset, around, count)
+	}
+
+	if !ok {
+		return TextRange{}, false
+	}
+
+	return TextRange{Start: offsetToPos(starts, start), End: offsetToPos(starts, end)}, true
+}
+
+/*
+Joins the lines with new lines, and returns the offset of the start of each line
+*/
+func joinLines(lines []string) (string, []int) {
+	starts := make([]int, len(lines))
+	offset := 0
+	for i, line := range lines {
+		starts[i] = offset
+		offset += len(line) + 1
+	}
+
+	return strings.Join(lines, "\n"), starts
+}
+
+func offsetToPos(starts []int, offset int) BufferPos {
+	line := len(starts) - 1
+	for line > 0 && starts[line] > offset {
+		line--
+	}
+
+	return BufferPos{Line: line, Index: offset - starts[line]}
+}
+
+func isBlank(c byte) bool {
+	return c == Space || c == Tab
+}
+
+/*
+Returns true if the character at the index is escaped
+by an odd number of backslashes before it
+*/
+func isEscaped(s string, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		n++
+	}
+
+	return n%2 == 1
+}
+
+func wordObject(lines []string, pos BufferPos, bigWord bool, around bool, count int, wordChars string) (TextRange, bool) {
+	line := lines[pos.Line]
+	if len(line) == 0 {
+		return TextRange{}, false
+	}
+
+	class := func(i int) byte {
+		c := charClassAt(lines, BufferPos{Line: pos.Line, Index: i}, wordChars)
+		if bigWord && c == charClassPunct {
+			return charClassWord
+		}
+		return c
+	}
+	runEnd := func(i int) int {
+		c := class(i)
+		for i < len(line) && class(i) == c {
+			i++
+		}
+		return i
+	}
+
+	index := math.Min(pos.Index, len(line)-1)
+	start := index
+	for start > 0 && class(start-1) == class(index) {
+		start--
+	}
+
+	end := start
+	switch {
+	case !around:
+		for i := 0; i < count && end < len(line); i++ {
+			end = runEnd(end)
+		}
+	case class(index) == charClassSpace:
+		// the whitespace and the word after it
+		for i := 0; i < count && end < len(line); i++ {
+			end = runEnd(end)
+			if end < len(line) {
+				end = runEnd(end)
+			}
+		}
+	default:
+		for i := 0; i < count && end < len(line); i++ {
+			end = runEnd(end)
+			if end < len(line) && class(end) == charClassSpace {
+				end = runEnd(end)
+			}
+		}
+
+		// without whitespace after the words, the whitespace before them is included
+		if class(end-1) != charClassSpace {
+			for start > 0 && class(start-1) == charClassSpace {
+				start--
+			}
+		}
+	}
+
+	return TextRange{Start: BufferPos{pos.Line, start}, End: BufferPos{pos.Line, end}}, true
+}
+
+func paragraphObject(lines []string, pos BufferPos, around bool, count int) (TextRange, bool) {
+	blank := func(i int) bool {
+		return strings.TrimSpace(lines[i]) == ""
+	}
+	runEnd := func(i int) int {
+		b := blank(i)
+		for i < len(lines)-1 && blank(i+1) == b {
+			i++
+		}
+		return i
+	}
+
+	start := pos.Line
+	for start > 0 && blank(start-1) == blank(pos.Line) {
+		start--
+	}
+
+	end := start - 1
+	for i := 0; i < count && end < len(lines)-1; i++ {
+		end = runEnd(end + 1)
+		if around && end < len(lines)-1 {
+			end = runEnd(end + 1)
+		}
+	}
+
+	// without blank lines after the paragraphs, the blank lines before them are included
+	if around && !blank(end) && !blank(start) {
+		for start > 0 && blank(start-1) {
+			start--
+		}
+	}
+
+	return TextRange{
+		Start:    BufferPos{Line: start},
+		End:      BufferPos{Line: end, Index: len(lines[end])},
+		Linewise: true,
+	}, true
+}
+
+func quoteObject(lines []string, pos BufferPos, quote byte, around bool) (TextRange, bool) {
+	line := lines[pos.Line]
+
+	quotes := make([]int, 0)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++ // skip the escaped character
+		} else if line[i] == quote {
+			quotes = append(quotes, i)
+		}
+	}
+
+	// quotes are paired from the start of the line, and when the position
+	// isn't inside of a pair, the first pair after it is used
+	open, close := -1, -1
+	for i := 0; i+1 < len(quotes); i += 2 {
+		if pos.Index <= quotes[i+1] {
+			open, close = quotes[i], quotes[i+1]
+			break
+		}
+	}
+	if open < 0 {
+		return TextRange{}, false
+	}
+
+	start, end := open+1, close
+	if around {
+		start, end = open, close+1
+
+		if end < len(line) && isBlank(line[end]) {
+			for end < len(line) && isBlank(line[end]) {
+				end++
+			}
+		} else {
+			for start > 0 && isBlank(line[start-1]) {
+				start--
+			}
+		}
+	}
+
+	return TextRange{Start: BufferPos{pos.Line, start}, End: BufferPos{pos.Line, end}}, true
+}
+
+/*
+Splits the text into sentences, and returns the start and end offset of each.
+A sentence ends after ., ! or ? and any closing brackets or quotes after it,
+when they are followed by whitespace. An empty line ends a sentence as well
+*/
+func sentenceSpans(text string) [][2]int {
+	isSpace := func(c byte) bool { return isBlank(c) || c == '\n' }
+
+	spans := make([][2]int, 0)
+	for i := 0; i < len(text); {
+		for i < len(text) && isSpace(text[i]) {
+			i++
+		}
+		if i >= len(text) {
+			break
+		}
+
+		start := i
+		for i < len(text) {
+			c := text[i]
+			if c == '\n' && i+1 < len(text) && text[i+1] == '\n' {
+				break
+			}
+
+			i++
+			if c == '.' || c == '!' || c == '?' {
+				for i < len(text) && strings.IndexByte(")]\"'", text[i]) >= 0 {
+					i++
+				}
+				if i >= len(text) || isSpace(text[i]) {
+					break
+				}
+			}
+		}
+
+		end := i
+		for end > start && isSpace(text[end-1]) {
+			end--
+		}
+		spans = append(spans, [2]int{start, end})
+	}
+
+	return spans
+}
+
+func sentenceObject(text string, offset int, around bool, count int) (int, int, bool) {
+	spans := sentenceSpans(text)
+
+	k := 0
+	for k < len(spans) && spans[k][1] <= offset {
+		k++
+	}
+	if k >= len(spans) {
+		return 0, 0, false
+	}
+
+	// between sentences, the whitespace is the inner object, and the whitespace
+	// along with the sentence after it is the around object
+	if offset < spans[k][0] {
+		gapStart := 0
+		if k > 0 {
+			gapStart = spans[k-1][1]
+		}
+		if !around {
+			return gapStart, spans[k][0], true
+		}
+		return gapStart, spans[math.Min(k+count-1, len(spans)-1)][1], true
+	}
+
+	start, end := spans[k][0], spans[math.Min(k+count-1, len(spans)-1)][1]
+	if around {
+		if end < len(text) && isBlank(text[end]) {
+			for end < len(text) && isBlank(text[end]) {
+				end++
+			}
+		} else {
+			for start > 0 && isBlank(text[start-1]) {
+				start--
+			}
+		}
+	}
+
+	return start, end, true
+}
+
+func bracketObject(text string, offset int, open byte, close byte, around bool, count int) (int, int, bool) {
+	unescaped := func(i int, c byte) bool {
+		return text[i] == c && !isEscaped(text, i)
+	}
+
+	// find the count-th unmatched opening bracket before the offset,
+	// where a bracket under the offset is the first one
+	openIndex := -1
+	found, depth := 0, 0
+	if offset < len(text) && unescaped(offset, open) {
+		found = 1
+		if count == 1 {
+			openIndex = offset
+		}
+	}
+
+	for i := offset - 1; i >= 0 && openIndex < 0; i-- {
+		if unescaped(i, close) {
+			depth++
+		} else if unescaped(i, open) {
+			if depth > 0 {
+				depth--
+				continue
+			}
+			found++
+			if found == count {
+				openIndex = i
+			}
+		}
+	}
+	if openIndex < 0 {
+		return 0, 0, false
+	}
+
+	closeIndex := -1
+	depth = 0
+	for i := openIndex + 1; i < len(text) && closeIndex < 0; i++ {
+		if unescaped(i, open) {
+			depth++
+		} else if unescaped(i, close) {
+			if depth == 0 {
+				closeIndex = i
+			}
+			depth--
+		}
+	}
+	if closeIndex < 0 {
+		return 0, 0, false
+	}
+
+	if around {
+		return openIndex, closeIndex + 1, true
+	}
+
+	// when the brackets are on lines of their own, the inner object is the lines between them
+	start, end := openIndex+1, closeIndex
+	if start < len(text) && text[start] == '\n' {
+		start++
+
+		k := closeIndex - 1
+		for k >= start && isBlank(text[k]) {
+			k--
+		}
+		if k >= start && text[k] == '\n' {
+			end = k + 1
+		}
+	}
+
+	return start, math.Max(end, start), true
+}
+
+var tagPattern = regexp.MustCompile(`<(/?)([A-Za-z][\w:.-]*)[^<>]*?(/?)>`)
+
+func tagObject(text string, offset int, around bool, count int) (int, int, bool) {
+	type tag struct {
+		name       string
+		start, end int
+	}
+
+	// match the closing tags with their opening tags, ignoring tags that are never closed
+	pairs := make([][2]tag, 0)
+	stack := make([]tag, 0)
+	for _, m := range tagPattern.FindAllStringSubmatchIndex(text, -1) {
+		closing := m[3] > m[2]
+		selfClosing := m[7] > m[6]
+		t := tag{name: text[m[4]:m[5]], start: m[0], end: m[1]}
+
+		switch {
+		case selfClosing:
+		case !closing:
+			stack = append(stack, t)
+		default:
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name == t.name {
+					pairs = append(pairs, [2]tag{stack[i], t})
+					stack = stack[:i]
+					break
+				}
+			}
+		}
+	}
+
+	// pairs are added innermost first, so the count-th pair around the offset is the one
+	var pair *[2]tag
+	found := 0
+	for i := range pairs {
+		if pairs[i][0].start <= offset && offset < pairs[i][1].end {
+			found++
+			if found == count {
+				pair = &pairs[i]
+				break
+			}
+		}
+	}
+	if pair == nil {
+		return 0, 0, false
+	}
+
+	if around {
+		return pair[0].start, pair[1].end, true
+	}
+
+	return pair[0].end, pair[1].start, true
+}
@@ -0,0 +1,122 @@
+package fileeditor
+
+/*
+This file is responsible for the undo history of a buffer.
+
+Before the FileBuffer is modified, a snapshot of it is pushed onto the undo
+stack. Since the lines are strings, a snapshot only copies the line headers
+and not the text itself, which keeps them cheap enough to take on every change.
+
+Typed edits are grouped: the first edit made by typing pushes a snapshot, and
+the following ones don't until the cursor is moved or the mode changes, so
+undoing removes everything typed in one go instead of a character at a time
+*/
+
+const maxUndoHistory int = 500
+
+type undoState struct {
+	FileBuffer []string
+	line       int // cursor position before the change
+	index      int
+}
+
+/*
+Returns a copy of the FileBuffer and the cursor position
+*/
+func (f FileEditor) snapshotBuffer() undoState {
+	lines := make([]string, len(f.FileBuffer))
+	copy(lines, f.FileBuffer)
+
+	line, index := f.CursorBufferPos()
+
+	return undoState{FileBuffer: lines, line: line, index: index}
+}
+
+/*
+Records the FileBuffer before a change so that the change can be undone.
+Every change made after this is part of the same undo step until
+pushUndo is called again
+*/
+func (f *FileEditor) pushUndo() {
+	// the changes of a macro being played are undone with the macro
+	if f.inMacroUndoStep() {
+		return
+	}
+
+	f.undoStack = append(f.undoStack, f.snapshotBuffer())
+	if len(f.undoStack) > maxUndoHistory {
+		f.undoStack = f.undoStack[1:]
+	}
+
+	f.redoStack = nil
+	f.typingUndoGroup = false
+}
+
+/*
+Records the FileBuffer before an edit made by typing, unless the
+edit belongs to the group of the previous typed edit
+*/
+func (f *FileEditor) pushTypingUndo() {
+	if f.typingUndoGroup {
+		return
+	}
+
+	f.pushUndo()
+	f.typingUndoGroup = true
+}
+
+/*
+Ends the current group of typed edits, so that the
+next typed edit will start a new undo step
+*/
+func (f *FileEditor) breakUndoGroup() {
+	f.typingUndoGroup = false
+
+	// the text typed after a change ends with its group as well
+	f.modal.recording = false
+}
+
+/*
+Restores the FileBuffer and cursor position of the state
+*/
+func (f *FileEditor) restoreUndoState(state undoState) {
+	f.FileBuffer = state.FileBuffer
+	f.Saved = false
+	f.typingUndoGroup = false
+	f.folds = nil // the lines of the folds may have changed
+
+	f.bufferModified()
+	f.MoveCursorToBufferPos(state.line, state.index)
+}
+
+/*
+Reverts the last change made to the FileBuffer
+*/
+func (f *FileEditor) Undo() {
+	if len(f.undoStack) == 0 {
+		f.statusMessage = "Already at the oldest change"
+		return
+	}
+
+	state := f.undoStack[len(f.undoStack)-1]
+	f.undoStack = f.undoStack[:len(f.undoStack)-1]
+	f.redoStack = append(f.redoStack, f.snapshotBuffer())
+
+	f.restoreUndoState(state)
+}
+
+/*
+Reapplies the last change that was undone
+*/
+func (f *FileEditor) Redo() {
+	if len(f.redoStack) == 0 {
+		f.statusMessage = "Already at the newest change"
+		return
+	}
+
+	state := f.redoStack[len(f.redoStack)-1]
+	f.redoStack = f.redoStack[:len(f.redoStack)-1]
+	f.undoStack = append(f.undoStack, f.snapshotBuffer())
+
+	f.restoreUndoState(state)
+}
@@ -70,6 +70,9 @@ Here are all the mouse events:
 	64 = Scroll up
 	65 = Scroll down
 
+	8 = Left click with alt held down
+	40 = Left drag with alt held down
+
 */
 
 const SGR_MOUSE_PREFIX string = "[<"
@@ -83,6 +86,10 @@ const (
 	MouseEventRightDrag  byte = 34
 	MouseEventScrollUp   byte = 64
 	MouseEventScrollDown byte = 65
+
+	// holding alt adds 8 to the event
+	MouseEventAltLeftClick byte = 8
+	MouseEventAltLeftDrag  byte = 40
 )
 
 type MouseInput struct {
@@ -120,9 +127,51 @@ func HandleMouseInput(editor *FileEditor, m MouseInput) byte {
 
 	if m.Event == MouseEventLeftClick && m.Event != lastMouseInputEvent {
 		lastMouseInputEvent = m.Event
+
+		if m.X <= editor.fileTreeWidth() {
+			return editor.handleFileTreeMouseInput(m)
+		}
+
+		if editor.inGrepPanel(m.X, m.Y) {
+			return editor.handleGrepPanelMouseInput(m)
+		}
+
+		w := editor.windowAt(m.X, m.Y)
+		if w == nil {
+			return 0
+		}
+		if editor.fileTree != nil {
+			editor.unfocusFileTree()
+		}
+		if editor.grepPanel != nil {
+			editor.grepPanel.Focused = false
+		}
+		editor.FocusWindow(w)
+
+		// the cursor position is relative to the focused window
+		m.X -= editor.EditorX
+		m.Y -= editor.EditorY
 		return editor.SetCursorPositionOnClick(m)
 	}
 
+	if m.Event == MouseEventAltLeftClick && m.Event != lastMouseInputEvent {
+		lastMouseInputEvent = m.Event
+		return editor.startBlockCursors(m)
+	}
+
+	if m.Event == MouseEventAltLeftDrag && lastMouseInputEvent == MouseEventAltLeftClick {
+		return editor.dragBlockCursors(m)
+	}
+
+	if m.Event == MouseEventScrollUp || m.Event == MouseEventScrollDown {
+		if m.X <= editor.fileTreeWidth() {
+			return editor.handleFileTreeMouseInput(m)
+		}
+		if editor.inGrepPanel(m.X, m.Y) {
+			return editor.handleGrepPanelMouseInput(m)
+		}
+	}
+
 	// // for now, soft-wrap toggling will use the scroll wheel -> 9/28 moved to the command bar
 	// if m.Event == MouseEventScrollDown {
 	// 	return editor.ToggleSoftWrap(true)
@@ -159,12 +208,56 @@ func HandleEscapeInput(editor *FileEditor, buf []byte, n int) byte {
 		return 0
 	}
 
+	if editor.prompt != nil {
+		if n == 1 {
+			editor.ClosePrompt()
+			return EnumCursorPositionChange
+		}
+		return 0
+	}
+
+	if editor.picker != nil {
+		return editor.handlePickerEscapeInput(buf, n)
+	}
+
+	if editor.completion != nil {
+		if flag, handled := editor.handleCompletionEscapeInput(buf, n); handled {
+			return flag
+		}
+	}
+
+	if editor.fileTree != nil && editor.fileTree.Focused {
+		return editor.handleFileTreeEscapeInput(buf, n)
+	}
+
+	if editor.grepPanel != nil && editor.grepPanel.Focused {
+		return editor.handleGrepPanelEscapeInput(buf, n)
+	}
+
+	if editor.diffView != nil {
+		return editor.handleDiffViewEscapeInput(buf, n)
+	}
+
+	if string(buf[1:n]) == shiftTabSequence {
+		return editor.handleShiftTab()
+	}
+
+	if action, ok := specialKeyActions[string(buf[1:n])]; ok {
+		return editor.RunAction(action)
+	}
+
 	if n == 3 && buf[2] == UpArrowKey || buf[2] == DownArrowKey ||
 		buf[2] == RightArrowKey || buf[2] == LeftArrowKey {
 		editor.Keybindings.MapKeybindToAction(buf[2], true, editor)
 		return EnumCursorPositionChange
 	}
 
+	// escape in Command mode removes the secondary cursors
+	if n == 1 && editor.EditorMode == EditorCommandMode && len(editor.cursors) > 0 {
+		editor.ClearCursors()
+		return EnumCursorPositionChange
+	}
+
 	// Return to Command mode
 	if n == 1 && buf[0] == Escape {
 		editor.EditorMode = EditorCommandMode
@@ -175,23 +268,77 @@ func HandleEscapeInput(editor *FileEditor, buf []byte, n int) byte {
 }
 
 func HandleKeyboardInput(editor *FileEditor, key byte) byte {
-	const asciiLowerDif uint8 = 32
+	if editor.prompt != nil {
+		return editor.handlePromptInput(key)
+	}
 
-	if ansi.IsAlphaChar(key) {
-		if !editor.CommandBarToggled {
-			// Transition to View or Edit mode
-			if editor.EditorMode == EditorCommandMode {
-				if key == EditorEditMode || key == EditorEditMode+asciiLowerDif {
-					editor.EditorMode = EditorEditMode
-					return EnumEditorModeChange
-				} else if key == EditorViewMode || key == EditorViewMode+asciiLowerDif {
-					editor.EditorMode = EditorViewMode
-					return EnumEditorModeChange
-				}
+	if editor.picker != nil {
+		return editor.handlePickerInput(key)
+	}
+
+	if editor.completion != nil && !editor.CommandBarToggled {
+		if flag, handled := editor.handleCompletionInput(key); handled {
+			return flag
+		}
+	}
+
+	// keys are matched against the keymap of the mode, except while typing in the command bar
+	if !editor.CommandBarToggled {
+		if flag, handled := editor.handleMappedKey(key); handled {
+			return flag
+		}
+	}
+
+	return handleUnmappedKey(editor, key)
+}
+
+/*
+Handles a key that isn't part of a key sequence of the keymap
+*/
+func handleUnmappedKey(editor *FileEditor, key byte) byte {
+	if editor.fileTree != nil && editor.fileTree.Focused {
+		return editor.handleFileTreeInput(key)
+	}
+
+	if editor.grepPanel != nil && editor.grepPanel.Focused && !editor.CommandBarToggled {
+		return editor.handleGrepPanelInput(key)
+	}
+
+	// the command bar can still be opened while the diff view is
+	if editor.diffView != nil && !editor.CommandBarToggled &&
+		!(key == NewLine && editor.EditorMode == EditorCommandMode) {
+		return editor.handleDiffViewInput(key)
+	}
+
+	// enter opens the command bar in Command mode, and every other key is part of a command
+	if editor.EditorMode == EditorCommandMode && !editor.CommandBarToggled {
+		if key != NewLine {
+			return editor.handleCommandModeKey(key)
+		}
+		editor.modal.resetCommand()
+	}
+
+	if editor.EditorMode == EditorViewMode && !editor.CommandBarToggled {
+		return editor.handleViewModeKey(key)
+	}
+
+	if editor.EditorMode == EditorEditMode && !editor.CommandBarToggled {
+		editor.recordInsertedKey(key)
+
+		if len(editor.cursors) > 0 || editor.typesPair(key) {
+			if flag := editor.typeAtCursors(key); flag != 0 {
+				return flag
 			}
+		}
+	}
 
+	if ansi.IsAlphaChar(key) {
+		if !editor.CommandBarToggled {
 			if editor.EditorMode == EditorEditMode {
+				pos := editor.cursorPos()
+				editor.pushTypingUndo()
 				editor.actionTyping(key)
+				editor.dedentClosingBracket(pos)
 			}
 
 		} else {
@@ -201,16 +348,20 @@ func HandleKeyboardInput(editor *FileEditor, key byte) byte {
 	} else {
 		if editor.EditorMode == EditorEditMode {
 			if key == NewLine {
+				editor.pushTypingUndo()
 				return editor.actionNewLine()
 			} else if key == Backspace {
+				editor.pushTypingUndo()
 				editor.actionDeleteText()
 			} else if key == Tab {
+				editor.pushTypingUndo()
 				editor.actionInsertTab()
 			}
 		} else if editor.EditorMode == EditorCommandMode {
 			if key == NewLine {
 				if editor.CommandBarToggled {
-					if editor.isCommandBarQuitStr() {
+					// quitting is refused when a buffer has unsaved changes, which the quit command shows once the command bar is closed
+					if editor.isCommandBarQuitStr() && editor.Quit(editor.CommandBarBuffer == CMDBAR_FORCE_QUIT) == EnumQuit {
 						return EnumQuit
 					}
 				}
@@ -1,11 +1,34 @@
 package fileeditor
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+)
+
 // The viewport height does not include the space occupied by the status bar
 func (f *FileEditor) GetViewportHeight() int {
-	return f.TermHeight - f.StatusBarHeight
+	return f.EditorHeight
 }
 
 // The viewport width does not include the margin space to the left
 func (f *FileEditor) GetViewportWidth() int {
-	return f.TermWidth - EditorLeftMargin + 1
+	return f.EditorWidth - EditorLeftMargin + 1
+}
+
+/*
+Moves the cursor to the start of the row (1-indexed) of the editor area
+and erases the row, without touching anything outside of the editor area
+*/
+func (f *FileEditor) eraseEditorRow(row int) {
+	ansi.MoveCursor(f.EditorY+row, f.EditorX+1)
+
+	if f.EditorX == 0 && f.EditorWidth == f.TermWidth {
+		ansi.EraseEntireLine()
+		return
+	}
+
+	fmt.Print(strings.Repeat(" ", f.EditorWidth))
+	ansi.MoveCursor(f.EditorY+row, f.EditorX+1)
 }
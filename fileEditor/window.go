@@ -0,0 +1,402 @@
+package fileeditor
+
+import (
+	"github.com/Asiandayboy/CLITextEditor/render"
+	"github.com/Asiandayboy/CLITextEditor/util/ansi"
+	"github.com/Asiandayboy/CLITextEditor/util/math"
+)
+
+/*
+This file is responsible for split windows.
+
+A window shows a buffer through its own cursor and viewport, and several
+windows can show the same buffer. The windows are the leaves of a layout tree,
+where every other node splits its rectangle between its two children, either
+side by side or one on top of the other, with a one cell border between them.
+
+Like buffers, only the focused window is stored in the FileEditor itself: its
+rectangle is stored in EditorX, EditorY, EditorWidth and EditorHeight, and its
+cursor and viewport in the embedded viewState. All of the cursor arithmetic
+works relative to the rectangle of the focused window, so other windows are
+drawn by temporarily loading them as the focused one
+*/
+
+const (
+	SplitHorizontal byte = 1 // the children are stacked on top of each other
+	SplitVertical   byte = 2 // the children are side by side
+)
+
+// the smallest size a window can be resized to
+const (
+	windowMinWidth  int = EditorLeftMargin + 8
+	windowMinHeight int = 3
+)
+
+var windowBorderColor ansi.RGBColor = ansi.NewRGBColor(60, 60, 60)
+
+type Rect struct {
+	X, Y          int // 0-indexed offsets from the top left of the terminal
+	Width, Height int
+}
+
+/*
+Returns true if the position (1-indexed) on the screen is inside of the rectangle
+*/
+func (r Rect) Contains(x, y int) bool {
+	return x > r.X && x <= r.X+r.Width && y > r.Y && y <= r.Y+r.Height
+}
+
+type window struct {
+	buffer int       // index of the buffer shown in the window
+	view   viewState // only up to date when the window isn't focused
+	rect   Rect
+	node   *layoutNode
+}
+
+type layoutNode struct {
+	split    byte // 0 for leaves, which hold a window
+	ratio    float64
+	children [2]*layoutNode
+	parent   *layoutNode
+	window   *window
+	rect     Rect
+}
+
+/*
+Returns the rectangle that the windows are laid out in, which is the whole
+terminal except for the status bar, and the file tree and the results panel
+when they are shown
+*/
+func (f FileEditor) layoutArea() Rect {
+	area := Rect{X: 0, Y: 0, Width: f.TermWidth, Height: f.TermHeight - f.StatusBarHeight}
+
+	if treeWidth := f.fileTreeWidth(); treeWidth > 0 {
+		area.X = treeWidth + 1 // +1 for the border
+		area.Width -= treeWidth + 1
+	}
+
+	if panelHeight := f.grepPanelHeight(); panelHeight > 0 {
+		area.Height -= panelHeight + 1 // +1 for the border
+	}
+
+	return area
+}
+
+/*
+Creates the layout with a single window showing the current buffer
+*/
+func (f *FileEditor) initLayout() {
+	w := &window{buffer: f.currentBuffer}
+	f.layoutRoot = &layoutNode{window: w}
+	w.node = f.layoutRoot
+	f.windows = []*window{w}
+	f.focusedWindow = w
+
+	f.layoutWindows()
+}
+
+/*
+Splits the rectangle of the node between its children, recursively
+*/
+func (n *layoutNode) layout(r Rect) {
+	n.rect = r
+
+	if n.split == 0 {
+		n.window.rect = r
+		return
+	}
+
+	first, second := r, r
+	if n.split == SplitVertical {
+		size := math.Clamp(int(float64(r.Width-1)*n.ratio), 1, math.Max(r.Width-2, 1))
+		first.Width = size
+		second.X = r.X + size + 1
+		second.Width = r.Width - size - 1
+	} else {
+		size := math.Clamp(int(float64(r.Height-1)*n.ratio), 1, math.Max(r.Height-2, 1))
+		first.Height = size
+		second.Y = r.Y + size + 1
+		second.Height = r.Height - size - 1
+	}
+
+	n.children[0].layout(first)
+	n.children[1].layout(second)
+}
+
+/*
+Computes the rectangle of every window, and loads the
+rectangle of the focused window into the editor area
+*/
+func (f *FileEditor) layoutWindows() {
+	f.layoutRoot.layout(f.layoutArea())
+
+	r := f.focusedWindow.rect
+	f.EditorX = r.X
+	f.EditorY = r.Y
+	f.EditorWidth = r.Width
+	f.EditorHeight = r.Height
+}
+
+/*
+Places the cursor back on its position in the FileBuffer after the
+editor area has changed size, scrolling the viewport if needed
+*/
+func (f *FileEditor) replaceCursor() {
+	f.RefreshVisualBuffers()
+	line, index := f.CursorBufferPos()
+	f.MoveCursorToBufferPos(line, index)
+}
+
+/*
+Stores the cursor and viewport of the focused window
+*/
+func (f *FileEditor) storeFocusedWindow() {
+	f.focusedWindow.buffer = f.currentBuffer
+	f.focusedWindow.view = f.viewState
+	f.storeCurrentBuffer()
+}
+
+/*
+Loads the window as the focused window without storing the previous one
+*/
+func (f *FileEditor) loadWindow(w *window) {
+	f.focusedWindow = w
+	f.currentBuffer = w.buffer
+	f.bufferState = f.buffers[w.buffer].bufferState
+	f.viewState = w.view
+
+	r := w.rect
+	f.EditorX = r.X
+	f.EditorY = r.Y
+	f.EditorWidth = r.Width
+	f.EditorHeight = r.Height
+}
+
+/*
+Moves the focus to the window. The cursor is placed again in case
+the buffer of the window was edited through another window
+*/
+func (f *FileEditor) FocusWindow(w *window) {
+	if w == f.focusedWindow {
+		return
+	}
+
+	f.storeFocusedWindow()
+	f.loadWindow(w)
+	f.replaceCursor()
+
+	ansi.SetTerminalWindowTitle(f.Filename)
+}
+
+/*
+Moves the focus to the next window, in the order they were created
+*/
+func (f *FileEditor) FocusNextWindow() {
+	for i, w := range f.windows {
+		if w == f.focusedWindow {
+			f.FocusWindow(f.windows[(i+1)%len(f.windows)])
+			return
+		}
+	}
+}
+
+/*
+Returns the window that contains the position (1-indexed) on the screen, or nil
+*/
+func (f FileEditor) windowAt(x, y int) *window {
+	for _, w := range f.windows {
+		if w.rect.Contains(x, y) {
+			return w
+		}
+	}
+
+	return nil
+}
+
+/*
+Returns the rectangle of every window, in the order they were created
+*/
+func (f FileEditor) WindowRects() []Rect {
+	rects := make([]Rect, len(f.windows))
+	for i, w := range f.windows {
+		rects[i] = w.rect
+	}
+
+	return rects
+}
+
+/*
+Splits the focused window in two, both showing the current buffer.
+The new window takes the focus
+*/
+func (f *FileEditor) SplitWindow(split byte) {
+	old := f.focusedWindow
+	r := old.rect
+	if (split == SplitVertical && r.Width < 2*windowMinWidth+1) ||
+		(split == SplitHorizontal && r.Height < 2*windowMinHeight+1) {
+		f.statusMessage = "Not enough room to split the window"
+		return
+	}
+
+	f.storeFocusedWindow()
+
+	node := old.node
+	oldLeaf := &layoutNode{window: old, parent: node}
+	newWindow := &window{buffer: old.buffer, view: old.view}
+	newLeaf := &layoutNode{window: newWindow, parent: node}
+	old.node = oldLeaf
+	newWindow.node = newLeaf
+
+	// the node of the focused window becomes the split
+	node.split = split
+	node.ratio = 0.5
+	node.window = nil
+	node.children = [2]*layoutNode{oldLeaf, newLeaf}
+
+	f.windows = append(f.windows, newWindow)
+
+	f.layoutRoot.layout(f.layoutArea())
+	f.loadWindow(newWindow)
+	f.replaceCursor()
+
+	ansi.ClearEntireScreen()
+}
+
+/*
+Closes the focused window and gives its space to its sibling
+*/
+func (f *FileEditor) CloseWindow() {
+	if len(f.windows) == 1 {
+		f.statusMessage = "Cannot close the last window"
+		return
+	}
+
+	closing := f.focusedWindow
+	f.storeFocusedWindow()
+
+	parent := closing.node.parent
+	sibling := parent.children[0]
+	if sibling == closing.node {
+		sibling = parent.children[1]
+	}
+
+	// the sibling takes the place of the parent in the tree
+	sibling.parent = parent.parent
+	if parent.parent == nil {
+		f.layoutRoot = sibling
+	} else if parent.parent.children[0] == parent {
+		parent.parent.children[0] = sibling
+	} else {
+		parent.parent.children[1] = sibling
+	}
+
+	for i, w := range f.windows {
+		if w == closing {
+			f.windows = append(f.windows[:i], f.windows[i+1:]...)
+			break
+		}
+	}
+
+	// focus the first window of what was the sibling
+	next := sibling
+	for next.split != 0 {
+		next = next.children[0]
+	}
+
+	f.layoutRoot.layout(f.layoutArea())
+	f.loadWindow(next.window)
+	f.replaceCursor()
+
+	ansi.ClearEntireScreen()
+}
+
+/*
+Grows (or shrinks, if amount is negative) the focused window by the amount of
+cells along the direction of the split it is part of
+*/
+func (f *FileEditor) ResizeWindow(amount int) {
+	parent := f.focusedWindow.node.parent
+	if parent == nil {
+		return
+	}
+
+	var total, min int
+	if parent.split == SplitVertical {
+		total, min = parent.rect.Width-1, windowMinWidth
+	} else {
+		total, min = parent.rect.Height-1, windowMinHeight
+	}
+
+	if parent.children[1] == f.focusedWindow.node {
+		amount = -amount
+	}
+
+	size := int(float64(total)*parent.ratio) + amount
+	size = math.Clamp(size, min, math.Max(total-min, min))
+	parent.ratio = float64(size) / float64(total)
+
+	f.layoutWindows()
+	f.replaceCursor()
+
+	ansi.ClearEntireScreen()
+}
+
+/*
+Draws the border between the children of every split node
+*/
+func (n *layoutNode) drawBorders() {
+	if n.split == 0 {
+		return
+	}
+
+	first := n.children[0].rect
+	if n.split == SplitVertical {
+		render.DrawVerticalLine(render.Line{
+			Length: n.rect.Height,
+			X:      first.X + first.Width, Y: first.Y,
+			LineColor: windowBorderColor,
+		}, true)
+	} else {
+		render.DrawHorizontalLine(render.Line{
+			Length: n.rect.Width,
+			X:      first.X, Y: first.Y + first.Height,
+			LineColor: windowBorderColor,
+		}, true)
+	}
+
+	n.children[0].drawBorders()
+	n.children[1].drawBorders()
+}
+
+/*
+Draws every window that isn't focused, as well as the borders between
+the windows. The focused window is drawn by Render itself
+*/
+func (f *FileEditor) PrintUnfocusedWindows() {
+	if len(f.windows) == 1 {
+		return
+	}
+
+	focused := f.focusedWindow
+	f.storeFocusedWindow()
+
+	for _, w := range f.windows {
+		if w == focused {
+			continue
+		}
+
+		f.loadWindow(w)
+		f.RefreshVisualBuffers()
+		if f.diffView != nil {
+			f.PrintDiffView()
+		} else {
+			f.PrintBuffer()
+		}
+		w.view = f.viewState
+	}
+
+	f.loadWindow(focused)
+	f.RefreshVisualBuffers()
+
+	f.layoutRoot.drawBorders()
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
 	"github.com/Asiandayboy/CLITextEditor/table"
@@ -86,14 +88,38 @@ func newTable() table.Table {
 
 func main() {
 
-	if len(os.Args) < 2 {
-		fmt.Println("\033[31m[Error]: A filename must be provided as an argument\n\033[0m")
+	// -R, or running the editor as view, opens the files read-only in View mode
+	readOnly := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == "view"
+	// +N, or +N:C, puts the cursor on that line, and column, of the first file
+	gotoLine, gotoCol := 0, 0
+	filenames := make([]string, 0, len(os.Args))
+	for _, arg := range os.Args[1:] {
+		if arg == "-R" {
+			readOnly = true
+		} else if strings.HasPrefix(arg, "+") {
+			line, col, err := fileeditor.ParseLineColumn(arg[1:])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			gotoLine, gotoCol = line, col
+		} else {
+			filenames = append(filenames, arg)
+		}
+	}
+
+	if len(filenames) < 1 {
+		fmt.Println("\033[31m[Error]: At least one filename must be provided as an argument\n\033[0m")
 		return
 	}
 
-	var filename string = os.Args[1]
+	var filename string = filenames[0]
 
 	editor := fileeditor.NewFileEditor(filename)
+	if readOnly {
+		editor.ReadOnly = true
+		editor.EditorMode = fileeditor.EditorViewMode
+	}
 	editor.OpenFile()
 	defer editor.CloseFile()
 	if err := editor.ReadFileToBuffer(); err != nil {
@@ -101,6 +127,19 @@ func main() {
 		return
 	}
 
+	// every other file is opened in its own buffer, and the first file stays the current one
+	for _, other := range filenames[1:] {
+		if err := editor.OpenBuffer(other); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	editor.SwitchBuffer(0)
+
+	if gotoLine > 0 {
+		editor.GotoLine(gotoLine, gotoCol)
+	}
+
 	// set terminal to raw mode
 	oldState, err := term.MakeRaw(int(os.Stdin.Fd()))
 	if err != nil {
@@ -133,8 +133,12 @@ func DrawVerticalLine(l Line, resetCurosr bool) {
 		line = DoubleVertical
 	}
 
-	ansi.MoveCursorRight(l.X)
-	ansi.MoveCursorDown(l.Y)
+	if l.X > 0 {
+		ansi.MoveCursorRight(l.X)
+	}
+	if l.Y > 0 {
+		ansi.MoveCursorDown(l.Y)
+	}
 
 	color := ansi.NewRGBColor(255, 255, 255).ToFgColorANSI()
 
@@ -148,7 +152,47 @@ func DrawVerticalLine(l Line, resetCurosr bool) {
 
 	for range l.Length {
 		fmt.Println(color + line + Reset)
+		if l.X > 0 {
+			ansi.MoveCursorRight(l.X)
+		}
+	}
+
+}
+
+func DrawHorizontalLine(l Line, resetCursor bool) {
+	if resetCursor {
+		ansi.MoveCursor(0, 0)
+	}
+
+	var line string = Horizontal
+	if l.LineStyle == "dashed" {
+		line = "-"
+	} else if l.LineStyle == "solid" {
+		line = Horizontal
+	} else if l.LineStyle == "double" {
+		line = DoubleHorizontal
+	}
+
+	if l.X > 0 {
 		ansi.MoveCursorRight(l.X)
 	}
+	if l.Y > 0 {
+		ansi.MoveCursorDown(l.Y)
+	}
+
+	color := ansi.NewRGBColor(255, 255, 255).ToFgColorANSI()
 
+	if l.LineColor != ansi.NO_COLOR {
+		color = l.LineColor.ToFgColorANSI()
+	}
+
+	if l.BackgroundColor != ansi.NO_COLOR {
+		color = ansi.CombineFgAndBgColorANSI(l.LineColor, l.BackgroundColor)
+	}
+
+	fmt.Print(color)
+	for range l.Length {
+		fmt.Print(line)
+	}
+	fmt.Print(Reset)
 }
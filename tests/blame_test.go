@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+const porcelainOutput string = `0123456789abcdef0123456789abcdef01234567 1 1 2
+author Jane Doe
+author-mail <jane@example.com>
+author-time 1700000000
+author-tz +0000
+summary initial commit
+filename main.go
+	package main
+0123456789abcdef0123456789abcdef01234567 2 2
+	
+fedcba9876543210fedcba9876543210fedcba98 3 3 1
+author John Doe
+author-time 1710000000
+summary add main
+filename main.go
+	func main() {}
+`
+
+func TestParseBlamePorcelain(t *testing.T) {
+	lines, err := fileeditor.ParseBlamePorcelain(porcelainOutput)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v\n", err)
+	}
+
+	tests := []struct {
+		content string
+		author  string
+		hash    string
+	}{
+		{content: "package main", author: "Jane Doe", hash: "0123456"},
+		{content: "", author: "Jane Doe", hash: "0123456"},
+		{content: "func main() {}", author: "John Doe", hash: "fedcba9"},
+	}
+
+	if len(lines) != len(tests) {
+		t.Fatalf("Expected: %d lines, got: %d\n", len(tests), len(lines))
+	}
+
+	for i, tc := range tests {
+		if lines[i].Content != tc.content {
+			t.Fatalf("Expected: %q, got: %q\n", tc.content, lines[i].Content)
+		}
+		if lines[i].Commit.Author != tc.author {
+			t.Fatalf("Expected: %q, got: %q\n", tc.author, lines[i].Commit.Author)
+		}
+		if lines[i].Commit.Hash[:7] != tc.hash {
+			t.Fatalf("Expected: %q, got: %q\n", tc.hash, lines[i].Commit.Hash[:7])
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		width    int
+		expected string
+	}{
+		{name: "fits", text: "Jane Doe", width: 10, expected: "Jane Doe"},
+		{name: "exact", text: "Jane Doe", width: 8, expected: "Jane Doe"},
+		{name: "cut", text: "Jane Doe", width: 4, expected: "Jane"},
+		{name: "multi-byte characters", text: "José Müller", width: 6, expected: "José M"},
+		{name: "cut after a multi-byte character", text: "Zoë", width: 3, expected: "Zoë"},
+		{name: "cut before a multi-byte character", text: "Zoë", width: 2, expected: "Zo"},
+		{name: "no space", text: "Jane Doe", width: 0, expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fileeditor.TruncateText(tc.text, tc.width)
+			if got != tc.expected {
+				t.Fatalf("Expected: %q, got: %q\n", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+/*
+Returns an editor with a buffer of the first file, and a buffer of the
+second file, which is the current one
+*/
+func newTwoBufferEditor(t *testing.T) (*fileeditor.FileEditor, string, string) {
+	t.Helper()
+
+	f := newTestEditor(t, "a.txt", []string{"a1", "a2"})
+	first := f.Filename
+	second := filepath.Join(filepath.Dir(first), "b.txt")
+	if err := os.WriteFile(second, []byte("b1\nb2\nb3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.OpenBuffer(second); err != nil {
+		t.Fatal(err)
+	}
+
+	return f, first, second
+}
+
+/*
+Changes the working directory for the rest of the test
+*/
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestSwitchBufferKeepsState(t *testing.T) {
+	f, first, second := newTwoBufferEditor(t)
+
+	typeKeys(f, "jdd")
+	f.SwitchBuffer(0)
+	if f.Filename != first {
+		t.Fatalf("Expected: %s, got: %s\n", first, f.Filename)
+	}
+	expectLines(t, f, []string{"a1", "a2"})
+
+	f.NextBuffer()
+	if f.Filename != second || f.Saved {
+		t.Fatalf("Expected: unsaved %s, got: %s saved %v\n", second, f.Filename, f.Saved)
+	}
+	expectLines(t, f, []string{"b1", "b3"})
+
+	if line, _ := f.CursorBufferPos(); line != 1 {
+		t.Fatalf("Expected: %v, got: %v\n", 1, line)
+	}
+
+	// opening a file that is open switches to its buffer
+	if err := f.OpenBuffer(first); err != nil || f.Filename != first {
+		t.Fatalf("Expected: %s, got: %s %v\n", first, f.Filename, err)
+	}
+	f.NextBuffer()
+	f.NextBuffer()
+	if f.Filename != first {
+		t.Fatalf("Expected: two buffers, got: %s after switching twice\n", f.Filename)
+	}
+}
+
+func TestCloseBuffer(t *testing.T) {
+	f, first, second := newTwoBufferEditor(t)
+
+	typeKeys(f, "dd")
+	f.CloseBuffer(false)
+	if f.Filename != second {
+		t.Fatalf("Expected: the unsaved buffer to stay open, got: %s\n", f.Filename)
+	}
+
+	f.CloseBuffer(true)
+	if f.Filename != first {
+		t.Fatalf("Expected: %s, got: %s\n", first, f.Filename)
+	}
+
+	f.CloseBuffer(true)
+	if f.Filename != first {
+		t.Fatalf("Expected: the last buffer to stay open, got: %s\n", f.Filename)
+	}
+}
+
+func TestQuitWithUnsavedBuffers(t *testing.T) {
+	f, _, _ := newTwoBufferEditor(t)
+	if flag := f.Quit(false); flag != fileeditor.EnumQuit {
+		t.Fatalf("Expected: %v, got: %v\n", fileeditor.EnumQuit, flag)
+	}
+
+	// the unsaved buffer isn't the current one
+	typeKeys(f, "dd")
+	f.SwitchBuffer(0)
+	if flag := f.Quit(false); flag == fileeditor.EnumQuit {
+		t.Fatalf("Expected: quitting to be refused, got: %v\n", flag)
+	}
+	if flag := f.Quit(true); flag != fileeditor.EnumQuit {
+		t.Fatalf("Expected: %v, got: %v\n", fileeditor.EnumQuit, flag)
+	}
+
+	typeKeys(f, "\rquit")
+	if flag := f.HandleEvent([]byte{fileeditor.NewLine}); flag == fileeditor.EnumQuit {
+		t.Fatalf("Expected: the quit command to be refused, got: %v\n", flag)
+	}
+	typeKeys(f, "\rquit!")
+	if flag := f.HandleEvent([]byte{fileeditor.NewLine}); flag != fileeditor.EnumQuit {
+		t.Fatalf("Expected: %v, got: %v\n", fileeditor.EnumQuit, flag)
+	}
+
+	f.NextBuffer()
+	f.SaveFile()
+	if flag := f.Quit(false); flag != fileeditor.EnumQuit {
+		t.Fatalf("Expected: %v, got: %v\n", fileeditor.EnumQuit, flag)
+	}
+}
+
+func TestDeleteOpenFileFromFileTree(t *testing.T) {
+	f, first, second := newTwoBufferEditor(t)
+	chdir(t, filepath.Dir(first)) // the file tree lists the working directory
+
+	// the tree lists a.txt and then b.txt
+	typeKeys(f, string([]byte{fileeditor.CtrlT})+"jdy\r")
+	if _, err := os.Stat(second); !os.IsNotExist(err) {
+		t.Fatalf("Expected: %s to be deleted, got: %v\n", second, err)
+	}
+	if f.Filename != first {
+		t.Fatalf("Expected: the buffer of the deleted file to close, got: %s\n", f.Filename)
+	}
+
+	// the last buffer is kept open, as unsaved
+	typeKeys(f, "kdy\r")
+	if f.Filename != first || f.Saved {
+		t.Fatalf("Expected: unsaved %s, got: %s saved %v\n", first, f.Filename, f.Saved)
+	}
+	if flag := f.Quit(false); flag == fileeditor.EnumQuit {
+		t.Fatalf("Expected: quitting to be refused, got: %v\n", flag)
+	}
+}
+
+func TestRenameOpenFileFromFileTree(t *testing.T) {
+	f, first, _ := newTwoBufferEditor(t)
+	chdir(t, filepath.Dir(first))
+	renamed := filepath.Join(filepath.Dir(first), "c.txt")
+
+	// the buffers are open by their absolute paths. Setting the marks creates the config
+	// directory, which is also the temporary directory, so the tree lists it first
+	f.SwitchBuffer(0)
+	typeKeys(f, "jmamAk")
+	typeKeys(f, string([]byte{fileeditor.CtrlT})+"jr\x7f\x7f\x7f\x7f\x7fc.txt\r\x1b")
+	if f.Filename != renamed {
+		t.Fatalf("Expected: %s, got: %s\n", renamed, f.Filename)
+	}
+
+	// the marks of the state file moved to the new path
+	f.LoadMarks()
+	typeKeys(f, "'a")
+	if line, _ := f.CursorBufferPos(); line != 1 {
+		t.Fatalf("Expected: %v, got: %v\n", 1, line)
+	}
+	typeKeys(f, "k'A")
+	if line, _ := f.CursorBufferPos(); line != 1 || f.Filename != renamed {
+		t.Fatalf("Expected: line 1 of %s, got: line %v of %s\n", renamed, line, f.Filename)
+	}
+
+	f.SaveFile()
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Fatalf("Expected: %s to stay moved, got: %v\n", first, err)
+	}
+}
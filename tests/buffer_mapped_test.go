@@ -109,6 +109,18 @@ func TestCalcBufferLineFromACY(t *testing.T) {
 			expected:        25,
 			viewportOffsetY: 0,
 		},
+		{
+			name:         "Test 16 (folded lines)",
+			cursorY:      3,
+			mappedBuffer: []int{1, 2, 2, 2, 3, 4},
+			expected:     4,
+		},
+		{
+			name:         "Test 17 (folded lines)",
+			cursorY:      2,
+			mappedBuffer: []int{1, 2, 2, 2, 3, 4},
+			expected:     1,
+		},
 	}
 
 	for _, tc := range tests {
@@ -0,0 +1,29 @@
+package tests
+
+import (
+	"testing"
+)
+
+func TestRepeatChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		keys     string
+		expected []string
+	}{
+		{name: "append at the end", lines: []string{"a", "b"}, keys: "Ax\x1bj.", expected: []string{"ax", "bx"}},
+		{name: "insert at the first character", lines: []string{"  a", "  b"}, keys: "I- \x1bj.", expected: []string{"  - a", "  - b"}},
+		{name: "open a line", lines: []string{"a", "b"}, keys: "oz\x1bj.", expected: []string{"a", "z", "b", "z"}},
+		{name: "undo the repeat", lines: []string{"a", "b"}, keys: "Ax\x1bj.u", expected: []string{"ax", "b"}},
+		{name: "change a word", lines: []string{"one two", "one two"}, keys: "cwsix\x1bj0.", expected: []string{"six two", "six two"}},
+		{name: "delete lines", lines: []string{"a", "b", "c"}, keys: "dd.", expected: []string{"c"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTestEditor(t, "notes.txt", tc.lines)
+			typeKeys(f, tc.keys)
+			expectLines(t, f, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"slices"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestToggleLineComments(t *testing.T) {
+	goFt := fileeditor.FiletypeFor("main.go")
+	pyFt := fileeditor.FiletypeFor("main.py")
+	htmlFt := fileeditor.FiletypeFor("index.html")
+
+	tests := []struct {
+		name     string
+		lines    []string
+		ft       fileeditor.Filetype
+		expected []string
+	}{
+		{
+			name:     "comments at the smallest indentation",
+			lines:    []string{"\tif x {", "\t\ty()", "", "\t}"},
+			ft:       goFt,
+			expected: []string{"\t// if x {", "\t// \ty()", "", "\t// }"},
+		},
+		{
+			name:     "uncomments",
+			lines:    []string{"  # a", "  #b", "    # c"},
+			ft:       pyFt,
+			expected: []string{"  a", "  b", "    c"},
+		},
+		{
+			name:     "comments mixed lines",
+			lines:    []string{"# a", "b"},
+			ft:       pyFt,
+			expected: []string{"# # a", "# b"},
+		},
+		{
+			name:     "wraps lines without line comments",
+			lines:    []string{"  <p>", "  </p>  "},
+			ft:       htmlFt,
+			expected: []string{"  <!-- <p> -->", "  <!-- </p> -->"},
+		},
+		{
+			name:     "unwraps lines without line comments",
+			lines:    []string{"  <!-- <p> -->"},
+			ft:       htmlFt,
+			expected: []string{"  <p>"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fileeditor.ToggleLineComments(tc.lines, tc.ft)
+			if !slices.Equal(got, tc.expected) {
+				t.Fatalf("Expected: %q, got: %q\n", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestToggleBlockComment(t *testing.T) {
+	goFt := fileeditor.FiletypeFor("main.go")
+
+	tests := []struct {
+		name     string
+		lines    []string
+		expected []string
+	}{
+		{name: "wraps", lines: []string{"\tx := 1", "\ty := 2", ""}, expected: []string{"\t/* x := 1", "\ty := 2 */", ""}},
+		{name: "unwraps", lines: []string{"\t/* x := 1", "\ty := 2 */"}, expected: []string{"\tx := 1", "\ty := 2"}},
+		{name: "single line", lines: []string{"/* x */"}, expected: []string{"x"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _ := fileeditor.ToggleBlockComment(tc.lines, goFt)
+			if !slices.Equal(got, tc.expected) {
+				t.Fatalf("Expected: %q, got: %q\n", tc.expected, got)
+			}
+		})
+	}
+
+	if _, ok := fileeditor.ToggleBlockComment([]string{"x = 1"}, fileeditor.FiletypeFor("main.py")); ok {
+		t.Fatalf("Expected: %v, got: %v\n", false, ok)
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"slices"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestCompletionWords(t *testing.T) {
+	current := []string{
+		"format := fmt.Sprintf(f)",
+		"fo",
+		"",
+		"found, found, found",
+		"forward",
+	}
+	other := []string{"foreign fo_bar format"}
+
+	tests := []struct {
+		name      string
+		prefix    string
+		buffers   [][]string
+		pos       fileeditor.BufferPos
+		wordChars string
+		expected  []string
+	}{
+		{
+			name:     "closest first",
+			prefix:   "fo",
+			buffers:  [][]string{current},
+			pos:      fileeditor.BufferPos{Line: 1, Index: 2},
+			expected: []string{"format", "found", "forward"},
+		},
+		{
+			name:     "most frequent first at the same distance",
+			prefix:   "fo",
+			buffers:  [][]string{{"fox", "fo", "food food"}},
+			pos:      fileeditor.BufferPos{Line: 1, Index: 2},
+			expected: []string{"food", "fox"},
+		},
+		{
+			name:     "closest before most frequent",
+			prefix:   "fo",
+			buffers:  [][]string{current},
+			pos:      fileeditor.BufferPos{Line: 4, Index: 2},
+			expected: []string{"found", "format"},
+		},
+		{
+			name:     "other buffers last",
+			prefix:   "fo",
+			buffers:  [][]string{current, other},
+			pos:      fileeditor.BufferPos{Line: 1, Index: 2},
+			expected: []string{"format", "found", "forward", "foreign"},
+		},
+		{
+			name:      "word characters",
+			prefix:    "fo_",
+			buffers:   [][]string{current, other},
+			pos:       fileeditor.BufferPos{Line: 1, Index: 2},
+			wordChars: "_",
+			expected:  []string{"fo_bar"},
+		},
+		{
+			name:     "no match",
+			prefix:   "xyz",
+			buffers:  [][]string{current},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fileeditor.CompletionWords(tc.prefix, tc.buffers, tc.pos, tc.wordChars)
+			if !slices.Equal(got, tc.expected) {
+				t.Fatalf("Expected: %q, got: %q\n", tc.expected, got)
+			}
+		})
+	}
+}
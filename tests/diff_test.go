@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Asiandayboy/CLITextEditor/util/diff"
+)
+
+/*
+Returns the lines prefix0, prefix1, ... up to n lines
+*/
+func numberedLines(prefix string, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+	return lines
+}
+
+func TestDiffLines(t *testing.T) {
+	long := numberedLines("line", 5000)
+	edited := append([]string{}, long...)
+	edited[10] = "changed"
+	edited[4000] = "changed"
+
+	tests := []struct {
+		name     string
+		a, b     []string
+		expected []diff.Hunk
+	}{
+		{
+			name:     "Equal lines",
+			a:        []string{"a", "b", "c"},
+			b:        []string{"a", "b", "c"},
+			expected: []diff.Hunk{},
+		},
+		{
+			name: "Inserted line",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "b", "x", "c"},
+			expected: []diff.Hunk{
+				{OldStart: 2, OldLines: 0, NewStart: 2, NewLines: 1},
+			},
+		},
+		{
+			name: "Deleted lines",
+			a:    []string{"a", "b", "c", "d"},
+			b:    []string{"a", "d"},
+			expected: []diff.Hunk{
+				{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 0},
+			},
+		},
+		{
+			name: "Modified line",
+			a:    []string{"a", "b", "c"},
+			b:    []string{"a", "B", "c"},
+			expected: []diff.Hunk{
+				{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1},
+			},
+		},
+		{
+			name: "Multiple hunks",
+			a:    []string{"a", "b", "c", "d", "e", "f"},
+			b:    []string{"x", "a", "c", "d", "f", "y"},
+			expected: []diff.Hunk{
+				{OldStart: 0, OldLines: 0, NewStart: 0, NewLines: 1},
+				{OldStart: 1, OldLines: 1, NewStart: 2, NewLines: 0},
+				{OldStart: 4, OldLines: 1, NewStart: 4, NewLines: 0},
+				{OldStart: 6, OldLines: 0, NewStart: 5, NewLines: 1},
+			},
+		},
+		{
+			name: "Long lines with few changes",
+			a:    long,
+			b:    edited,
+			expected: []diff.Hunk{
+				{OldStart: 10, OldLines: 1, NewStart: 10, NewLines: 1},
+				{OldStart: 4000, OldLines: 1, NewStart: 4000, NewLines: 1},
+			},
+		},
+		{
+			name: "Long lines that differ completely are replaced in one hunk",
+			a:    long,
+			b:    numberedLines("other", 4000),
+			expected: []diff.Hunk{
+				{OldStart: 0, OldLines: 5000, NewStart: 0, NewLines: 4000},
+			},
+		},
+		{
+			name: "Empty old lines",
+			a:    []string{},
+			b:    []string{"a", "b"},
+			expected: []diff.Hunk{
+				{OldStart: 0, OldLines: 0, NewStart: 0, NewLines: 2},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := diff.Lines(tc.a, tc.b)
+			if len(result) == 0 && len(tc.expected) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+	"github.com/Asiandayboy/CLITextEditor/util/diff"
+)
+
+func TestDiffRows(t *testing.T) {
+	const (
+		header  = fileeditor.DiffRowHeader
+		context = fileeditor.DiffRowContext
+		deleted = fileeditor.DiffRowDeleted
+		added   = fileeditor.DiffRowAdded
+	)
+
+	tests := []struct {
+		name       string
+		old, new   []string
+		hunks      []diff.Hunk
+		unified    []fileeditor.DiffRow
+		sideBySide []fileeditor.DiffRow
+	}{
+		{
+			name:  "Changed line",
+			old:   []string{"a", "b", "c"},
+			new:   []string{"a", "B", "c"},
+			hunks: []diff.Hunk{{OldStart: 1, OldLines: 1, NewStart: 1, NewLines: 1}},
+			unified: []fileeditor.DiffRow{
+				{Kind: header, Hunk: 0, OldNum: -1, NewNum: -1, OldText: "@@ -2,1 +2,1 @@", NewText: "@@ -2,1 +2,1 @@"},
+				{Kind: context, Hunk: 0, OldNum: 0, NewNum: 0, OldText: "a", NewText: "a"},
+				{Kind: deleted, Hunk: 0, OldNum: 1, NewNum: -1, OldText: "b"},
+				{Kind: added, Hunk: 0, OldNum: -1, NewNum: 1, NewText: "B"},
+				{Kind: context, Hunk: 0, OldNum: 2, NewNum: 2, OldText: "c", NewText: "c"},
+			},
+			sideBySide: []fileeditor.DiffRow{
+				{Kind: header, Hunk: 0, OldNum: -1, NewNum: -1, OldText: "@@ -2,1 +2,1 @@", NewText: "@@ -2,1 +2,1 @@"},
+				{Kind: context, Hunk: 0, OldNum: 0, NewNum: 0, OldText: "a", NewText: "a"},
+				{Kind: deleted, Hunk: 0, OldNum: 1, NewNum: 1, OldText: "b", NewText: "B"},
+				{Kind: context, Hunk: 0, OldNum: 2, NewNum: 2, OldText: "c", NewText: "c"},
+			},
+		},
+		{
+			name:  "Inserted lines shift the context after them",
+			old:   []string{"a", "b"},
+			new:   []string{"x", "y", "a", "b"},
+			hunks: []diff.Hunk{{OldStart: 0, OldLines: 0, NewStart: 0, NewLines: 2}},
+			unified: []fileeditor.DiffRow{
+				{Kind: header, Hunk: 0, OldNum: -1, NewNum: -1, OldText: "@@ -1,0 +1,2 @@", NewText: "@@ -1,0 +1,2 @@"},
+				{Kind: added, Hunk: 0, OldNum: -1, NewNum: 0, NewText: "x"},
+				{Kind: added, Hunk: 0, OldNum: -1, NewNum: 1, NewText: "y"},
+				{Kind: context, Hunk: 0, OldNum: 0, NewNum: 2, OldText: "a", NewText: "a"},
+				{Kind: context, Hunk: 0, OldNum: 1, NewNum: 3, OldText: "b", NewText: "b"},
+			},
+			sideBySide: []fileeditor.DiffRow{
+				{Kind: header, Hunk: 0, OldNum: -1, NewNum: -1, OldText: "@@ -1,0 +1,2 @@", NewText: "@@ -1,0 +1,2 @@"},
+				{Kind: added, Hunk: 0, OldNum: -1, NewNum: 0, NewText: "x"},
+				{Kind: added, Hunk: 0, OldNum: -1, NewNum: 1, NewText: "y"},
+				{Kind: context, Hunk: 0, OldNum: 0, NewNum: 2, OldText: "a", NewText: "a"},
+				{Kind: context, Hunk: 0, OldNum: 1, NewNum: 3, OldText: "b", NewText: "b"},
+			},
+		},
+		{
+			name: "Deleted and added lines of different counts",
+			old:  []string{"a", "b", "c", "d"},
+			new:  []string{"a", "X", "d"},
+			hunks: []diff.Hunk{
+				{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 1},
+			},
+			unified: []fileeditor.DiffRow{
+				{Kind: header, Hunk: 0, OldNum: -1, NewNum: -1, OldText: "@@ -2,2 +2,1 @@", NewText: "@@ -2,2 +2,1 @@"},
+				{Kind: context, Hunk: 0, OldNum: 0, NewNum: 0, OldText: "a", NewText: "a"},
+				{Kind: deleted, Hunk: 0, OldNum: 1, NewNum: -1, OldText: "b"},
+				{Kind: deleted, Hunk: 0, OldNum: 2, NewNum: -1, OldText: "c"},
+				{Kind: added, Hunk: 0, OldNum: -1, NewNum: 1, NewText: "X"},
+				{Kind: context, Hunk: 0, OldNum: 3, NewNum: 2, OldText: "d", NewText: "d"},
+			},
+			sideBySide: []fileeditor.DiffRow{
+				{Kind: header, Hunk: 0, OldNum: -1, NewNum: -1, OldText: "@@ -2,2 +2,1 @@", NewText: "@@ -2,2 +2,1 @@"},
+				{Kind: context, Hunk: 0, OldNum: 0, NewNum: 0, OldText: "a", NewText: "a"},
+				{Kind: deleted, Hunk: 0, OldNum: 1, NewNum: 1, OldText: "b", NewText: "X"},
+				{Kind: deleted, Hunk: 0, OldNum: 2, NewNum: -1, OldText: "c"},
+				{Kind: context, Hunk: 0, OldNum: 3, NewNum: 2, OldText: "d", NewText: "d"},
+			},
+		},
+		{
+			name: "Context is limited and not repeated between hunks",
+			old:  []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"},
+			new:  []string{"one", "2", "3", "4", "5", "6", "7", "8", "nine"},
+			hunks: []diff.Hunk{
+				{OldStart: 0, OldLines: 1, NewStart: 0, NewLines: 1},
+				{OldStart: 8, OldLines: 1, NewStart: 8, NewLines: 1},
+			},
+			unified: []fileeditor.DiffRow{
+				{Kind: header, Hunk: 0, OldNum: -1, NewNum: -1, OldText: "@@ -1,1 +1,1 @@", NewText: "@@ -1,1 +1,1 @@"},
+				{Kind: deleted, Hunk: 0, OldNum: 0, NewNum: -1, OldText: "1"},
+				{Kind: added, Hunk: 0, OldNum: -1, NewNum: 0, NewText: "one"},
+				{Kind: context, Hunk: 0, OldNum: 1, NewNum: 1, OldText: "2", NewText: "2"},
+				{Kind: context, Hunk: 0, OldNum: 2, NewNum: 2, OldText: "3", NewText: "3"},
+				{Kind: context, Hunk: 0, OldNum: 3, NewNum: 3, OldText: "4", NewText: "4"},
+				{Kind: header, Hunk: 1, OldNum: -1, NewNum: -1, OldText: "@@ -9,1 +9,1 @@", NewText: "@@ -9,1 +9,1 @@"},
+				{Kind: context, Hunk: 1, OldNum: 5, NewNum: 5, OldText: "6", NewText: "6"},
+				{Kind: context, Hunk: 1, OldNum: 6, NewNum: 6, OldText: "7", NewText: "7"},
+				{Kind: context, Hunk: 1, OldNum: 7, NewNum: 7, OldText: "8", NewText: "8"},
+				{Kind: deleted, Hunk: 1, OldNum: 8, NewNum: -1, OldText: "9"},
+				{Kind: added, Hunk: 1, OldNum: -1, NewNum: 8, NewText: "nine"},
+			},
+			sideBySide: []fileeditor.DiffRow{
+				{Kind: header, Hunk: 0, OldNum: -1, NewNum: -1, OldText: "@@ -1,1 +1,1 @@", NewText: "@@ -1,1 +1,1 @@"},
+				{Kind: deleted, Hunk: 0, OldNum: 0, NewNum: 0, OldText: "1", NewText: "one"},
+				{Kind: context, Hunk: 0, OldNum: 1, NewNum: 1, OldText: "2", NewText: "2"},
+				{Kind: context, Hunk: 0, OldNum: 2, NewNum: 2, OldText: "3", NewText: "3"},
+				{Kind: context, Hunk: 0, OldNum: 3, NewNum: 3, OldText: "4", NewText: "4"},
+				{Kind: header, Hunk: 1, OldNum: -1, NewNum: -1, OldText: "@@ -9,1 +9,1 @@", NewText: "@@ -9,1 +9,1 @@"},
+				{Kind: context, Hunk: 1, OldNum: 5, NewNum: 5, OldText: "6", NewText: "6"},
+				{Kind: context, Hunk: 1, OldNum: 6, NewNum: 6, OldText: "7", NewText: "7"},
+				{Kind: context, Hunk: 1, OldNum: 7, NewNum: 7, OldText: "8", NewText: "8"},
+				{Kind: deleted, Hunk: 1, OldNum: 8, NewNum: 8, OldText: "9", NewText: "nine"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			unified, sideBySide := fileeditor.DiffRows(tc.hunks, tc.old, tc.new)
+			if !reflect.DeepEqual(unified, tc.unified) {
+				t.Fatalf("Expected: %v, got: %v\n", tc.unified, unified)
+			}
+			if !reflect.DeepEqual(sideBySide, tc.sideBySide) {
+				t.Fatalf("Expected: %v, got: %v\n", tc.sideBySide, sideBySide)
+			}
+		})
+	}
+}
+
+func TestRevertDiffHunk(t *testing.T) {
+	onDisk := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}
+	edited := []string{"one", "2", "3", "4", "6", "7", "8", "9", "10"} // three hunks
+
+	tests := []struct {
+		name     string
+		keys     string
+		expected []string
+	}{
+		{
+			name:     "First hunk",
+			keys:     "r",
+			expected: []string{"1", "2", "3", "4", "6", "7", "8", "9", "10"},
+		},
+		{
+			name:     "Middle hunk",
+			keys:     "nr",
+			expected: []string{"one", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+		},
+		{
+			name:     "Last hunk",
+			keys:     "Nr",
+			expected: []string{"one", "2", "3", "4", "6", "7", "8", "9"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTestEditor(t, "numbers.txt", onDisk)
+			f.FileBuffer = append([]string{}, edited...)
+			if err := f.OpenDiffView(""); err != nil {
+				t.Fatal(err)
+			}
+
+			typeKeys(f, tc.keys)
+			expectLines(t, f, tc.expected)
+
+			// undo brings the reverted hunk back
+			typeKeys(f, "q")
+			f.Undo()
+			expectLines(t, f, edited)
+		})
+	}
+}
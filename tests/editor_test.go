@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+/*
+Returns an editor with a file of the lines open, in a temporary directory
+that is also used as the config directory
+*/
+func newTestEditor(t *testing.T, name string, lines []string) *fileeditor.FileEditor {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := fileeditor.NewFileEditorWithSize(path, 80, 24)
+	f.OpenFile()
+	defer f.CloseFile()
+	if err := f.ReadFileToBuffer(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &f
+}
+
+/*
+Types the keys one at a time, where \x1b is escape
+*/
+func typeKeys(f *fileeditor.FileEditor, keys string) {
+	for i := 0; i < len(keys); i++ {
+		f.HandleEvent([]byte{keys[i]})
+	}
+}
+
+func expectLines(t *testing.T, f *fileeditor.FileEditor, expected []string) {
+	t.Helper()
+
+	if !slices.Equal(f.FileBuffer, expected) {
+		t.Fatalf("Expected: %q, got: %q\n", expected, f.FileBuffer)
+	}
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"slices"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestIndentFoldRegions(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected []fileeditor.FoldRegion
+	}{
+		{
+			name:     "nested blocks",
+			lines:    []string{"def f():", "    if x:", "        y()", "    return 1", "", "z = 2"},
+			expected: []fileeditor.FoldRegion{{Start: 0, End: 3}, {Start: 1, End: 2}},
+		},
+		{
+			name:     "blank lines inside of a block",
+			lines:    []string{"a:", "  b", "", "  c", "", "d"},
+			expected: []fileeditor.FoldRegion{{Start: 0, End: 3}},
+		},
+		{
+			name:     "tabs",
+			lines:    []string{"a", "\tb", "    c", "d"},
+			expected: []fileeditor.FoldRegion{{Start: 0, End: 2}},
+		},
+		{
+			name:     "no indentation",
+			lines:    []string{"a", "b"},
+			expected: []fileeditor.FoldRegion{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fileeditor.IndentFoldRegions(tc.lines, 4)
+			if !slices.Equal(got, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestBracketFoldRegions(t *testing.T) {
+	goFt := fileeditor.FiletypeFor("main.go")
+
+	tests := []struct {
+		name     string
+		lines    []string
+		expected []fileeditor.FoldRegion
+	}{
+		{
+			name: "function with a block",
+			lines: []string{
+				"func f() {",
+				"\tif x {",
+				"\t\ty()",
+				"\t}",
+				"}",
+			},
+			expected: []fileeditor.FoldRegion{{Start: 0, End: 4}, {Start: 1, End: 3}},
+		},
+		{
+			name: "else leaves the closer to the next region",
+			lines: []string{
+				"if x {",
+				"\ta()",
+				"} else {",
+				"\tb()",
+				"}",
+			},
+			expected: []fileeditor.FoldRegion{{Start: 0, End: 1}, {Start: 2, End: 4}},
+		},
+		{
+			name: "brackets in strings and comments",
+			lines: []string{
+				"s := \"{\"",
+				"// (",
+				"f(",
+				"\t1,",
+				")",
+			},
+			expected: []fileeditor.FoldRegion{{Start: 2, End: 4}},
+		},
+		{
+			name:     "brackets on one line",
+			lines:    []string{"f(a, b{})", "g()"},
+			expected: []fileeditor.FoldRegion{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fileeditor.BracketFoldRegions(tc.lines, goFt)
+			if !slices.Equal(got, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestEditingClosedFoldOpensIt(t *testing.T) {
+	lines := []string{
+		"func main() {",
+		"\tx := 1",
+		"}",
+		"end",
+	}
+
+	tests := []struct {
+		name     string
+		keys     string
+		expected int // the line that j moves to from the first line
+	}{
+		{name: "Closed", keys: "zc", expected: 3},
+		{name: "Indented", keys: "zc>>", expected: 1},
+		{name: "Lines replaced with as many lines", keys: "zc\rsort\r", expected: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTestEditor(t, "main.go", lines)
+			typeKeys(f, tc.keys+"ggj")
+			if line, _ := f.CursorBufferPos(); line != tc.expected {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, line)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Asiandayboy/CLITextEditor/util/fuzzy"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		str       string
+		ok        bool
+		positions []int
+	}{
+		{name: "Empty pattern", pattern: "", str: "main.go", ok: true, positions: nil},
+		{name: "Exact match", pattern: "main", str: "main.go", ok: true, positions: []int{0, 1, 2, 3}},
+		{name: "Ignores case", pattern: "MAIN", str: "main.go", ok: true, positions: []int{0, 1, 2, 3}},
+		{name: "Subsequence", pattern: "dgo", str: "diff.go", ok: true, positions: []int{0, 5, 6}},
+		{name: "Shortest match", pattern: "ab", str: "a_a_ab", ok: true, positions: []int{4, 5}},
+		{name: "Out of order", pattern: "ba", str: "ab", ok: false, positions: nil},
+		{name: "Missing character", pattern: "mainx", str: "main.go", ok: false, positions: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, positions, ok := fuzzy.Match(tc.pattern, tc.str)
+			if ok != tc.ok || !reflect.DeepEqual(positions, tc.positions) {
+				t.Fatalf("Expected: %v %v, got: %v %v\n", tc.ok, tc.positions, ok, positions)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchRanking(t *testing.T) {
+	tests := []struct {
+		name          string
+		pattern       string
+		better, worse string
+	}{
+		{name: "Consecutive over scattered", pattern: "diff", better: "util/diff.go", worse: "d/i/f/f.go"},
+		{name: "Basename over directory", pattern: "buf", better: "fileEditor/buffer.go", worse: "buf/editor.go"},
+		{name: "Word start over middle", pattern: "fe", better: "file_editor.go", worse: "safe.go"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			better, _, _ := fuzzy.Match(tc.pattern, tc.better)
+			worse, _, _ := fuzzy.Match(tc.pattern, tc.worse)
+			if better <= worse {
+				t.Fatalf("Expected: %s (%d) > %s (%d)\n", tc.better, better, tc.worse, worse)
+			}
+		})
+	}
+}
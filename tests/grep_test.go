@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestSearchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	content := "package main\n\nfunc main() {\n\tfmt.Println(\"a.b\", \"axb\")\n}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		literal  bool
+		expected []fileeditor.GrepMatch
+	}{
+		{
+			name:    "Regex",
+			pattern: "ma[a-z]n",
+			expected: []fileeditor.GrepMatch{
+				{Line: 0, Text: "package main", Ranges: [][2]int{{8, 12}}},
+				{Line: 2, Text: "func main() {", Ranges: [][2]int{{5, 9}}},
+			},
+		},
+		{
+			name:    "Regex dot matches any character",
+			pattern: "a.b",
+			expected: []fileeditor.GrepMatch{
+				{Line: 3, Text: "\tfmt.Println(\"a.b\", \"axb\")", Ranges: [][2]int{{14, 17}, {21, 24}}},
+			},
+		},
+		{
+			name:    "Literal",
+			pattern: "a.b",
+			literal: true,
+			expected: []fileeditor.GrepMatch{
+				{Line: 3, Text: "\tfmt.Println(\"a.b\", \"axb\")", Ranges: [][2]int{{14, 17}}},
+			},
+		},
+		{
+			name:     "No match",
+			pattern:  "missing",
+			expected: []fileeditor.GrepMatch{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			re, err := fileeditor.CompileSearchPattern(tc.pattern, tc.literal)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := fileeditor.SearchFile(path, re)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	gitignore := `# build output
+*.log
+!keep.log
+build/
+/root.txt
+docs/*.md
+**/generated
+**/build/out
+`
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte(gitignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := fileeditor.LoadIgnoreMatcher(root)
+
+	tests := []struct {
+		name     string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{name: "glob", path: "debug.log", expected: true},
+		{name: "glob in a directory", path: "src/debug.log", expected: true},
+		{name: "negation", path: "keep.log", expected: false},
+		{name: "negation in a directory", path: "src/keep.log", expected: false},
+		{name: "not matched", path: "main.go", expected: false},
+		{name: "dir-only matches a directory", path: "build", isDir: true, expected: true},
+		{name: "dir-only matches a nested directory", path: "src/build", isDir: true, expected: true},
+		{name: "dir-only skips a file", path: "build", expected: false},
+		{name: "anchored with a leading slash", path: "root.txt", expected: true},
+		{name: "anchored with a leading slash in a directory", path: "src/root.txt", expected: false},
+		{name: "anchored with a slash in the middle", path: "docs/readme.md", expected: true},
+		{name: "anchored with a slash in the middle in a directory", path: "src/docs/readme.md", expected: false},
+		{name: "leading **/ is trimmed", path: "generated", isDir: true, expected: true},
+		{name: "leading **/ matches in a directory", path: "src/api/generated", expected: true},
+		{name: "leading **/ with a slash matches at the root", path: "build/out", expected: true},
+		{name: "leading **/ with a slash matches in a directory", path: "src/build/out", expected: true},
+		{name: "leading **/ with a slash matches the whole names", path: "src/rebuild/out", expected: false},
+		{name: "leading **/ with a slash skips other names", path: "src/build/other", expected: false},
+		{name: ".git is always ignored", path: ".git", isDir: true, expected: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := m.IsIgnored(filepath.FromSlash(tc.path), tc.isDir)
+			if got != tc.expected {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcherWithoutGitignore(t *testing.T) {
+	m := fileeditor.LoadIgnoreMatcher(t.TempDir())
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{path: "debug.log", expected: false},
+		{path: "build", isDir: true, expected: false},
+		{path: ".git", isDir: true, expected: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			got := m.IsIgnored(tc.path, tc.isDir)
+			if got != tc.expected {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+}
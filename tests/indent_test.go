@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestDetectIndent(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []string
+		indentType uint8
+		size       uint8
+		ok         bool
+	}{
+		{
+			name:       "tabs",
+			lines:      []string{"func main() {", "\tif true {", "\t\treturn", "\t}", "}"},
+			indentType: fileeditor.IndentWithTab,
+			ok:         true,
+		},
+		{
+			name:       "two spaces",
+			lines:      []string{"a:", "  b:", "    c: 1", "  d: 2", "e: 3"},
+			indentType: fileeditor.IndentWithSpace,
+			size:       2,
+			ok:         true,
+		},
+		{
+			name:       "four spaces with a continuation line",
+			lines:      []string{"def f(a,", "        b):", "    if a:", "        return b", "    return a"},
+			indentType: fileeditor.IndentWithSpace,
+			size:       4,
+			ok:         true,
+		},
+		{
+			name:  "comment alignment is not indentation",
+			lines: []string{"/*", " * comment", " */", "int x;"},
+			ok:    false,
+		},
+		{
+			name:  "no indented lines",
+			lines: []string{"a", "", "b"},
+			ok:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			indentType, size, ok := fileeditor.DetectIndent(tc.lines)
+			if ok != tc.ok || indentType != tc.indentType || size != tc.size {
+				t.Fatalf("Expected: %v %v %v, got: %v %v %v\n", tc.indentType, tc.size, tc.ok, indentType, size, ok)
+			}
+		})
+	}
+}
+
+func TestNewLineIndent(t *testing.T) {
+	goFt := fileeditor.FiletypeFor("main.go")
+	pyFt := fileeditor.FiletypeFor("main.py")
+
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		ft       fileeditor.Filetype
+		expected string
+		closed   bool
+	}{
+		{name: "keeps the indentation", before: "\tx := 1", ft: goFt, expected: "\t"},
+		{name: "indents after an opener", before: "\tif x {", ft: goFt, expected: "\t\t"},
+		{name: "indents between brackets", before: "\tf(", after: ")", ft: goFt, expected: "\t\t", closed: true},
+		{name: "colon in python", before: "    if x:  ", ft: pyFt, expected: "        "},
+		{name: "colon in go", before: "\tcase 1:", ft: goFt, expected: "\t"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			unit := "\t"
+			if tc.ft.TabIndentType == fileeditor.IndentWithSpace {
+				unit = "    "
+			}
+
+			got, closed := fileeditor.NewLineIndent(tc.before, tc.after, tc.ft, unit)
+			if got != tc.expected || closed != tc.closed {
+				t.Fatalf("Expected: %q %v, got: %q %v\n", tc.expected, tc.closed, got, closed)
+			}
+		})
+	}
+}
+
+func TestRetabLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		toTabs   bool
+		oldSize  int
+		newSize  int
+		expected string
+	}{
+		{name: "tabs to spaces", line: "\t\tx", oldSize: 4, newSize: 4, expected: "        x"},
+		{name: "tabs to two spaces", line: "\t\tx", oldSize: 4, newSize: 2, expected: "    x"},
+		{name: "spaces to tabs", line: "        x", toTabs: true, oldSize: 4, newSize: 4, expected: "\t\tx"},
+		{name: "partial level kept as spaces", line: "      x", toTabs: true, oldSize: 4, newSize: 4, expected: "\t  x"},
+		{name: "mixed indentation", line: "  \tx", toTabs: true, oldSize: 4, newSize: 4, expected: "\tx"},
+		{name: "blank line", line: " \t ", oldSize: 4, newSize: 4, expected: ""},
+		{name: "unindented line", line: "x\ty", oldSize: 4, newSize: 4, expected: "x\ty"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fileeditor.RetabLine(tc.line, tc.toTabs, tc.oldSize, tc.newSize)
+			if got != tc.expected {
+				t.Fatalf("Expected: %q, got: %q\n", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDedentClosingBracket(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		lines    []string
+		softWrap bool
+		keys     string
+		expected []string
+	}{
+		{
+			name:     "tabs",
+			filename: "main.go",
+			lines:    []string{"func f() {", "\tif x {"},
+			softWrap: true,
+			keys:     "jA\r}",
+			expected: []string{"func f() {", "\tif x {", "\t}"},
+		},
+		{
+			name:     "spaces without soft wrap",
+			filename: "main.go",
+			lines:    []string{"func f() {", "    if x {", "        y()"},
+			keys:     "jjA\r}",
+			expected: []string{"func f() {", "    if x {", "        y()", "    }"},
+		},
+		{
+			name:     "not the first character",
+			filename: "main.go",
+			lines:    []string{"func f() {", "\tx := []int{1,"},
+			softWrap: true,
+			keys:     "jA\r2}",
+			expected: []string{"func f() {", "\tx := []int{1,", "\t2}"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTestEditor(t, tc.filename, tc.lines)
+			f.SoftWrapEnabled = tc.softWrap
+			typeKeys(f, tc.keys)
+			expectLines(t, f, tc.expected)
+		})
+	}
+}
+
+func TestDedentClosingBracketAtCursors(t *testing.T) {
+	f := newTestEditor(t, "main.go", []string{"if a { if b {", "\tx", "\ty"})
+	typeKeys(f, "jA")
+	f.HandleEvent([]byte("\x1b[1;3B")) // alt+down adds a cursor below
+	typeKeys(f, "\r}")
+
+	expectLines(t, f, []string{"if a { if b {", "\tx", "}", "\ty", "}"})
+}
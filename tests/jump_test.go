@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestParseLineColumn(t *testing.T) {
+	tests := []struct {
+		location string
+		line     int
+		col      int
+		ok       bool
+	}{
+		{location: "12", line: 12, ok: true},
+		{location: "12:5", line: 12, col: 5, ok: true},
+		{location: " 3 ", line: 3, ok: true},
+		{location: "0", ok: false},
+		{location: "12:", ok: false},
+		{location: "abc", ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.location, func(t *testing.T) {
+			line, col, err := fileeditor.ParseLineColumn(tc.location)
+			if (err == nil) != tc.ok || line != tc.line || col != tc.col {
+				t.Fatalf("Expected: %v %v %v, got: %v %v %v\n", tc.line, tc.col, tc.ok, line, col, err)
+			}
+		})
+	}
+}
+
+func TestJumpList(t *testing.T) {
+	jump := func(file string, line int) fileeditor.Jump {
+		return fileeditor.Jump{Filename: file, Pos: fileeditor.BufferPos{Line: line}}
+	}
+
+	var l fileeditor.JumpList
+	l.Push(jump("a.go", 1))
+	l.Push(jump("b.go", 5))
+	l.Push(jump("a.go", 10))
+
+	steps := []struct {
+		name     string
+		back     bool
+		expected fileeditor.Jump
+		ok       bool
+	}{
+		{name: "back to the last jump", back: true, expected: jump("a.go", 10), ok: true},
+		{name: "back across buffers", back: true, expected: jump("b.go", 5), ok: true},
+		{name: "back to the oldest", back: true, expected: jump("a.go", 1), ok: true},
+		{name: "nothing before the oldest", back: true, ok: false},
+		{name: "forward", expected: jump("b.go", 5), ok: true},
+		{name: "forward to the last jump", expected: jump("a.go", 10), ok: true},
+		{name: "forward to where back started", expected: jump("c.go", 7), ok: true},
+		{name: "nothing after the newest", ok: false},
+	}
+
+	for _, step := range steps {
+		var got fileeditor.Jump
+		var ok bool
+		if step.back {
+			got, ok = l.Back(jump("c.go", 7))
+		} else {
+			got, ok = l.Forward()
+		}
+
+		if ok != step.ok || (ok && got != step.expected) {
+			t.Fatalf("%s: Expected: %v %v, got: %v %v\n", step.name, step.expected, step.ok, got, ok)
+		}
+	}
+
+	// jumping after going back drops the jumps ahead, and a line is only in the list once
+	l.Back(jump("c.go", 7))
+	l.Back(jump("c.go", 7))
+	l.Push(jump("a.go", 1))
+
+	got, ok := l.Back(jump("d.go", 0))
+	if expected := jump("a.go", 1); !ok || got != expected {
+		t.Fatalf("Expected: %v, got: %v\n", expected, got)
+	}
+	if _, ok := l.Back(jump("d.go", 0)); ok {
+		t.Fatalf("Expected: %v, got: %v\n", false, ok)
+	}
+}
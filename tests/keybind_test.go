@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestDefaultKeybindsRunRegisteredActions(t *testing.T) {
+	k := fileeditor.NewKeybind()
+
+	for _, key := range []byte{fileeditor.CtrlO, fileeditor.CtrlP, fileeditor.CtrlS, fileeditor.CtrlT, fileeditor.CtrlW} {
+		for _, mode := range []byte{fileeditor.EditorCommandMode, fileeditor.EditorEditMode, fileeditor.EditorViewMode} {
+			binding, _ := k.Lookup(mode, []byte{key})
+			if binding == nil || fileeditor.LookupAction(binding.Action) == nil {
+				t.Fatalf("Expected: key %d to run a registered action in mode %c, got: %v\n", key, mode, binding)
+			}
+		}
+	}
+}
+
+func TestChangeKeybind(t *testing.T) {
+	k := fileeditor.NewKeybind()
+	k.ChangeKeybind(fileeditor.ActionToggleFileTree, fileeditor.CtrlE)
+
+	for _, mode := range []byte{fileeditor.EditorCommandMode, fileeditor.EditorEditMode, fileeditor.EditorViewMode} {
+		if binding, _ := k.Lookup(mode, []byte{fileeditor.CtrlT}); binding != nil {
+			t.Fatalf("Expected: the old key to be unbound, got: %v\n", binding)
+		}
+
+		if binding, _ := k.Lookup(mode, []byte{fileeditor.CtrlE}); binding == nil || binding.Action != fileeditor.ActionToggleFileTree {
+			t.Fatalf("Expected: %q, got: %v\n", fileeditor.ActionToggleFileTree, binding)
+		}
+
+		if keys := k.KeysForAction(mode, fileeditor.ActionToggleFileTree); string(keys) != string([]byte{fileeditor.CtrlE}) {
+			t.Fatalf("Expected: %v, got: %v\n", []byte{fileeditor.CtrlE}, keys)
+		}
+	}
+
+	if k.ToggleFileTree != fileeditor.CtrlE {
+		t.Fatalf("Expected: %d, got: %d\n", fileeditor.CtrlE, k.ToggleFileTree)
+	}
+}
+
+func TestKeybindLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keymap.conf")
+	config := `# comment
+leader ,
+timeout 300
+map C <leader> g = FindFile
+map CV g g = :goto 1
+map * <C-e> = Save
+unmap C <Space> f f
+map X q = Save
+map C q = NotAnAction
+`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	k := fileeditor.NewKeybind()
+	err := k.LoadConfig(path)
+	if err == nil {
+		t.Fatalf("Expected: an error for the invalid lines, got: nil\n")
+	}
+
+	if k.Leader != ',' || k.Timeout != 300*time.Millisecond {
+		t.Fatalf("Expected: leader ',' and timeout 300ms, got: %q %v\n", k.Leader, k.Timeout)
+	}
+
+	tests := []struct {
+		name     string
+		mode     byte
+		keys     string
+		expected string
+	}{
+		{name: "Leader sequence", mode: fileeditor.EditorCommandMode, keys: ",g", expected: fileeditor.ActionFindFile},
+		{name: "Command binding", mode: fileeditor.EditorViewMode, keys: "gg", expected: ":goto 1"},
+		{name: "Every mode", mode: fileeditor.EditorEditMode, keys: "\x05", expected: fileeditor.ActionSave},
+		{name: "Unmapped", mode: fileeditor.EditorCommandMode, keys: " ff", expected: ""},
+		{name: "Default still bound", mode: fileeditor.EditorCommandMode, keys: " fs", expected: fileeditor.ActionSave},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			binding, _ := k.Lookup(tc.mode, []byte(tc.keys))
+			got := ""
+			if binding != nil {
+				got = binding.String()
+			}
+			if got != tc.expected {
+				t.Fatalf("Expected: %q, got: %q\n", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestKeymapLookup(t *testing.T) {
+	m := fileeditor.NewKeymap()
+	m.Bind([]byte("gg"), fileeditor.Binding{Action: "Top"})
+	m.Bind([]byte("g"), fileeditor.Binding{Action: "Go"})
+	m.Bind([]byte("dd"), fileeditor.Binding{Command: "delete line"})
+
+	tests := []struct {
+		name     string
+		keys     string
+		binding  string
+		isPrefix bool
+	}{
+		{name: "Ambiguous prefix", keys: "g", binding: "Go", isPrefix: true},
+		{name: "Complete sequence", keys: "gg", binding: "Top", isPrefix: false},
+		{name: "Prefix without binding", keys: "d", binding: "", isPrefix: true},
+		{name: "Command binding", keys: "dd", binding: ":delete line", isPrefix: false},
+		{name: "No match", keys: "gx", binding: "", isPrefix: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			binding, isPrefix := m.Lookup([]byte(tc.keys))
+			got := ""
+			if binding != nil {
+				got = binding.String()
+			}
+			if got != tc.binding || isPrefix != tc.isPrefix {
+				t.Fatalf("Expected: %q %v, got: %q %v\n", tc.binding, tc.isPrefix, got, isPrefix)
+			}
+		})
+	}
+
+	m.Unbind([]byte("gg"))
+	if _, isPrefix := m.Lookup([]byte("g")); isPrefix {
+		t.Fatalf("Expected: g to no longer be a prefix after unbinding gg\n")
+	}
+}
+
+func TestParseKeySequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []byte
+		err      bool
+	}{
+		{name: "Separate keys", input: "g g", expected: []byte("gg")},
+		{name: "Word", input: "gg", expected: []byte("gg")},
+		{name: "Leader", input: "<leader> f s", expected: []byte(" fs")},
+		{name: "Ctrl", input: "<C-t>", expected: []byte{fileeditor.CtrlT}},
+		{name: "Named keys", input: "<Tab> <Enter> <BS>", expected: []byte{fileeditor.Tab, fileeditor.NewLine, fileeditor.Backspace}},
+		{name: "Unknown name", input: "<nope>", err: true},
+		{name: "Empty", input: "  ", err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := fileeditor.ParseKeySequence(tc.input, fileeditor.Space)
+			if (err != nil) != tc.err || (!tc.err && !reflect.DeepEqual(got, tc.expected)) {
+				t.Fatalf("Expected: %v (error: %v), got: %v (%v)\n", tc.expected, tc.err, got, err)
+			}
+		})
+	}
+}
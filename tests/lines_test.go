@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"slices"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestJoinLineTexts(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected string
+	}{
+		{name: "indentation is removed", lines: []string{"\tfoo(a,  ", "\t\tb,", "\t\tc"}, expected: "\tfoo(a, b, c"},
+		{name: "no space before a closing bracket", lines: []string{"f(", "\tx", ")"}, expected: "f( x)"},
+		{name: "empty lines", lines: []string{"a", "", "  ", "b"}, expected: "a b"},
+		{name: "empty first line", lines: []string{"", "  b"}, expected: "b"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fileeditor.JoinLineTexts(tc.lines)
+			if got != tc.expected {
+				t.Fatalf("Expected: %q, got: %q\n", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSortLineTexts(t *testing.T) {
+	lines := []string{"item 10", "b", "item 9", "a", "item -2", "b"}
+
+	tests := []struct {
+		name     string
+		numeric  bool
+		reverse  bool
+		unique   bool
+		expected []string
+	}{
+		{name: "lexical", expected: []string{"a", "b", "b", "item -2", "item 10", "item 9"}},
+		{name: "numeric", numeric: true, expected: []string{"b", "a", "b", "item -2", "item 9", "item 10"}},
+		{name: "reverse", reverse: true, expected: []string{"item 9", "item 10", "item -2", "b", "b", "a"}},
+		{name: "unique", unique: true, expected: []string{"a", "b", "item -2", "item 10", "item 9"}},
+		{name: "numeric, reverse and unique", numeric: true, reverse: true, unique: true,
+			expected: []string{"item 10", "item 9", "item -2", "b", "a"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fileeditor.SortLineTexts(lines, tc.numeric, tc.reverse, tc.unique)
+			if !slices.Equal(got, tc.expected) {
+				t.Fatalf("Expected: %q, got: %q\n", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"testing"
+)
+
+func TestMacros(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		keys     string
+		expected []string
+	}{
+		{
+			name:     "plays count times",
+			lines:    []string{"a", "b", "c", "d"},
+			keys:     "qaddq2@a",
+			expected: []string{"d"},
+		},
+		{
+			name:     "undone in one step",
+			lines:    []string{"a", "b", "c", "d"},
+			keys:     "qaddq2@au",
+			expected: []string{"b", "c", "d"},
+		},
+		{
+			name:     "appends to a macro",
+			lines:    []string{"a", "b", "c", "d", "e"},
+			keys:     "qaddqqAjq@a",
+			expected: []string{"b", "d", "e"},
+		},
+		{
+			name:     "stops at a motion that fails",
+			lines:    []string{"x1", "x2", "x3"},
+			keys:     "qwfaddq5@w",
+			expected: []string{"x2", "x3"},
+		},
+		{
+			name:     "keeps the undo step before a macro that undoes",
+			lines:    []string{"a", "b", "c"},
+			keys:     "ddqzdduq@zu",
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "edits in Edit mode",
+			lines:    []string{"a", "b"},
+			keys:     "qqI-\x1bjq@q",
+			expected: []string{"-a", "-b"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTestEditor(t, "notes.txt", tc.lines)
+			typeKeys(f, tc.keys)
+			expectLines(t, f, tc.expected)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestAdjustMarkLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     int
+		start    int
+		count    int
+		newCount int
+		expected int
+		ok       bool
+	}{
+		{name: "before the change", line: 2, start: 5, count: 1, newCount: 3, expected: 2, ok: true},
+		{name: "lines inserted above", line: 5, start: 5, count: 0, newCount: 2, expected: 7, ok: true},
+		{name: "lines deleted above", line: 9, start: 2, count: 3, newCount: 0, expected: 6, ok: true},
+		{name: "line deleted", line: 3, start: 2, count: 3, newCount: 0, ok: false},
+		{name: "line joined with the one before it", line: 4, start: 3, count: 2, newCount: 1, expected: 3, ok: true},
+		{name: "line split", line: 4, start: 4, count: 1, newCount: 2, expected: 4, ok: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := fileeditor.AdjustMarkLine(tc.line, tc.start, tc.count, tc.newCount)
+			if ok != tc.ok || (ok && got != tc.expected) {
+				t.Fatalf("Expected: %v %v, got: %v %v\n", tc.expected, tc.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestMovedMarkLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     int
+		first    int
+		last     int
+		step     int
+		expected int
+	}{
+		{name: "moved line down", line: 3, first: 2, last: 4, step: 1, expected: 4},
+		{name: "moved line up", line: 2, first: 2, last: 4, step: -1, expected: 1},
+		{name: "line below moved over", line: 5, first: 2, last: 4, step: 1, expected: 2},
+		{name: "line above moved over", line: 1, first: 2, last: 4, step: -1, expected: 4},
+		{name: "line above", line: 0, first: 2, last: 4, step: 1, expected: 0},
+		{name: "line below", line: 6, first: 2, last: 4, step: -1, expected: 6},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fileeditor.MovedMarkLine(tc.line, tc.first, tc.last, tc.step)
+			if got != tc.expected {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMarksMoveWithMovedLines(t *testing.T) {
+	f := newTestEditor(t, "marks.txt", []string{"a", "b", "c", "d"})
+
+	// a mark on the moved line, and one on the line it moves over
+	typeKeys(f, "jmajmbk")
+	f.MoveLines(true)
+	expectLines(t, f, []string{"a", "c", "b", "d"})
+
+	typeKeys(f, "'a")
+	if line, _ := f.CursorBufferPos(); line != 2 {
+		t.Fatalf("Expected: %v, got: %v\n", 2, line)
+	}
+	typeKeys(f, "'b")
+	if line, _ := f.CursorBufferPos(); line != 1 {
+		t.Fatalf("Expected: %v, got: %v\n", 1, line)
+	}
+}
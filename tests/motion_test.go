@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestWordMotions(t *testing.T) {
+	lines := []string{
+		"foo.bar(baz)  qux",
+		"",
+		"\tlast_word",
+	}
+
+	tests := []struct {
+		name     string
+		motion   func([]string, fileeditor.BufferPos, string) fileeditor.BufferPos
+		start    fileeditor.BufferPos
+		expected fileeditor.BufferPos
+	}{
+		{name: "w stops at punctuation", motion: fileeditor.NextWordStart, start: fileeditor.BufferPos{Line: 0, Index: 0}, expected: fileeditor.BufferPos{Line: 0, Index: 3}},
+		{name: "w skips whitespace", motion: fileeditor.NextWordStart, start: fileeditor.BufferPos{Line: 0, Index: 11}, expected: fileeditor.BufferPos{Line: 0, Index: 14}},
+		{name: "w stops at an empty line", motion: fileeditor.NextWordStart, start: fileeditor.BufferPos{Line: 0, Index: 14}, expected: fileeditor.BufferPos{Line: 1, Index: 0}},
+		{name: "w skips indentation", motion: fileeditor.NextWordStart, start: fileeditor.BufferPos{Line: 1, Index: 0}, expected: fileeditor.BufferPos{Line: 2, Index: 1}},
+		{name: "w at the end", motion: fileeditor.NextWordStart, start: fileeditor.BufferPos{Line: 2, Index: 3}, expected: fileeditor.BufferPos{Line: 2, Index: 10}},
+		{name: "b to the start of the word", motion: fileeditor.PrevWordStart, start: fileeditor.BufferPos{Line: 0, Index: 6}, expected: fileeditor.BufferPos{Line: 0, Index: 4}},
+		{name: "b across lines", motion: fileeditor.PrevWordStart, start: fileeditor.BufferPos{Line: 2, Index: 1}, expected: fileeditor.BufferPos{Line: 1, Index: 0}},
+		{name: "b at the start", motion: fileeditor.PrevWordStart, start: fileeditor.BufferPos{Line: 0, Index: 0}, expected: fileeditor.BufferPos{Line: 0, Index: 0}},
+		{name: "e to the end of the word", motion: fileeditor.NextWordEnd, start: fileeditor.BufferPos{Line: 0, Index: 0}, expected: fileeditor.BufferPos{Line: 0, Index: 2}},
+		{name: "e from the end of a word", motion: fileeditor.NextWordEnd, start: fileeditor.BufferPos{Line: 0, Index: 2}, expected: fileeditor.BufferPos{Line: 0, Index: 3}},
+		{name: "e skips empty lines", motion: fileeditor.NextWordEnd, start: fileeditor.BufferPos{Line: 0, Index: 16}, expected: fileeditor.BufferPos{Line: 2, Index: 9}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.motion(lines, tc.start, "_")
+			if got != tc.expected {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFindChar(t *testing.T) {
+	line := "a(b, c(d))"
+
+	tests := []struct {
+		name     string
+		start    int
+		count    int
+		forward  bool
+		till     bool
+		expected int
+		ok       bool
+	}{
+		{name: "f", start: 0, count: 1, forward: true, expected: 1, ok: true},
+		{name: "2f", start: 0, count: 2, forward: true, expected: 6, ok: true},
+		{name: "t", start: 0, count: 1, forward: true, till: true, expected: 0, ok: true},
+		{name: "F", start: 9, count: 1, forward: false, expected: 6, ok: true},
+		{name: "T", start: 9, count: 1, forward: false, till: true, expected: 7, ok: true},
+		{name: "Not found", start: 0, count: 3, forward: true, expected: 0, ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := fileeditor.FindChar(line, fileeditor.BufferPos{Index: tc.start}, '(', tc.count, tc.forward, tc.till)
+			if got.Index != tc.expected || ok != tc.ok {
+				t.Fatalf("Expected: %d %v, got: %d %v\n", tc.expected, tc.ok, got.Index, ok)
+			}
+		})
+	}
+}
+
+func TestRangeEdits(t *testing.T) {
+	var f fileeditor.FileEditor
+	f.FileBuffer = []string{"one two", "three", "four five"}
+
+	start := fileeditor.BufferPos{Line: 0, Index: 4}
+	end := fileeditor.BufferPos{Line: 2, Index: 5}
+
+	if text := f.TextInRange(start, end); text != "two\nthree\nfour " {
+		t.Fatalf("Expected: %q, got: %q\n", "two\nthree\nfour ", text)
+	}
+
+	f.DeleteRange(start, end)
+	if len(f.FileBuffer) != 1 || f.FileBuffer[0] != "one five" {
+		t.Fatalf("Expected: %q, got: %q\n", []string{"one five"}, f.FileBuffer)
+	}
+
+	after := f.InsertText(start, "2\n3\n4 ")
+	if len(f.FileBuffer) != 3 || f.FileBuffer[0] != "one 2" || f.FileBuffer[2] != "4 five" {
+		t.Fatalf("Expected: %q, got: %q\n", []string{"one 2", "3", "4 five"}, f.FileBuffer)
+	}
+	if after != (fileeditor.BufferPos{Line: 2, Index: 2}) {
+		t.Fatalf("Expected: %v, got: %v\n", fileeditor.BufferPos{Line: 2, Index: 2}, after)
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestParagraphMotions(t *testing.T) {
+	lines := []string{
+		"first",
+		"paragraph",
+		"",
+		"  ",
+		"second",
+		"last line",
+	}
+
+	tests := []struct {
+		name     string
+		forward  bool
+		start    fileeditor.BufferPos
+		count    int
+		expected fileeditor.BufferPos
+	}{
+		{name: "} to the blank line after", forward: true, start: fileeditor.BufferPos{Line: 0, Index: 2}, count: 1, expected: fileeditor.BufferPos{Line: 2}},
+		{name: "} from a blank line", forward: true, start: fileeditor.BufferPos{Line: 2}, count: 1, expected: fileeditor.BufferPos{Line: 5, Index: 9}},
+		{name: "} with a count", forward: true, start: fileeditor.BufferPos{Line: 0}, count: 2, expected: fileeditor.BufferPos{Line: 5, Index: 9}},
+		{name: "{ to the blank line before", start: fileeditor.BufferPos{Line: 5, Index: 3}, count: 1, expected: fileeditor.BufferPos{Line: 3}},
+		{name: "{ to the start", start: fileeditor.BufferPos{Line: 2}, count: 1, expected: fileeditor.BufferPos{Line: 0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got fileeditor.BufferPos
+			if tc.forward {
+				got = fileeditor.NextParagraph(lines, tc.start, tc.count)
+			} else {
+				got = fileeditor.PrevParagraph(lines, tc.start, tc.count)
+			}
+
+			if got != tc.expected {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMatchingBracket(t *testing.T) {
+	lines := []string{
+		"if (a[0] == b) {",
+		"\tcall(\"\\(\")",
+		"}",
+	}
+
+	tests := []struct {
+		name     string
+		start    fileeditor.BufferPos
+		expected fileeditor.BufferPos
+		ok       bool
+	}{
+		{name: "forward", start: fileeditor.BufferPos{Line: 0, Index: 3}, expected: fileeditor.BufferPos{Line: 0, Index: 13}, ok: true},
+		{name: "nested", start: fileeditor.BufferPos{Line: 0, Index: 5}, expected: fileeditor.BufferPos{Line: 0, Index: 7}, ok: true},
+		{name: "across lines", start: fileeditor.BufferPos{Line: 0, Index: 15}, expected: fileeditor.BufferPos{Line: 2, Index: 0}, ok: true},
+		{name: "backward", start: fileeditor.BufferPos{Line: 2, Index: 0}, expected: fileeditor.BufferPos{Line: 0, Index: 15}, ok: true},
+		{name: "escaped brackets don't count", start: fileeditor.BufferPos{Line: 1, Index: 5}, expected: fileeditor.BufferPos{Line: 1, Index: 10}, ok: true},
+		{name: "first bracket after the position", start: fileeditor.BufferPos{Line: 1, Index: 0}, expected: fileeditor.BufferPos{Line: 1, Index: 10}, ok: true},
+		{name: "no bracket", start: fileeditor.BufferPos{Line: 1, Index: 11}, expected: fileeditor.BufferPos{Line: 1, Index: 11}, ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := fileeditor.MatchingBracket(lines, tc.start)
+			if got != tc.expected || ok != tc.ok {
+				t.Fatalf("Expected: %v %v, got: %v %v\n", tc.expected, tc.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestWordChars(t *testing.T) {
+	line := []string{"foo-bar baz"}
+
+	got := fileeditor.NextWordStart(line, fileeditor.BufferPos{}, "_")
+	if expected := (fileeditor.BufferPos{Line: 0, Index: 3}); got != expected {
+		t.Fatalf("Expected: %v, got: %v\n", expected, got)
+	}
+
+	got = fileeditor.NextWordStart(line, fileeditor.BufferPos{}, "_-")
+	if expected := (fileeditor.BufferPos{Line: 0, Index: 8}); got != expected {
+		t.Fatalf("Expected: %v, got: %v\n", expected, got)
+	}
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestApplyEdits(t *testing.T) {
+	pos := func(line, index int) fileeditor.BufferPos {
+		return fileeditor.BufferPos{Line: line, Index: index}
+	}
+	insert := func(p fileeditor.BufferPos, text string) fileeditor.TextEdit {
+		return fileeditor.TextEdit{Start: p, End: p, Text: text}
+	}
+
+	tests := []struct {
+		name     string
+		lines    []string
+		edits    []fileeditor.TextEdit
+		expected []string
+		ends     []fileeditor.BufferPos
+	}{
+		{
+			name:     "insert at every cursor",
+			lines:    []string{"a = 1", "bb = 2", "c = 3"},
+			edits:    []fileeditor.TextEdit{insert(pos(2, 1), "x"), insert(pos(0, 1), "x"), insert(pos(1, 2), "x")},
+			expected: []string{"ax = 1", "bbx = 2", "cx = 3"},
+			ends:     []fileeditor.BufferPos{pos(2, 2), pos(0, 2), pos(1, 3)},
+		},
+		{
+			name:     "insert twice on a line",
+			lines:    []string{"foo(foo)"},
+			edits:    []fileeditor.TextEdit{insert(pos(0, 3), "Bar"), insert(pos(0, 7), "Bar")},
+			expected: []string{"fooBar(fooBar)"},
+			ends:     []fileeditor.BufferPos{pos(0, 6), pos(0, 13)},
+		},
+		{
+			name:     "new lines shift the edits after them",
+			lines:    []string{"ab", "cd"},
+			edits:    []fileeditor.TextEdit{insert(pos(0, 1), "\n"), insert(pos(0, 2), "\n"), insert(pos(1, 1), "\n")},
+			expected: []string{"a", "b", "", "c", "d"},
+			ends:     []fileeditor.BufferPos{pos(1, 0), pos(2, 0), pos(4, 0)},
+		},
+		{
+			name:  "adjacent backspaces",
+			lines: []string{"abcd"},
+			edits: []fileeditor.TextEdit{
+				{Start: pos(0, 2), End: pos(0, 3)},
+				{Start: pos(0, 1), End: pos(0, 2)},
+			},
+			expected: []string{"ad"},
+			ends:     []fileeditor.BufferPos{pos(0, 1), pos(0, 1)},
+		},
+		{
+			name:  "overlapping ranges are deleted once",
+			lines: []string{"abcdef"},
+			edits: []fileeditor.TextEdit{
+				{Start: pos(0, 1), End: pos(0, 4), Text: "X"},
+				{Start: pos(0, 2), End: pos(0, 5), Text: "Y"},
+			},
+			expected: []string{"aXf"},
+			ends:     []fileeditor.BufferPos{pos(0, 2), pos(0, 2)},
+		},
+		{
+			name:     "duplicate cursors insert once",
+			lines:    []string{"ab"},
+			edits:    []fileeditor.TextEdit{insert(pos(0, 1), "x"), insert(pos(0, 1), "x")},
+			expected: []string{"axb"},
+			ends:     []fileeditor.BufferPos{pos(0, 2), pos(0, 2)},
+		},
+		{
+			name:  "backspace joins lines",
+			lines: []string{"a", "b", "c"},
+			edits: []fileeditor.TextEdit{
+				{Start: pos(0, 1), End: pos(1, 0)},
+				{Start: pos(1, 1), End: pos(2, 0)},
+			},
+			expected: []string{"abc"},
+			ends:     []fileeditor.BufferPos{pos(0, 1), pos(0, 2)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var f fileeditor.FileEditor
+			f.FileBuffer = slices.Clone(tc.lines)
+
+			ends := f.ApplyEdits(tc.edits)
+			if !slices.Equal(f.FileBuffer, tc.expected) {
+				t.Fatalf("Expected: %q, got: %q\n", strings.Join(tc.expected, "\n"), strings.Join(f.FileBuffer, "\n"))
+			}
+			if !slices.Equal(ends, tc.ends) {
+				t.Fatalf("Expected: %v, got: %v\n", tc.ends, ends)
+			}
+		})
+	}
+}
+
+func TestFindWordOccurrences(t *testing.T) {
+	tests := []struct {
+		line     string
+		word     string
+		expected []int
+	}{
+		{line: "count := count + 1", word: "count", expected: []int{0, 9}},
+		{line: "counter := count_2 + count", word: "count", expected: []int{21}},
+		{line: "a.b(a)", word: "a", expected: []int{0, 4}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.line, func(t *testing.T) {
+			got := fileeditor.FindWordOccurrences(tc.line, tc.word, "_")
+			if !slices.Equal(got, tc.expected) {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestSearchLines(t *testing.T) {
+	lines := []string{
+		"error: one",
+		"ok",
+		"warning, error: two error",
+	}
+	re := regexp.MustCompile(`error`)
+
+	tests := []struct {
+		name     string
+		from     fileeditor.BufferPos
+		forward  bool
+		expected fileeditor.BufferPos
+	}{
+		{name: "Next on a later line", from: fileeditor.BufferPos{Line: 0, Index: 0}, forward: true, expected: fileeditor.BufferPos{Line: 2, Index: 9}},
+		{name: "Next on the same line", from: fileeditor.BufferPos{Line: 2, Index: 9}, forward: true, expected: fileeditor.BufferPos{Line: 2, Index: 20}},
+		{name: "Next wraps around", from: fileeditor.BufferPos{Line: 2, Index: 20}, forward: true, expected: fileeditor.BufferPos{Line: 0, Index: 0}},
+		{name: "Previous on the same line", from: fileeditor.BufferPos{Line: 2, Index: 20}, forward: false, expected: fileeditor.BufferPos{Line: 2, Index: 9}},
+		{name: "Previous wraps around", from: fileeditor.BufferPos{Line: 0, Index: 0}, forward: false, expected: fileeditor.BufferPos{Line: 2, Index: 20}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := fileeditor.SearchLines(lines, re, tc.from, tc.forward)
+			if !ok || got != tc.expected {
+				t.Fatalf("Expected: %v, got: %v (%v)\n", tc.expected, got, ok)
+			}
+		})
+	}
+
+	if _, ok := fileeditor.SearchLines(lines, regexp.MustCompile(`fatal`), fileeditor.BufferPos{}, true); ok {
+		t.Fatalf("Expected: no match, got: a match\n")
+	}
+
+	// the only match is under the position, so the search comes back to it
+	single := []string{"a", "error", "b"}
+	if got, ok := fileeditor.SearchLines(single, re, fileeditor.BufferPos{Line: 1, Index: 0}, true); !ok || got.Line != 1 {
+		t.Fatalf("Expected: the match under the position, got: %v (%v)\n", got, ok)
+	}
+}
+
+func TestAppendFileText(t *testing.T) {
+	tests := []struct {
+		name            string
+		lines           []string
+		partial         bool
+		data            string
+		expected        []string
+		expectedPartial bool
+	}{
+		{name: "new lines", lines: []string{"a"}, data: "b\nc\n", expected: []string{"a", "b", "c"}},
+		{name: "unfinished line", lines: []string{"a"}, data: "b\nc", expected: []string{"a", "b", "c"}, expectedPartial: true},
+		{name: "continues an unfinished line", lines: []string{"a"}, partial: true, data: "b\nc\n", expected: []string{"ab", "c"}},
+		{name: "finishes an unfinished line", lines: []string{"a"}, partial: true, data: "\n", expected: []string{"a"}},
+		{name: "empty line", lines: []string{"a"}, data: "\n", expected: []string{"a", ""}},
+		{name: "empty file", lines: []string{""}, partial: true, data: "a\n", expected: []string{"a"}},
+		{name: "carriage returns", lines: []string{"a"}, data: "b\r\nc\r\n", expected: []string{"a", "b", "c"}},
+		{name: "nothing appended", lines: []string{"a"}, partial: true, data: "", expected: []string{"a"}, expectedPartial: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, partial := fileeditor.AppendFileText(tc.lines, tc.partial, tc.data)
+			if !slices.Equal(got, tc.expected) || partial != tc.expectedPartial {
+				t.Fatalf("Expected: %q %v, got: %q %v\n", tc.expected, tc.expectedPartial, got, partial)
+			}
+		})
+	}
+}
+
+func TestStartFollowReadsLikeReadFileToBuffer(t *testing.T) {
+	f := newTestEditor(t, "log.txt", []string{"one", "two", ""}) // ends with a new line
+	expected := append([]string{}, f.FileBuffer...)
+
+	f.StartFollow()
+	defer f.StopFollow()
+
+	expectLines(t, f, expected)
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestPairEdit(t *testing.T) {
+	pos := func(index int) fileeditor.BufferPos {
+		return fileeditor.BufferPos{Index: index}
+	}
+	pairs := fileeditor.FiletypeFor("main.go").Pairs
+
+	tests := []struct {
+		name     string
+		line     string
+		index    int
+		key      byte
+		expected fileeditor.TextEdit
+		offset   int
+		ok       bool
+	}{
+		{name: "opener inserts its closer", line: "f", index: 1, key: '(',
+			expected: fileeditor.TextEdit{Start: pos(1), End: pos(1), Text: "()"}, offset: -1, ok: true},
+		{name: "not before a word", line: "x", index: 0, key: '(', ok: false},
+		{name: "closer moves over the closer", line: "f()", index: 2, key: ')',
+			expected: fileeditor.TextEdit{Start: pos(2), End: pos(2)}, offset: 1, ok: true},
+		{name: "closer without one after it", line: "f(", index: 2, key: ')', ok: false},
+		{name: "quote after a word is an apostrophe", line: "don", index: 3, key: '\'', ok: false},
+		{name: "quote", line: "x = ", index: 4, key: '"',
+			expected: fileeditor.TextEdit{Start: pos(4), End: pos(4), Text: "\"\""}, offset: -1, ok: true},
+		{name: "backspace in an empty pair", line: "f[]", index: 2, key: fileeditor.Backspace,
+			expected: fileeditor.TextEdit{Start: pos(1), End: pos(3)}, ok: true},
+		{name: "backspace in a pair that isn't empty", line: "f[a]", index: 3, key: fileeditor.Backspace, ok: false},
+		{name: "other keys", line: "", index: 0, key: 'a', ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, offset, ok := fileeditor.PairEdit(tc.line, pos(tc.index), tc.key, pairs, "_")
+			if ok != tc.ok || (ok && (got != tc.expected || offset != tc.offset)) {
+				t.Fatalf("Expected: %v %v %v, got: %v %v %v\n", tc.expected, tc.offset, tc.ok, got, offset, ok)
+			}
+		})
+	}
+}
+
+func TestMatchingBracketInCode(t *testing.T) {
+	goFt := fileeditor.FiletypeFor("main.go")
+
+	tests := []struct {
+		name     string
+		lines    []string
+		pos      fileeditor.BufferPos
+		expected fileeditor.BufferPos
+		ok       bool
+	}{
+		{
+			name:     "skips brackets in strings",
+			lines:    []string{`f("(", x)`},
+			pos:      fileeditor.BufferPos{Index: 1},
+			expected: fileeditor.BufferPos{Index: 8},
+			ok:       true,
+		},
+		{
+			name:     "skips brackets in comments",
+			lines:    []string{"if x {", "\t// }", "\t/* { */", "}"},
+			pos:      fileeditor.BufferPos{Index: 5},
+			expected: fileeditor.BufferPos{Line: 3},
+			ok:       true,
+		},
+		{
+			name:     "backwards over an escaped quote",
+			lines:    []string{`a["\"]"]`},
+			pos:      fileeditor.BufferPos{Index: 7},
+			expected: fileeditor.BufferPos{Index: 1},
+			ok:       true,
+		},
+		{
+			name:  "bracket in a string",
+			lines: []string{`s := "(x)"`},
+			pos:   fileeditor.BufferPos{Index: 6},
+			ok:    false,
+		},
+		{
+			name:  "not on a bracket",
+			lines: []string{"f(x)"},
+			pos:   fileeditor.BufferPos{Index: 0},
+			ok:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := fileeditor.MatchingBracketInCode(tc.lines, tc.pos, goFt)
+			if ok != tc.ok || (ok && got != tc.expected) {
+				t.Fatalf("Expected: %v %v, got: %v %v\n", tc.expected, tc.ok, got, ok)
+			}
+		})
+	}
+}
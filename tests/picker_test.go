@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"slices"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func pickerItems(labels ...string) []fileeditor.PickerItem {
+	items := make([]fileeditor.PickerItem, len(labels))
+	for i, label := range labels {
+		items[i] = fileeditor.PickerItem{Label: label}
+	}
+	return items
+}
+
+/*
+Returns the labels of the items that match the query of the open picker, in
+the order they are listed, by moving the selection from the top to the bottom
+*/
+func pickerLabels(f *fileeditor.FileEditor, p *fileeditor.Picker) []string {
+	for range p.Items {
+		f.HandleEvent([]byte("\x1b[A"))
+	}
+
+	labels := make([]string, 0)
+	for {
+		item, ok := p.Selected()
+		if !ok || slices.Contains(labels, item.Label) {
+			return labels
+		}
+		labels = append(labels, item.Label)
+		f.HandleEvent([]byte("\x1b[B"))
+	}
+}
+
+func TestPickerAddItems(t *testing.T) {
+	first := []string{"main.go", "readme.md", "mark.go", "cmd/main.go"}
+	second := []string{"xmain.go", "ma.go", "other.txt", "mainly.go"}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "No query", query: ""},
+		{name: "Query", query: "ma"},
+		{name: "Query that ties", query: "main"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTestEditor(t, "main.go", []string{""})
+
+			// every item at once
+			all := fileeditor.NewPicker("Files", pickerItems(append(first, second...)...), nil)
+			f.OpenPicker(all)
+			typeKeys(f, tc.query)
+			expected := pickerLabels(f, all)
+			f.ClosePicker()
+
+			// the second items are added after the query is typed
+			p := fileeditor.NewPicker("Files", pickerItems(first...), nil)
+			f.OpenPicker(p)
+			typeKeys(f, tc.query)
+			p.AddItems(pickerItems(second...))
+
+			if got := pickerLabels(f, p); !slices.Equal(got, expected) {
+				t.Fatalf("Expected: %q, got: %q\n", expected, got)
+			}
+		})
+	}
+}
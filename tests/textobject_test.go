@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+func TestResolveTextObject(t *testing.T) {
+	var f fileeditor.FileEditor
+	f.FileBuffer = []string{
+		`call(a, "x \" (y)", f(b[1]))  next`,
+		"func() {",
+		"\treturn 1",
+		"}",
+		"",
+		"One two. Three four!  Five",
+		"<div><p>hi <b>there</b></p></div>",
+	}
+
+	tests := []struct {
+		name     string
+		pos      fileeditor.BufferPos
+		object   byte
+		around   bool
+		count    int
+		expected string
+		ok       bool
+	}{
+		{name: "Inner word", pos: fileeditor.BufferPos{Line: 0, Index: 1}, object: 'w', count: 1, expected: "call", ok: true},
+		{name: "Around word", pos: fileeditor.BufferPos{Line: 0, Index: 32}, object: 'w', around: true, count: 1, expected: "  next", ok: true},
+		{name: "Inner WORD", pos: fileeditor.BufferPos{Line: 0, Index: 1}, object: 'W', count: 1, expected: "call(a,", ok: true},
+		{name: "Quotes with an escaped quote", pos: fileeditor.BufferPos{Line: 0, Index: 10}, object: '"', count: 1, expected: `x \" (y)`, ok: true},
+		{name: "Quotes after the position", pos: fileeditor.BufferPos{Line: 0, Index: 0}, object: '"', around: true, count: 1, expected: ` "x \" (y)"`, ok: true},
+		{name: "Parentheses ignore quoted escapes", pos: fileeditor.BufferPos{Line: 0, Index: 24}, object: '(', count: 1, expected: "b[1]", ok: true},
+		{name: "Parentheses with a count", pos: fileeditor.BufferPos{Line: 0, Index: 24}, object: 'b', count: 2, expected: `a, "x \" (y)", f(b[1])`, ok: true},
+		{name: "Brackets around", pos: fileeditor.BufferPos{Line: 0, Index: 25}, object: ']', around: true, count: 1, expected: "[1]", ok: true},
+		{name: "Braces over lines", pos: fileeditor.BufferPos{Line: 2, Index: 2}, object: '{', count: 1, expected: "\treturn 1\n", ok: true},
+		{name: "On a closing brace", pos: fileeditor.BufferPos{Line: 3, Index: 0}, object: 'B', around: true, count: 1, expected: "{\n\treturn 1\n}", ok: true},
+		{name: "No brackets", pos: fileeditor.BufferPos{Line: 5, Index: 0}, object: '(', count: 1, ok: false},
+		{name: "Paragraph", pos: fileeditor.BufferPos{Line: 2, Index: 0}, object: 'p', count: 1, expected: f.FileBuffer[0] + "\nfunc() {\n\treturn 1\n}", ok: true},
+		{name: "Sentence", pos: fileeditor.BufferPos{Line: 5, Index: 10}, object: 's', count: 1, expected: "Three four!", ok: true},
+		{name: "Around sentence", pos: fileeditor.BufferPos{Line: 5, Index: 10}, object: 's', around: true, count: 1, expected: "Three four!  ", ok: true},
+		{name: "Inner tag", pos: fileeditor.BufferPos{Line: 6, Index: 17}, object: 't', count: 1, expected: "there", ok: true},
+		{name: "Tag with a count", pos: fileeditor.BufferPos{Line: 6, Index: 17}, object: 't', around: true, count: 2, expected: "<p>hi <b>there</b></p>", ok: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, ok := fileeditor.ResolveTextObject(f.FileBuffer, tc.pos, tc.object, tc.around, tc.count, "_")
+			if ok != tc.ok {
+				t.Fatalf("Expected: %v, got: %v\n", tc.ok, ok)
+			}
+			if !ok {
+				return
+			}
+
+			if got := f.TextInRange(r.Start, r.End); got != tc.expected {
+				t.Fatalf("Expected: %q, got: %q\n", tc.expected, got)
+			}
+		})
+	}
+}
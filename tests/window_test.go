@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"slices"
+	"testing"
+
+	fileeditor "github.com/Asiandayboy/CLITextEditor/fileEditor"
+)
+
+/*
+Returns the rectangle of the focused window, which is loaded into the editor area
+*/
+func focusedRect(f *fileeditor.FileEditor) fileeditor.Rect {
+	return fileeditor.Rect{X: f.EditorX, Y: f.EditorY, Width: f.EditorWidth, Height: f.EditorHeight}
+}
+
+func expectWindows(t *testing.T, f *fileeditor.FileEditor, expected []fileeditor.Rect, focused int) {
+	t.Helper()
+
+	got := f.WindowRects()
+	if !slices.Equal(got, expected) {
+		t.Fatalf("Expected: %v, got: %v\n", expected, got)
+	}
+	if rect := focusedRect(f); rect != expected[focused] {
+		t.Fatalf("Expected: focus on %v, got: %v\n", expected[focused], rect)
+	}
+}
+
+func TestSplitWindow(t *testing.T) {
+	// the windows are laid out in 80x21, above the status bar
+	f := newTestEditor(t, "main.go", []string{"package main"})
+	expectWindows(t, f, []fileeditor.Rect{{X: 0, Y: 0, Width: 80, Height: 21}}, 0)
+
+	f.SplitWindow(fileeditor.SplitVertical)
+	expectWindows(t, f, []fileeditor.Rect{
+		{X: 0, Y: 0, Width: 39, Height: 21},
+		{X: 40, Y: 0, Width: 40, Height: 21},
+	}, 1)
+
+	// nested in the right window
+	f.SplitWindow(fileeditor.SplitHorizontal)
+	expectWindows(t, f, []fileeditor.Rect{
+		{X: 0, Y: 0, Width: 39, Height: 21},
+		{X: 40, Y: 0, Width: 40, Height: 10},
+		{X: 40, Y: 11, Width: 40, Height: 10},
+	}, 2)
+
+	f.SplitWindow(fileeditor.SplitVertical)
+	expectWindows(t, f, []fileeditor.Rect{
+		{X: 0, Y: 0, Width: 39, Height: 21},
+		{X: 40, Y: 0, Width: 40, Height: 10},
+		{X: 40, Y: 11, Width: 19, Height: 10},
+		{X: 60, Y: 11, Width: 20, Height: 10},
+	}, 3)
+
+	// too narrow to split again
+	f.SplitWindow(fileeditor.SplitVertical)
+	if n := len(f.WindowRects()); n != 4 {
+		t.Fatalf("Expected: %v, got: %v\n", 4, n)
+	}
+}
+
+func TestResizeWindow(t *testing.T) {
+	f := newTestEditor(t, "main.go", []string{"package main"})
+	f.ResizeWindow(5) // a window that isn't split can't be resized
+	expectWindows(t, f, []fileeditor.Rect{{X: 0, Y: 0, Width: 80, Height: 21}}, 0)
+
+	f.SplitWindow(fileeditor.SplitHorizontal)
+	tests := []struct {
+		name     string
+		amount   int
+		expected []fileeditor.Rect
+	}{
+		{
+			name:   "grow",
+			amount: 4,
+			expected: []fileeditor.Rect{
+				{X: 0, Y: 0, Width: 80, Height: 6},
+				{X: 0, Y: 7, Width: 80, Height: 14},
+			},
+		},
+		{
+			name:   "shrink",
+			amount: -6,
+			expected: []fileeditor.Rect{
+				{X: 0, Y: 0, Width: 80, Height: 12},
+				{X: 0, Y: 13, Width: 80, Height: 8},
+			},
+		},
+		{
+			name:   "grow to the limit",
+			amount: 100,
+			expected: []fileeditor.Rect{
+				{X: 0, Y: 0, Width: 80, Height: 3},
+				{X: 0, Y: 4, Width: 80, Height: 17},
+			},
+		},
+		{
+			name:   "shrink to the limit",
+			amount: -100,
+			expected: []fileeditor.Rect{
+				{X: 0, Y: 0, Width: 80, Height: 17},
+				{X: 0, Y: 18, Width: 80, Height: 3},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f.ResizeWindow(tc.amount)
+			expectWindows(t, f, tc.expected, 1)
+		})
+	}
+}
+
+func TestCloseWindow(t *testing.T) {
+	f := newTestEditor(t, "main.go", []string{"package main"})
+	f.SplitWindow(fileeditor.SplitVertical)
+	f.SplitWindow(fileeditor.SplitHorizontal)
+
+	// the sibling of the first window is a split, which takes the whole area
+	f.FocusNextWindow()
+	f.CloseWindow()
+	expectWindows(t, f, []fileeditor.Rect{
+		{X: 0, Y: 0, Width: 80, Height: 10},
+		{X: 0, Y: 11, Width: 80, Height: 10},
+	}, 0)
+
+	// the last window of the split takes the whole area
+	f.CloseWindow()
+	expectWindows(t, f, []fileeditor.Rect{{X: 0, Y: 0, Width: 80, Height: 21}}, 0)
+
+	f.CloseWindow()
+	expectWindows(t, f, []fileeditor.Rect{{X: 0, Y: 0, Width: 80, Height: 21}}, 0)
+}
+
+func TestRectContains(t *testing.T) {
+	r := fileeditor.Rect{X: 40, Y: 11, Width: 40, Height: 10}
+
+	tests := []struct {
+		name     string
+		x, y     int
+		expected bool
+	}{
+		{name: "top left", x: 41, y: 12, expected: true},
+		{name: "bottom right", x: 80, y: 21, expected: true},
+		{name: "left of it", x: 40, y: 12, expected: false},
+		{name: "above it", x: 41, y: 11, expected: false},
+		{name: "right of it", x: 81, y: 21, expected: false},
+		{name: "below it", x: 80, y: 22, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := r.Contains(tc.x, tc.y); got != tc.expected {
+				t.Fatalf("Expected: %v, got: %v\n", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+/*
+A simple line diffing package based on Myers' O(ND) difference algorithm
+*/
+package diff
+
+/*
+Represents a contiguous region of lines that differ between the old
+and the new lines. All values are 0-indexed.
+
+A hunk with OldLines = 0 is a pure insertion, and a hunk with NewLines = 0
+is a pure deletion, in which case NewStart is the index of the line
+that follows the deleted lines in the new lines
+*/
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+}
+
+/*
+The most edits the algorithm looks for before giving up. The trace it keeps
+grows with the square of the edits, so lines that differ more than this are
+reported as a single hunk that replaces all of them
+*/
+const maxEditDistance int = 1000
+
+// Returns true if the hunk only adds lines
+func (h Hunk) IsInsertion() bool {
+	return h.OldLines == 0
+}
+
+// Returns true if the hunk only removes lines
+func (h Hunk) IsDeletion() bool {
+	return h.NewLines == 0
+}
+
+/*
+Returns the hunks needed to turn the old lines (a) into the new lines (b).
+
+Common prefixes and suffixes are trimmed before running the algorithm, so
+diffing two mostly equal buffers, like a buffer being typed in against its
+saved version, stays cheap
+*/
+func Lines(a, b []string) []Hunk {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	hunks := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for i := range hunks {
+		hunks[i].OldStart += prefix
+		hunks[i].NewStart += prefix
+	}
+
+	return hunks
+}
+
+/*
+Runs the greedy forward Myers algorithm, keeping the part of every V array that
+was read at each step, so that the shortest edit script can be walked backwards
+once the end is reached
+*/
+func myers(a, b []string) []Hunk {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	if n == 0 || m == 0 {
+		return []Hunk{{OldStart: 0, OldLines: n, NewStart: 0, NewLines: m}}
+	}
+
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	trace := make([][]int, 0)
+
+	var found bool
+	for d := 0; d <= max && !found; d++ {
+		if d > maxEditDistance {
+			return []Hunk{{OldStart: 0, OldLines: n, NewStart: 0, NewLines: m}}
+		}
+
+		// step d only reads the diagonals -d to d
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // moving down; an insertion
+			} else {
+				x = v[offset+k-1] + 1 // moving right; a deletion
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	/*
+		Walk the trace backwards from (n, m) and mark which lines of a were
+		deleted and which lines of b were inserted
+	*/
+	deleted := make([]bool, n)
+	inserted := make([]bool, m)
+
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d] // v[d+k] is diagonal k
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[d+k-1] < v[d+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[d+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY { // diagonal, equal lines
+			x--
+			y--
+		}
+
+		if x == prevX {
+			inserted[prevY] = true
+		} else {
+			deleted[prevX] = true
+		}
+
+		x, y = prevX, prevY
+	}
+
+	return groupHunks(deleted, inserted)
+}
+
+/*
+Walks both sides in lockstep and collects each run of deleted
+and/or inserted lines into a single hunk
+*/
+func groupHunks(deleted, inserted []bool) []Hunk {
+	hunks := make([]Hunk, 0)
+
+	i, j := 0, 0
+	for i < len(deleted) || j < len(inserted) {
+		if i < len(deleted) && j < len(inserted) && !deleted[i] && !inserted[j] {
+			i++
+			j++
+			continue
+		}
+
+		h := Hunk{OldStart: i, NewStart: j}
+		for i < len(deleted) && deleted[i] {
+			h.OldLines++
+			i++
+		}
+		for j < len(inserted) && inserted[j] {
+			h.NewLines++
+			j++
+		}
+
+		if h.OldLines == 0 && h.NewLines == 0 { // lengths don't line up; nothing left to pair
+			break
+		}
+
+		hunks = append(hunks, h)
+	}
+
+	return hunks
+}
@@ -0,0 +1,119 @@
+/*
+A small fuzzy matching package for filtering and ranking strings, like file
+paths, by a pattern typed by the user
+*/
+package fuzzy
+
+const (
+	scoreMatch         int = 16
+	bonusConsecutive   int = 16
+	bonusBoundary      int = 24 // the match starts a word, like after a "/" or "_"
+	bonusCamelCase     int = 16 // the match is an uppercase letter following a lowercase one
+	bonusBasename      int = 8  // the match is in the part of a path after the last "/"
+	penaltyGap         int = 2  // for each character skipped between two matches
+	penaltyLeading     int = 1  // for each character before the first match
+	maxPenaltyLeading  int = 12
+	penaltyLengthShift int = 4 // longer strings lose a point every 2^shift characters
+)
+
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 32
+	}
+	return c
+}
+
+func isSeparator(c byte) bool {
+	return c == '/' || c == '\\' || c == '_' || c == '-' || c == '.' || c == ' '
+}
+
+/*
+Matches the pattern against the string, ignoring case. Every character of the
+pattern has to appear in the string in the same order, but not necessarily
+next to each other.
+
+Returns the score of the match (higher is better) and the indicies of the
+matched characters in the string. The ok flag is false if the string doesn't
+match. An empty pattern matches every string with a score of 0
+*/
+func Match(pattern, str string) (score int, positions []int, ok bool) {
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+
+	// find where the first occurrence of the pattern ends
+	pi := 0
+	end := -1
+	for i := 0; i < len(str); i++ {
+		if toLower(str[i]) == toLower(pattern[pi]) {
+			pi++
+			if pi == len(pattern) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	// walk back from the end to find the shortest match that ends there
+	pi = len(pattern) - 1
+	start := end
+	for i := end; i >= 0; i-- {
+		if toLower(str[i]) == toLower(pattern[pi]) {
+			pi--
+			if pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	lastSlash := -1
+	for i := len(str) - 1; i >= 0; i-- {
+		if str[i] == '/' || str[i] == '\\' {
+			lastSlash = i
+			break
+		}
+	}
+
+	positions = make([]int, 0, len(pattern))
+	pi = 0
+	prev := -1
+	for i := start; i <= end && pi < len(pattern); i++ {
+		if toLower(str[i]) != toLower(pattern[pi]) {
+			continue
+		}
+
+		score += scoreMatch
+		if prev >= 0 && prev == i-1 {
+			score += bonusConsecutive
+		} else if prev >= 0 {
+			score -= penaltyGap * (i - prev - 1)
+		}
+
+		if i == 0 || isSeparator(str[i-1]) {
+			score += bonusBoundary
+		} else if str[i] >= 'A' && str[i] <= 'Z' && str[i-1] >= 'a' && str[i-1] <= 'z' {
+			score += bonusCamelCase
+		}
+
+		if i > lastSlash {
+			score += bonusBasename
+		}
+
+		positions = append(positions, i)
+		prev = i
+		pi++
+	}
+
+	leading := start * penaltyLeading
+	if leading > maxPenaltyLeading {
+		leading = maxPenaltyLeading
+	}
+	score -= leading
+	score -= len(str) >> penaltyLengthShift
+
+	return score, positions, true
+}